@@ -0,0 +1,76 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cfg "sigs.k8s.io/controller-runtime/pkg/config/v1alpha1"
+)
+
+// ControllerConfig configures a single merge controller.
+type ControllerConfig struct {
+	// Enabled enables the controller. The controllers are enabled by default.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// MaxConcurrentReconciles is the maximum number of concurrent reconciles of the controller. Defaults to 1.
+	// +optional
+	MaxConcurrentReconciles int `json:"maxConcurrentReconciles,omitempty"`
+}
+
+// IsEnabled checks if the controller is enabled.
+func (c ControllerConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// +kubebuilder:object:root=true
+
+// ControllerManagerConfig is the Schema for the configuration file of the controller manager.
+type ControllerManagerConfig struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// ControllerManagerConfigurationSpec returns the configurations for controllers
+	cfg.ControllerManagerConfigurationSpec `json:",inline"`
+
+	// Controllers configures the merge controllers by the kind of the resources which they merge,
+	// e.g. ConsulServiceRoute. The controllers which aren't listed are enabled with the default options.
+	// +optional
+	Controllers map[string]ControllerConfig `json:"controllers,omitempty"`
+
+	// Namespaces are the namespaces watched by the controllers. All namespaces are watched when it is empty.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// LabelPrefix is the prefix of the labels which select the merge destinations of the resources,
+	// e.g. <prefix>/service-router. Defaults to service.consul.k8s.nativechat.com.
+	// +optional
+	LabelPrefix string `json:"labelPrefix,omitempty"`
+
+	// FinalizerPrefix is the domain of the finalizer of the merged resources,
+	// e.g. finalizer.<prefix>. Defaults to service.consul.k8s.nativechat.com.
+	// +optional
+	FinalizerPrefix string `json:"finalizerPrefix,omitempty"`
+}
+
+// GetControllerConfig returns the configuration of the controller for the kind.
+func (c *ControllerManagerConfig) GetControllerConfig(kind string) ControllerConfig {
+	return c.Controllers[kind]
+}
+
+func init() {
+	SchemeBuilder.Register(&ControllerManagerConfig{})
+}
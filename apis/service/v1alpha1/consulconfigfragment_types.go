@@ -0,0 +1,132 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// ConfigFragmentTarget is a reference to the resource into which a config fragment is merged.
+type ConfigFragmentTarget struct {
+	// APIVersion is the API version of the resource, e.g. consul.hashicorp.com/v1alpha1.
+	// Only the resources of the consul.hashicorp.com group are supported.
+	// +kubebuilder:validation:Pattern=`^consul\.hashicorp\.com/`
+	APIVersion string `json:"apiVersion"`
+
+	// Kind is the kind of the resource, e.g. ServiceDefaults.
+	Kind string `json:"kind"`
+
+	// Name is the name of the resource. The resource must be in the namespace of the fragment.
+	Name string `json:"name"`
+}
+
+// ConsulConfigFragmentSpec defines the desired state of ConsulConfigFragment
+type ConsulConfigFragmentSpec struct {
+	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	Target ConfigFragmentTarget `json:"target"`
+
+	// Path is the dot separated path of the list or map field of the target into which the fragment is merged,
+	// e.g. spec.expose.paths.
+	// +kubebuilder:validation:Pattern=`^[^.]+(\.[^.]+)*$`
+	Path string `json:"path"`
+
+	// Fragment is the JSON list or object which is merged into the field.
+	Fragment apiextensionsv1.JSON `json:"fragment"`
+
+	// MergeStrategy defines how the entries of the fragment are merged with the entries of the field.
+	// Append adds the entries, Upsert replaces the entries with the same key and RejectDuplicates
+	// reports a conflict for an entry which is already in the field.
+	// All fragments for the same path of a target must use the same strategy and merge key.
+	// +kubebuilder:validation:Enum=Append;Upsert;RejectDuplicates
+	// +kubebuilder:default=Append
+	MergeStrategy string `json:"mergeStrategy,omitempty"`
+
+	// MergeKey is the field which identifies the entries of a list. It is required by the Upsert strategy.
+	// The RejectDuplicates strategy compares the whole entries when it isn't set. The keys of a map are used for map fields.
+	// +optional
+	MergeKey string `json:"mergeKey,omitempty"`
+}
+
+// ConsulConfigFragmentStatus defines the observed state of ConsulConfigFragment
+type ConsulConfigFragmentStatus struct {
+	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	UpdatedAt  string `json:"updatedAt,omitempty"`
+	ContentSHA string `json:"contentSha,omitempty"`
+
+	// ObservedGeneration is the generation of the fragment which was last reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Destination is the resource into which the fragment is merged.
+	// +optional
+	Destination *DestinationReference `json:"destination,omitempty"`
+
+	// Conditions describe the outcome of the merge of the fragment into its target.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// ConsulConfigFragment is the Schema for the consulconfigfragments API
+type ConsulConfigFragment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsulConfigFragmentSpec   `json:"spec,omitempty"`
+	Status ConsulConfigFragmentStatus `json:"status,omitempty"`
+}
+
+// GetContent returns the spec of the fragment.
+func (in *ConsulConfigFragment) GetContent() interface{} {
+	return in.Spec
+}
+
+// GetMergeStatus returns the status fields of the fragment which are set by the merge.
+func (in *ConsulConfigFragment) GetMergeStatus() MergeStatus {
+	return MergeStatus{
+		UpdatedAt:          &in.Status.UpdatedAt,
+		ContentSHA:         &in.Status.ContentSHA,
+		Destination:        &in.Status.Destination,
+		ObservedGeneration: &in.Status.ObservedGeneration,
+		Conditions:         &in.Status.Conditions,
+	}
+}
+
+// +kubebuilder:object:root=true
+
+// ConsulConfigFragmentList contains a list of ConsulConfigFragment
+type ConsulConfigFragmentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsulConfigFragment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsulConfigFragment{}, &ConsulConfigFragmentList{})
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// ConsulIngressGatewayServiceSpec defines the desired state of ConsulIngressGatewayService
+type ConsulIngressGatewayServiceSpec struct {
+	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// Port is the port of the listener to which the service is added.
+	Port int `json:"port"`
+
+	// Protocol is the protocol of the listener to which the service is added.
+	// All services added to the same port must use the same protocol.
+	// +kubebuilder:validation:Enum=tcp;http;http2;grpc
+	// +kubebuilder:default=tcp
+	Protocol string `json:"protocol,omitempty"`
+
+	Service consulk8s.IngressService `json:"service"`
+}
+
+// ConsulIngressGatewayServiceStatus defines the observed state of ConsulIngressGatewayService
+type ConsulIngressGatewayServiceStatus struct {
+	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	UpdatedAt  string `json:"updatedAt,omitempty"`
+	ContentSHA string `json:"contentSha,omitempty"`
+
+	// ObservedGeneration is the generation of the ingress gateway service which was last reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Destination is the ingress gateway into which the service is merged.
+	// +optional
+	Destination *DestinationReference `json:"destination,omitempty"`
+
+	// Conditions describe the outcome of the merge of the service into the ingress gateway.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// ConsulIngressGatewayService is the Schema for the consulingressgatewayservices API
+type ConsulIngressGatewayService struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsulIngressGatewayServiceSpec   `json:"spec,omitempty"`
+	Status ConsulIngressGatewayServiceStatus `json:"status,omitempty"`
+}
+
+// GetContent returns the spec of the ingress gateway service.
+func (in *ConsulIngressGatewayService) GetContent() interface{} {
+	return in.Spec
+}
+
+// GetMergeStatus returns the status fields of the ingress gateway service which are set by the merge.
+func (in *ConsulIngressGatewayService) GetMergeStatus() MergeStatus {
+	return MergeStatus{
+		UpdatedAt:          &in.Status.UpdatedAt,
+		ContentSHA:         &in.Status.ContentSHA,
+		Destination:        &in.Status.Destination,
+		ObservedGeneration: &in.Status.ObservedGeneration,
+		Conditions:         &in.Status.Conditions,
+	}
+}
+
+// +kubebuilder:object:root=true
+
+// ConsulIngressGatewayServiceList contains a list of ConsulIngressGatewayService
+type ConsulIngressGatewayServiceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsulIngressGatewayService `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsulIngressGatewayService{}, &ConsulIngressGatewayServiceList{})
+}
@@ -39,6 +39,20 @@ type ConsulServiceIntentionsSourceStatus struct {
 
 	UpdatedAt  string `json:"updatedAt,omitempty"`
 	ContentSHA string `json:"contentSha,omitempty"`
+
+	// ObservedGeneration is the generation of the source which was last reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Destination is the service intentions into which the source is merged.
+	// +optional
+	Destination *DestinationReference `json:"destination,omitempty"`
+
+	// Conditions describe the outcome of the merge of the source into the service intentions.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -53,6 +67,22 @@ type ConsulServiceIntentionsSource struct {
 	Status ConsulServiceIntentionsSourceStatus `json:"status,omitempty"`
 }
 
+// GetContent returns the spec of the intentions source.
+func (in *ConsulServiceIntentionsSource) GetContent() interface{} {
+	return in.Spec
+}
+
+// GetMergeStatus returns the status fields of the intentions source which are set by the merge.
+func (in *ConsulServiceIntentionsSource) GetMergeStatus() MergeStatus {
+	return MergeStatus{
+		UpdatedAt:          &in.Status.UpdatedAt,
+		ContentSHA:         &in.Status.ContentSHA,
+		Destination:        &in.Status.Destination,
+		ObservedGeneration: &in.Status.ObservedGeneration,
+		Conditions:         &in.Status.Conditions,
+	}
+}
+
 // +kubebuilder:object:root=true
 
 // ConsulServiceIntentionsSourceList contains a list of ConsulServiceIntentionsSource
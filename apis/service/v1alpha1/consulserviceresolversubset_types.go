@@ -0,0 +1,86 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// ConsulServiceResolverSubsetSpec defines the desired state of ConsulServiceResolverSubset
+type ConsulServiceResolverSubsetSpec struct {
+	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// Subsets is a map of subset name to subset definition which is merged into the subsets of the service resolver.
+	Subsets consulk8s.ServiceResolverSubsetMap `json:"subsets"`
+}
+
+// ConsulServiceResolverSubsetStatus defines the observed state of ConsulServiceResolverSubset
+type ConsulServiceResolverSubsetStatus struct {
+	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	UpdatedAt  string `json:"updatedAt,omitempty"`
+	ContentSHA string `json:"contentSha,omitempty"`
+
+	// Destination is the service resolver into which the subsets are merged.
+	// +optional
+	Destination *DestinationReference `json:"destination,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// ConsulServiceResolverSubset is the Schema for the consulserviceresolversubsets API
+type ConsulServiceResolverSubset struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsulServiceResolverSubsetSpec   `json:"spec,omitempty"`
+	Status ConsulServiceResolverSubsetStatus `json:"status,omitempty"`
+}
+
+// GetContent returns the spec of the resolver subset.
+func (in *ConsulServiceResolverSubset) GetContent() interface{} {
+	return in.Spec
+}
+
+// GetMergeStatus returns the status fields of the resolver subset which are set by the merge.
+func (in *ConsulServiceResolverSubset) GetMergeStatus() MergeStatus {
+	return MergeStatus{
+		UpdatedAt:   &in.Status.UpdatedAt,
+		ContentSHA:  &in.Status.ContentSHA,
+		Destination: &in.Status.Destination,
+	}
+}
+
+// +kubebuilder:object:root=true
+
+// ConsulServiceResolverSubsetList contains a list of ConsulServiceResolverSubset
+type ConsulServiceResolverSubsetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsulServiceResolverSubset `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsulServiceResolverSubset{}, &ConsulServiceResolverSubsetList{})
+}
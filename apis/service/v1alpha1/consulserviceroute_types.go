@@ -30,6 +30,11 @@ type ConsulServiceRouteSpec struct {
 	// Important: Run "make" to regenerate code after modifying this file
 
 	Route consulk8s.ServiceRoute `json:"route"`
+
+	// Priority defines the position of the route in the service router.
+	// Routes with higher priority are placed first and routes with the same priority are sorted by name.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
 }
 
 // ConsulServiceRouteStatus defines the observed state of ConsulServiceRoute
@@ -39,6 +44,20 @@ type ConsulServiceRouteStatus struct {
 
 	UpdatedAt  string `json:"updatedAt,omitempty"`
 	ContentSHA string `json:"contentSha,omitempty"`
+
+	// ObservedGeneration is the generation of the route which was last reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Destination is the service router into which the route is merged.
+	// +optional
+	Destination *DestinationReference `json:"destination,omitempty"`
+
+	// Conditions describe the outcome of the merge of the route into the service router.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -53,6 +72,22 @@ type ConsulServiceRoute struct {
 	Status ConsulServiceRouteStatus `json:"status,omitempty"`
 }
 
+// GetContent returns the spec of the route.
+func (in *ConsulServiceRoute) GetContent() interface{} {
+	return in.Spec
+}
+
+// GetMergeStatus returns the status fields of the route which are set by the merge.
+func (in *ConsulServiceRoute) GetMergeStatus() MergeStatus {
+	return MergeStatus{
+		UpdatedAt:          &in.Status.UpdatedAt,
+		ContentSHA:         &in.Status.ContentSHA,
+		Destination:        &in.Status.Destination,
+		ObservedGeneration: &in.Status.ObservedGeneration,
+		Conditions:         &in.Status.Conditions,
+	}
+}
+
 // +kubebuilder:object:root=true
 
 // ConsulServiceRouteList contains a list of ConsulServiceRoute
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// ConsulServiceSplitSpec defines the desired state of ConsulServiceSplit
+type ConsulServiceSplitSpec struct {
+	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	Split consulk8s.ServiceSplit `json:"split"`
+
+	// Default marks the split which receives the remainder of the weights,
+	// so that the weights in the service splitter add up to 100.
+	// The weight of the default split is ignored.
+	Default bool `json:"default,omitempty"`
+}
+
+// ConsulServiceSplitStatus defines the observed state of ConsulServiceSplit
+type ConsulServiceSplitStatus struct {
+	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	UpdatedAt  string `json:"updatedAt,omitempty"`
+	ContentSHA string `json:"contentSha,omitempty"`
+
+	// Destination is the service splitter into which the split is merged.
+	// +optional
+	Destination *DestinationReference `json:"destination,omitempty"`
+
+	// WeightPolicy is the outcome of the weight policy for this split.
+	// It is one of Accepted, RemainderAssigned or Rejected.
+	WeightPolicy string `json:"weightPolicy,omitempty"`
+	// EffectiveWeight is the weight written in the service splitter for this split.
+	EffectiveWeight string `json:"effectiveWeight,omitempty"`
+	// Message explains the weight policy outcome.
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// ConsulServiceSplit is the Schema for the consulservicesplits API
+type ConsulServiceSplit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsulServiceSplitSpec   `json:"spec,omitempty"`
+	Status ConsulServiceSplitStatus `json:"status,omitempty"`
+}
+
+// GetContent returns the spec of the split.
+func (in *ConsulServiceSplit) GetContent() interface{} {
+	return in.Spec
+}
+
+// GetMergeStatus returns the status fields of the split which are set by the merge.
+func (in *ConsulServiceSplit) GetMergeStatus() MergeStatus {
+	return MergeStatus{
+		UpdatedAt:   &in.Status.UpdatedAt,
+		ContentSHA:  &in.Status.ContentSHA,
+		Destination: &in.Status.Destination,
+	}
+}
+
+// +kubebuilder:object:root=true
+
+// ConsulServiceSplitList contains a list of ConsulServiceSplit
+type ConsulServiceSplitList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsulServiceSplit `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsulServiceSplit{}, &ConsulServiceSplitList{})
+}
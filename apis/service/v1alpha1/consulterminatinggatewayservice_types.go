@@ -0,0 +1,85 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// ConsulTerminatingGatewayServiceSpec defines the desired state of ConsulTerminatingGatewayService
+type ConsulTerminatingGatewayServiceSpec struct {
+	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	Service consulk8s.LinkedService `json:"service"`
+}
+
+// ConsulTerminatingGatewayServiceStatus defines the observed state of ConsulTerminatingGatewayService
+type ConsulTerminatingGatewayServiceStatus struct {
+	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	UpdatedAt  string `json:"updatedAt,omitempty"`
+	ContentSHA string `json:"contentSha,omitempty"`
+
+	// Destination is the terminating gateway into which the service is merged.
+	// +optional
+	Destination *DestinationReference `json:"destination,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// ConsulTerminatingGatewayService is the Schema for the consulterminatinggatewayservices API
+type ConsulTerminatingGatewayService struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsulTerminatingGatewayServiceSpec   `json:"spec,omitempty"`
+	Status ConsulTerminatingGatewayServiceStatus `json:"status,omitempty"`
+}
+
+// GetContent returns the spec of the terminating gateway service.
+func (in *ConsulTerminatingGatewayService) GetContent() interface{} {
+	return in.Spec
+}
+
+// GetMergeStatus returns the status fields of the terminating gateway service which are set by the merge.
+func (in *ConsulTerminatingGatewayService) GetMergeStatus() MergeStatus {
+	return MergeStatus{
+		UpdatedAt:   &in.Status.UpdatedAt,
+		ContentSHA:  &in.Status.ContentSHA,
+		Destination: &in.Status.Destination,
+	}
+}
+
+// +kubebuilder:object:root=true
+
+// ConsulTerminatingGatewayServiceList contains a list of ConsulTerminatingGatewayService
+type ConsulTerminatingGatewayServiceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConsulTerminatingGatewayService `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConsulTerminatingGatewayService{}, &ConsulTerminatingGatewayServiceList{})
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MergeSource is implemented by the resources which are merged into a destination.
+// +kubebuilder:object:generate=false
+type MergeSource interface {
+	// GetContent returns the part of the resource which is merged into the destination.
+	// Its SHA detects the changes of the resource since its last merge.
+	GetContent() interface{}
+
+	// GetMergeStatus returns the status fields of the resource which are set by the merge.
+	GetMergeStatus() MergeStatus
+}
+
+// MergeStatus points to the status fields of a resource which are set by the merge.
+// The fields which the status of the resource doesn't have are nil.
+// +kubebuilder:object:generate=false
+type MergeStatus struct {
+	UpdatedAt          *string
+	ContentSHA         *string
+	ObservedGeneration *int64
+	Destination        **DestinationReference
+	Conditions         *[]metav1.Condition
+}
+
+var (
+	_ MergeSource = &ConsulConfigFragment{}
+	_ MergeSource = &ConsulIngressGatewayService{}
+	_ MergeSource = &ConsulServiceIntentionsSource{}
+	_ MergeSource = &ConsulServiceResolverSubset{}
+	_ MergeSource = &ConsulServiceRoute{}
+	_ MergeSource = &ConsulServiceSplit{}
+	_ MergeSource = &ConsulTerminatingGatewayService{}
+)
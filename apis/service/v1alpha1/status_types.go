@@ -0,0 +1,74 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+const (
+	// ConditionTypeMerged is the type of the condition which is true when the resource is merged into its destination.
+	ConditionTypeMerged = "Merged"
+
+	// ConditionTypeSynced mirrors the Synced condition which consul-k8s sets on the destination of the resource.
+	ConditionTypeSynced = "Synced"
+
+	// ConditionTypeConflict is the type of the condition which is true when the resource
+	// can't be merged as defined because it conflicts with another resource.
+	ConditionTypeConflict = "Conflict"
+
+	// ConditionTypeInvalid is the type of the condition which is true when the resource can't be merged
+	// because its definition is invalid.
+	ConditionTypeInvalid = "Invalid"
+)
+
+const (
+	// ConditionReasonMerged is the reason for a resource which is merged into its destination.
+	ConditionReasonMerged = "Merged"
+
+	// ConditionReasonMergeFailed is the reason for a resource whose merge has failed and will be retried.
+	ConditionReasonMergeFailed = "MergeFailed"
+
+	// ConditionReasonMergeConflict is the reason for a resource which conflicts with another resource with the same destination.
+	ConditionReasonMergeConflict = "MergeConflict"
+
+	// ConditionReasonNoConflict is the reason for a resource which doesn't conflict with other resources.
+	ConditionReasonNoConflict = "NoConflict"
+
+	// ConditionReasonInvalidDefinition is the reason for a resource whose definition can't be merged.
+	ConditionReasonInvalidDefinition = "InvalidDefinition"
+
+	// ConditionReasonMissingLabel is the reason for a resource without the label which selects its destination.
+	ConditionReasonMissingLabel = "MissingLabel"
+
+	// ConditionReasonValid is the reason for a resource whose definition is valid.
+	ConditionReasonValid = "Valid"
+
+	// ConditionReasonUnmanagedDestination is the reason for a resource whose destination wasn't created by the controller
+	// and can't be changed in the strict adoption mode.
+	ConditionReasonUnmanagedDestination = "UnmanagedDestination"
+
+	// ConditionReasonSyncPending is the reason for a resource whose destination isn't synced by consul-k8s yet.
+	ConditionReasonSyncPending = "SyncPending"
+
+	// ConditionReasonWrittenToConsul is the reason for a resource whose destination is written directly to the Consul API.
+	ConditionReasonWrittenToConsul = "WrittenToConsul"
+)
+
+// DestinationReference is a reference to the object into which a resource is merged.
+type DestinationReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+}
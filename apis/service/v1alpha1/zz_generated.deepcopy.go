@@ -22,16 +22,237 @@ package v1alpha1
 
 import (
 	apiv1alpha1 "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigFragmentTarget) DeepCopyInto(out *ConfigFragmentTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigFragmentTarget.
+func (in *ConfigFragmentTarget) DeepCopy() *ConfigFragmentTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigFragmentTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulConfigFragment) DeepCopyInto(out *ConsulConfigFragment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulConfigFragment.
+func (in *ConsulConfigFragment) DeepCopy() *ConsulConfigFragment {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulConfigFragment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsulConfigFragment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulConfigFragmentList) DeepCopyInto(out *ConsulConfigFragmentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ConsulConfigFragment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulConfigFragmentList.
+func (in *ConsulConfigFragmentList) DeepCopy() *ConsulConfigFragmentList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulConfigFragmentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsulConfigFragmentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulConfigFragmentSpec) DeepCopyInto(out *ConsulConfigFragmentSpec) {
+	*out = *in
+	out.Target = in.Target
+	in.Fragment.DeepCopyInto(&out.Fragment)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulConfigFragmentSpec.
+func (in *ConsulConfigFragmentSpec) DeepCopy() *ConsulConfigFragmentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulConfigFragmentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulConfigFragmentStatus) DeepCopyInto(out *ConsulConfigFragmentStatus) {
+	*out = *in
+	if in.Destination != nil {
+		in, out := &in.Destination, &out.Destination
+		*out = new(DestinationReference)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulConfigFragmentStatus.
+func (in *ConsulConfigFragmentStatus) DeepCopy() *ConsulConfigFragmentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulConfigFragmentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulIngressGatewayService) DeepCopyInto(out *ConsulIngressGatewayService) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulIngressGatewayService.
+func (in *ConsulIngressGatewayService) DeepCopy() *ConsulIngressGatewayService {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulIngressGatewayService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsulIngressGatewayService) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulIngressGatewayServiceList) DeepCopyInto(out *ConsulIngressGatewayServiceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ConsulIngressGatewayService, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulIngressGatewayServiceList.
+func (in *ConsulIngressGatewayServiceList) DeepCopy() *ConsulIngressGatewayServiceList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulIngressGatewayServiceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsulIngressGatewayServiceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulIngressGatewayServiceSpec) DeepCopyInto(out *ConsulIngressGatewayServiceSpec) {
+	*out = *in
+	in.Service.DeepCopyInto(&out.Service)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulIngressGatewayServiceSpec.
+func (in *ConsulIngressGatewayServiceSpec) DeepCopy() *ConsulIngressGatewayServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulIngressGatewayServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulIngressGatewayServiceStatus) DeepCopyInto(out *ConsulIngressGatewayServiceStatus) {
+	*out = *in
+	if in.Destination != nil {
+		in, out := &in.Destination, &out.Destination
+		*out = new(DestinationReference)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulIngressGatewayServiceStatus.
+func (in *ConsulIngressGatewayServiceStatus) DeepCopy() *ConsulIngressGatewayServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulIngressGatewayServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConsulServiceIntentionsSource) DeepCopyInto(out *ConsulServiceIntentionsSource) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulServiceIntentionsSource.
@@ -107,6 +328,18 @@ func (in *ConsulServiceIntentionsSourceSpec) DeepCopy() *ConsulServiceIntentions
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConsulServiceIntentionsSourceStatus) DeepCopyInto(out *ConsulServiceIntentionsSourceStatus) {
 	*out = *in
+	if in.Destination != nil {
+		in, out := &in.Destination, &out.Destination
+		*out = new(DestinationReference)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulServiceIntentionsSourceStatus.
@@ -119,13 +352,114 @@ func (in *ConsulServiceIntentionsSourceStatus) DeepCopy() *ConsulServiceIntentio
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulServiceResolverSubset) DeepCopyInto(out *ConsulServiceResolverSubset) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulServiceResolverSubset.
+func (in *ConsulServiceResolverSubset) DeepCopy() *ConsulServiceResolverSubset {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulServiceResolverSubset)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsulServiceResolverSubset) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulServiceResolverSubsetList) DeepCopyInto(out *ConsulServiceResolverSubsetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ConsulServiceResolverSubset, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulServiceResolverSubsetList.
+func (in *ConsulServiceResolverSubsetList) DeepCopy() *ConsulServiceResolverSubsetList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulServiceResolverSubsetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsulServiceResolverSubsetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulServiceResolverSubsetSpec) DeepCopyInto(out *ConsulServiceResolverSubsetSpec) {
+	*out = *in
+	if in.Subsets != nil {
+		in, out := &in.Subsets, &out.Subsets
+		*out = make(apiv1alpha1.ServiceResolverSubsetMap, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulServiceResolverSubsetSpec.
+func (in *ConsulServiceResolverSubsetSpec) DeepCopy() *ConsulServiceResolverSubsetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulServiceResolverSubsetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulServiceResolverSubsetStatus) DeepCopyInto(out *ConsulServiceResolverSubsetStatus) {
+	*out = *in
+	if in.Destination != nil {
+		in, out := &in.Destination, &out.Destination
+		*out = new(DestinationReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulServiceResolverSubsetStatus.
+func (in *ConsulServiceResolverSubsetStatus) DeepCopy() *ConsulServiceResolverSubsetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulServiceResolverSubsetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConsulServiceRoute) DeepCopyInto(out *ConsulServiceRoute) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulServiceRoute.
@@ -197,6 +531,18 @@ func (in *ConsulServiceRouteSpec) DeepCopy() *ConsulServiceRouteSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConsulServiceRouteStatus) DeepCopyInto(out *ConsulServiceRouteStatus) {
 	*out = *in
+	if in.Destination != nil {
+		in, out := &in.Destination, &out.Destination
+		*out = new(DestinationReference)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulServiceRouteStatus.
@@ -208,3 +554,208 @@ func (in *ConsulServiceRouteStatus) DeepCopy() *ConsulServiceRouteStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulServiceSplit) DeepCopyInto(out *ConsulServiceSplit) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulServiceSplit.
+func (in *ConsulServiceSplit) DeepCopy() *ConsulServiceSplit {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulServiceSplit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsulServiceSplit) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulServiceSplitList) DeepCopyInto(out *ConsulServiceSplitList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ConsulServiceSplit, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulServiceSplitList.
+func (in *ConsulServiceSplitList) DeepCopy() *ConsulServiceSplitList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulServiceSplitList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsulServiceSplitList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulServiceSplitSpec) DeepCopyInto(out *ConsulServiceSplitSpec) {
+	*out = *in
+	out.Split = in.Split
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulServiceSplitSpec.
+func (in *ConsulServiceSplitSpec) DeepCopy() *ConsulServiceSplitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulServiceSplitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulServiceSplitStatus) DeepCopyInto(out *ConsulServiceSplitStatus) {
+	*out = *in
+	if in.Destination != nil {
+		in, out := &in.Destination, &out.Destination
+		*out = new(DestinationReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulServiceSplitStatus.
+func (in *ConsulServiceSplitStatus) DeepCopy() *ConsulServiceSplitStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulServiceSplitStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulTerminatingGatewayService) DeepCopyInto(out *ConsulTerminatingGatewayService) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulTerminatingGatewayService.
+func (in *ConsulTerminatingGatewayService) DeepCopy() *ConsulTerminatingGatewayService {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulTerminatingGatewayService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsulTerminatingGatewayService) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulTerminatingGatewayServiceList) DeepCopyInto(out *ConsulTerminatingGatewayServiceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ConsulTerminatingGatewayService, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulTerminatingGatewayServiceList.
+func (in *ConsulTerminatingGatewayServiceList) DeepCopy() *ConsulTerminatingGatewayServiceList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulTerminatingGatewayServiceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConsulTerminatingGatewayServiceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulTerminatingGatewayServiceSpec) DeepCopyInto(out *ConsulTerminatingGatewayServiceSpec) {
+	*out = *in
+	out.Service = in.Service
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulTerminatingGatewayServiceSpec.
+func (in *ConsulTerminatingGatewayServiceSpec) DeepCopy() *ConsulTerminatingGatewayServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulTerminatingGatewayServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulTerminatingGatewayServiceStatus) DeepCopyInto(out *ConsulTerminatingGatewayServiceStatus) {
+	*out = *in
+	if in.Destination != nil {
+		in, out := &in.Destination, &out.Destination
+		*out = new(DestinationReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulTerminatingGatewayServiceStatus.
+func (in *ConsulTerminatingGatewayServiceStatus) DeepCopy() *ConsulTerminatingGatewayServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulTerminatingGatewayServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DestinationReference) DeepCopyInto(out *DestinationReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DestinationReference.
+func (in *DestinationReference) DeepCopy() *DestinationReference {
+	if in == nil {
+		return nil
+	}
+	out := new(DestinationReference)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/NativeChat/consul-merge-controller/pkg/render"
+	"github.com/NativeChat/consul-merge-controller/pkg/routes"
+)
+
+// runRender prints the destinations, e.g. the ServiceRouter and ServiceIntentions resources, which the controller
+// would create from the resources in the files, e.g. the ConsulServiceRoute and ConsulServiceIntentionsSource resources.
+func runRender(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var namespace string
+	var routeOrdering string
+	var excludeConflictingRoutes bool
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&namespace, "namespace", "default", "The namespace of the resources which don't set one.")
+	flags.StringVar(&routeOrdering, "route-ordering", routes.OrderingPriority,
+		fmt.Sprintf("The ordering of the routes in the service routers which don't set the %s annotation. "+
+			"One of %s or %s.", routes.GetOrderingAnnotation(), routes.OrderingPriority, routes.OrderingSpecificity))
+	flags.BoolVar(&excludeConflictingRoutes, "exclude-conflicting-routes", false,
+		"Exclude the duplicated and fully shadowed routes from the service routers.")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: render [flags] [files]\n\nThe resources are read from stdin when no files are given or the file is -.\n\nflags:\n")
+		flags.PrintDefaults()
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return exitError
+	}
+
+	if !routes.IsValidOrdering(routeOrdering) {
+		fmt.Fprintf(stderr, "invalid route ordering %s\n", routeOrdering)
+
+		return exitError
+	}
+
+	objs, err := decodeFiles(flags.Args(), stdin, render.NewSourceResources()...)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+
+		return exitError
+	}
+
+	renderer := render.NewRenderer(logr.Discard(), namespace, routeOrdering, excludeConflictingRoutes)
+	result, err := renderer.Render(context.Background(), objs)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+
+		return exitError
+	}
+
+	for _, unlabeled := range result.Unlabeled {
+		fmt.Fprintf(stderr, "warning: %s has no destination label and is not merged\n", unlabeled)
+	}
+
+	docs := []string{}
+	for _, destination := range result.Destinations {
+		doc, err := yaml.Marshal(destination)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+
+			return exitError
+		}
+
+		docs = append(docs, string(doc))
+	}
+
+	fmt.Fprint(stdout, strings.Join(docs, "---\n"))
+
+	for _, conflict := range result.Conflicts {
+		fmt.Fprintf(stderr, "conflict in %s: %s\n", conflict.Destination, conflict.Message)
+	}
+
+	if len(result.Conflicts) > 0 {
+		return exitConflict
+	}
+
+	return 0
+}
+
+// decodeFiles decodes the resources of the given kinds from the files or from stdin if there are no files.
+func decodeFiles(files []string, stdin io.Reader, kinds ...client.Object) ([]client.Object, error) {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	objs := []client.Object{}
+	for _, file := range files {
+		var r io.Reader = stdin
+		if file != "-" {
+			f, err := os.Open(file)
+			if err != nil {
+				return nil, err
+			}
+
+			defer f.Close()
+			r = f
+		}
+
+		fileObjs, err := render.Decode(r, kinds...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", file, err)
+		}
+
+		objs = append(objs, fileObjs...)
+	}
+
+	return objs, nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const routesYAML = `apiVersion: service.consul.k8s.nativechat.com/v1alpha1
+kind: ConsulServiceRoute
+metadata:
+  name: service-a-v1
+  labels:
+    service.consul.k8s.nativechat.com/service-router: service-a
+spec:
+  route:
+    match:
+      http:
+        pathPrefix: /v1
+    destination:
+      service: service-a-v1
+`
+
+const conflictingRouteYAML = `---
+apiVersion: service.consul.k8s.nativechat.com/v1alpha1
+kind: ConsulServiceRoute
+metadata:
+  name: service-a-v1-copy
+  labels:
+    service.consul.k8s.nativechat.com/service-router: service-a
+spec:
+  route:
+    match:
+      http:
+        pathPrefix: /v1
+    destination:
+      service: service-a-v1-copy
+`
+
+var _ = Describe("render", func() {
+	var stdout *bytes.Buffer
+	var stderr *bytes.Buffer
+
+	BeforeEach(func() {
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+	})
+
+	It("prints the destinations of the resources from stdin", func() {
+		code := runRender([]string{}, strings.NewReader(routesYAML), stdout, stderr)
+		Expect(code).To(Equal(0))
+		Expect(stderr.String()).To(BeEmpty())
+		Expect(stdout.String()).To(ContainSubstring("kind: ServiceRouter"))
+		Expect(stdout.String()).To(ContainSubstring("name: service-a"))
+		Expect(stdout.String()).To(ContainSubstring("pathPrefix: /v1"))
+	})
+
+	It("exits with the conflict code when the resources conflict", func() {
+		code := runRender([]string{}, strings.NewReader(routesYAML+conflictingRouteYAML), stdout, stderr)
+		Expect(code).To(Equal(exitConflict))
+		Expect(stdout.String()).To(ContainSubstring("kind: ServiceRouter"))
+		Expect(stderr.String()).To(ContainSubstring("conflict in ServiceRouter default/service-a"))
+	})
+
+	It("exits with the error code when the route ordering is invalid", func() {
+		code := runRender([]string{"-route-ordering", "alphabetical"}, strings.NewReader(routesYAML), stdout, stderr)
+		Expect(code).To(Equal(exitError))
+		Expect(stderr.String()).To(ContainSubstring("invalid route ordering alphabetical"))
+		Expect(stdout.String()).To(BeEmpty())
+	})
+
+	It("exits with the error code when a file doesn't exist", func() {
+		code := runRender([]string{"does-not-exist.yaml"}, strings.NewReader(""), stdout, stderr)
+		Expect(code).To(Equal(exitError))
+		Expect(stderr.String()).NotTo(BeEmpty())
+	})
+})
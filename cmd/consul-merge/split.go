@@ -0,0 +1,177 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/NativeChat/consul-merge-controller/pkg/migrate"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(consulk8s.AddToScheme(scheme))
+}
+
+// runSplit prints a ConsulServiceRoute for each route of the ServiceRouter resources,
+// a ConsulServiceSplit for each split of the ServiceSplitter resources and
+// a ConsulServiceIntentionsSource for each source of the ServiceIntentions resources
+// from the files or the cluster.
+func runSplit(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var fromCluster bool
+	var kubeconfig string
+	var kubeContext string
+	var namespace string
+	flags := flag.NewFlagSet("split", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.BoolVar(&fromCluster, "from-cluster", false,
+		"Read the ServiceRouter, ServiceSplitter and ServiceIntentions resources from the cluster. "+
+			"The arguments are the names of the resources, all resources are read when there are no arguments.")
+	flags.StringVar(&kubeconfig, "kubeconfig", "", "The path to the kubeconfig file used with --from-cluster.")
+	flags.StringVar(&kubeContext, "context", "", "The kubeconfig context used with --from-cluster.")
+	flags.StringVar(&namespace, "namespace", "", "The namespace of the resources read with --from-cluster. All namespaces are read by default.")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: split [flags] [files | names]\n\nThe resources are read from stdin when no files are given or the file is -.\n\nflags:\n")
+		flags.PrintDefaults()
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return exitError
+	}
+
+	var objs []client.Object
+	if fromCluster {
+		objs, err = getFromCluster(kubeconfig, kubeContext, namespace, flags.Args())
+	} else {
+		objs, err = decodeFiles(flags.Args(), stdin, &consulk8s.ServiceRouter{}, &consulk8s.ServiceSplitter{}, &consulk8s.ServiceIntentions{})
+	}
+
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+
+		return exitError
+	}
+
+	split, err := migrate.Split(objs)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+
+		return exitError
+	}
+
+	err = migrate.Verify(context.Background(), objs, split)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+
+		return exitError
+	}
+
+	docs := []string{}
+	for _, obj := range split {
+		doc, err := yaml.Marshal(obj)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+
+			return exitError
+		}
+
+		docs = append(docs, string(doc))
+	}
+
+	fmt.Fprint(stdout, strings.Join(docs, "---\n"))
+
+	return 0
+}
+
+// getFromCluster gets the ServiceRouter, ServiceSplitter and ServiceIntentions resources with the given names from the cluster.
+// All resources are returned when there are no names.
+func getFromCluster(kubeconfig, kubeContext, namespace string, names []string) ([]client.Object, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: kubeContext}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	serviceRouters := &consulk8s.ServiceRouterList{}
+	err = c.List(ctx, serviceRouters, client.InNamespace(namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	serviceSplitters := &consulk8s.ServiceSplitterList{}
+	err = c.List(ctx, serviceSplitters, client.InNamespace(namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	serviceIntentions := &consulk8s.ServiceIntentionsList{}
+	err = c.List(ctx, serviceIntentions, client.InNamespace(namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	objs := []client.Object{}
+	for i := range serviceRouters.Items {
+		objs = append(objs, &serviceRouters.Items[i])
+	}
+
+	for i := range serviceSplitters.Items {
+		objs = append(objs, &serviceSplitters.Items[i])
+	}
+
+	for i := range serviceIntentions.Items {
+		objs = append(objs, &serviceIntentions.Items[i])
+	}
+
+	if len(names) == 0 {
+		return objs, nil
+	}
+
+	selected := []client.Object{}
+	for _, obj := range objs {
+		for _, name := range names {
+			if obj.GetName() == name {
+				selected = append(selected, obj)
+
+				break
+			}
+		}
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/finalizers"
+	"github.com/NativeChat/consul-merge-controller/pkg/fragments"
+	"github.com/NativeChat/consul-merge-controller/pkg/reconcile"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+)
+
+// ConsulConfigFragmentReconciler reconciles a ConsulConfigFragment object
+type ConsulConfigFragmentReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	Recorder record.EventRecorder
+
+	// MaxConcurrentReconciles is the maximum number of concurrent reconciles. Defaults to 1.
+	MaxConcurrentReconciles int
+
+	watcher fragments.TargetWatcher
+}
+
+// +kubebuilder:rbac:groups=service.consul.k8s.nativechat.com,resources=consulconfigfragments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=service.consul.k8s.nativechat.com,resources=consulconfigfragments/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=service.consul.k8s.nativechat.com,resources=consulconfigfragments/finalizers,verbs=update
+
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=*,verbs=get;list;watch;update;patch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *ConsulConfigFragmentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("target", req.NamespacedName)
+
+	crdService := r.newCRDService(log)
+	merger := fragments.NewMerger(
+		r.Client,
+		r.Client,
+		log,
+		r.Recorder,
+		r.watcher,
+	)
+
+	// The fragments are selected by their target, so there is no label which selects the destination.
+	reconciler := reconcile.NewReconciler(
+		r,
+		crdService,
+		merger,
+		log,
+		r.Recorder,
+		"",
+		nil,
+	)
+
+	res, err := reconciler.Reconcile(ctx, req)
+
+	return res, err
+}
+
+func (r *ConsulConfigFragmentReconciler) newCRDService(log logr.Logger) services.CRDService {
+	crdService := fragments.NewCRDService(
+		r.Client,
+		r.Client,
+		log,
+		finalizers.FinalizerName,
+	)
+
+	return crdService
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ConsulConfigFragmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	c, err := controller.New("consulconfigfragment", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: r.MaxConcurrentReconciles})
+	if err != nil {
+		return err
+	}
+
+	// The kinds of the targets aren't known in advance, so they are watched when fragments are first merged into them.
+	r.watcher = fragments.NewTargetWatcher(c)
+
+	// The requests are for the targets, so the changes of all fragments with the same target are merged at once.
+	mapSource := fragments.NewSourceMapFunc(r.newCRDService(r.Log))
+
+	return c.Watch(&source.Kind{Type: &servicev1alpha1.ConsulConfigFragment{}}, handler.EnqueueRequestsFromMapFunc(mapSource))
+}
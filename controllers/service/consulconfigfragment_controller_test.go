@@ -0,0 +1,87 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"time"
+
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/NativeChat/consul-merge-controller/pkg/fragments"
+	"github.com/NativeChat/consul-merge-controller/testutils"
+)
+
+const (
+	fragmentServiceDefaults = "service-fragments"
+
+	metricsPathFragment = "metrics-path"
+	healthPathFragment  = "health-path"
+)
+
+var _ = Describe("ConsulConfigFragment controller", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		err := testutils.CreateServiceDefaults(ctx, k8sClient, fragmentServiceDefaults)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(testutils.DeleteConsulConfigFragment(ctx, k8sClient, metricsPathFragment)).To(Succeed())
+		Expect(testutils.DeleteConsulConfigFragment(ctx, k8sClient, healthPathFragment)).To(Succeed())
+		Expect(testutils.DeleteServiceDefaults(ctx, k8sClient, fragmentServiceDefaults)).To(Succeed())
+	})
+
+	It("should merge the fragments into the service defaults", func() {
+		err := testutils.CreateConsulConfigFragment(ctx, k8sClient, metricsPathFragment, fragmentServiceDefaults, "spec.expose.paths",
+			fragments.StrategyUpsert, "path", `[{"path": "/metrics", "localPathPort": 9102, "listenerPort": 20200, "protocol": "http"}]`)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = testutils.CreateConsulConfigFragment(ctx, k8sClient, healthPathFragment, fragmentServiceDefaults, "spec.expose.paths",
+			fragments.StrategyUpsert, "path", `[{"path": "/health", "localPathPort": 8080, "listenerPort": 20201, "protocol": "http"}]`)
+		Expect(err).NotTo(HaveOccurred())
+
+		serviceDefaults, err := testutils.GetServiceDefaults(ctx, k8sClient, fragmentServiceDefaults)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(serviceDefaults.Spec.Protocol).To(Equal("http"))
+		Expect(serviceDefaults.Spec.Expose.Paths).To(Equal([]consulk8s.ExposePath{
+			{Path: "/health", LocalPathPort: 8080, ListenerPort: 20201, Protocol: "http"},
+			{Path: "/metrics", LocalPathPort: 9102, ListenerPort: 20200, Protocol: "http"},
+		}))
+	})
+
+	It("should restore the field of the service defaults when its fragments are deleted", func() {
+		err := testutils.CreateConsulConfigFragment(ctx, k8sClient, metricsPathFragment, fragmentServiceDefaults, "spec.expose.paths",
+			fragments.StrategyAppend, "", `[{"path": "/metrics", "localPathPort": 9102, "listenerPort": 20200, "protocol": "http"}]`)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = testutils.DeleteConsulConfigFragment(ctx, k8sClient, metricsPathFragment)
+		Expect(err).NotTo(HaveOccurred())
+
+		time.Sleep(time.Second)
+
+		serviceDefaults, err := testutils.GetServiceDefaults(ctx, k8sClient, fragmentServiceDefaults)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(serviceDefaults.Spec.Protocol).To(Equal("http"))
+		Expect(serviceDefaults.Spec.Expose.Paths).To(BeEmpty())
+	})
+})
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/reconcile"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	"github.com/NativeChat/consul-merge-controller/pkg/strategies"
+)
+
+// ConsulIngressGatewayServiceReconciler reconciles a ConsulIngressGatewayService object
+type ConsulIngressGatewayServiceReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	Recorder record.EventRecorder
+
+	// MaxConcurrentReconciles is the maximum number of concurrent reconciles. Defaults to 1.
+	MaxConcurrentReconciles int
+
+	// ConsulBackend writes the merged destinations directly to Consul when it is set.
+	// The consul-k8s resources are neither created nor watched then.
+	ConsulBackend *services.ConsulBackend
+}
+
+// +kubebuilder:rbac:groups=service.consul.k8s.nativechat.com,resources=consulingressgatewayservices,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=service.consul.k8s.nativechat.com,resources=consulingressgatewayservices/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=service.consul.k8s.nativechat.com,resources=consulingressgatewayservices/finalizers,verbs=update
+
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=ingressgateways,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=ingressgateways/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=ingressgateways/finalizers,verbs=update
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *ConsulIngressGatewayServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("ingressgateway", req.NamespacedName)
+
+	crdService := r.newCRDService(log)
+
+	kind := strategies.GetMergeKind(controllerlabels.IngressGateway)
+	options := strategies.MergeOptions{}
+	merger := kind.NewMerger(r.Client, r.Client, log, r.Recorder, options)
+	if r.ConsulBackend != nil {
+		merger = services.NewConsulMerger(merger, r.ConsulBackend)
+	}
+
+	reconciler := reconcile.NewReconciler(
+		r,
+		crdService,
+		merger,
+		log,
+		r.Recorder,
+		controllerlabels.IngressGateway,
+		kind.NewFindConflictsFunc(options),
+	)
+
+	res, err := reconciler.Reconcile(ctx, req)
+
+	return res, err
+}
+
+func (r *ConsulIngressGatewayServiceReconciler) newCRDService(log logr.Logger) services.CRDService {
+	return strategies.GetMergeKind(controllerlabels.IngressGateway).NewCRDService(r.Client, r.Client, log)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ConsulIngressGatewayServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	mapSource := reconcile.NewSourceMapFunc(r.newCRDService(r.Log), controllerlabels.IngressGateway)
+
+	// The requests are for the merge destinations, so the changes of all resources
+	// with the same destination are merged at once.
+	return reconcile.SetupWithManager(
+		mgr,
+		controller.Options{Reconciler: r, MaxConcurrentReconciles: r.MaxConcurrentReconciles},
+		&consulk8s.IngressGateway{},
+		&servicev1alpha1.ConsulIngressGatewayService{},
+		mapSource,
+		r.ConsulBackend == nil,
+	)
+}
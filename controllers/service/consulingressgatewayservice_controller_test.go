@@ -0,0 +1,177 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/strategies"
+	"github.com/NativeChat/consul-merge-controller/testutils"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+const (
+	ingressGatewayA = "ingress-gateway-a"
+	ingressGatewayB = "ingress-gateway-b"
+
+	ingressListenerProtocolHTTP = "http"
+	ingressListenerProtocolTCP  = "tcp"
+)
+
+var _ = Describe("ConsulIngressGatewayService controller", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		for _, serviceDefaultsName := range serviceDefaults {
+			err := testutils.CreateServiceDefaults(ctx, k8sClient, serviceDefaultsName)
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	AfterEach(func() {
+		testutils.DeleteConsulIngressGatewayServices(ctx, k8sClient, ingressGatewayA)
+		testutils.DeleteConsulIngressGatewayServices(ctx, k8sClient, ingressGatewayB)
+
+		for _, serviceDefaultsName := range serviceDefaults {
+			err := testutils.DeleteServiceDefaults(ctx, k8sClient, serviceDefaultsName)
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	Context("Merge", func() {
+		It("should group the services into listeners by port", func() {
+			serviceAV1Service := testutils.CreateIngressService(serviceAV1, "a-v1.example.com")
+			serviceAV2Service := testutils.CreateIngressService(serviceAV2, "a-v2.example.com")
+			serviceBV1Service := testutils.CreateIngressService(serviceBV1, "b-v1.example.com")
+
+			_, err := testutils.CreateConsulIngressGatewayService(ctx, k8sClient, ingressGatewayA, 8080, ingressListenerProtocolHTTP, serviceAV1Service)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = testutils.CreateConsulIngressGatewayService(ctx, k8sClient, ingressGatewayA, 8080, ingressListenerProtocolHTTP, serviceAV2Service)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = testutils.CreateConsulIngressGatewayService(ctx, k8sClient, ingressGatewayA, 8081, ingressListenerProtocolHTTP, serviceBV1Service)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForIngressGatewayToBeCreated(ctx, k8sClient, ingressGatewayA)
+			Expect(err).NotTo(HaveOccurred())
+
+			ingressGateway, err := testutils.GetIngressGateway(ctx, k8sClient, ingressGatewayA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ingressGateway).NotTo(BeNil())
+
+			Expect(ingressGateway.Spec.Listeners).To(HaveLen(2))
+			Expect(ingressGateway.Spec.Listeners).To(ContainElements(
+				consulk8s.IngressListener{
+					Port:     8080,
+					Protocol: ingressListenerProtocolHTTP,
+					Services: []consulk8s.IngressService{serviceAV1Service, serviceAV2Service},
+				},
+				consulk8s.IngressListener{
+					Port:     8081,
+					Protocol: ingressListenerProtocolHTTP,
+					Services: []consulk8s.IngressService{serviceBV1Service},
+				},
+			))
+		})
+
+		It("should not merge services with different protocols on the same port", func() {
+			_, err := testutils.CreateConsulIngressGatewayService(ctx, k8sClient, ingressGatewayA, 8080, ingressListenerProtocolHTTP, testutils.CreateIngressService(serviceAV1))
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForIngressGatewayToBeCreated(ctx, k8sClient, ingressGatewayA)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = testutils.CreateConsulIngressGatewayService(ctx, k8sClient, ingressGatewayA, 8080, ingressListenerProtocolTCP, testutils.CreateIngressService(serviceAV2))
+			Expect(err).To(HaveOccurred())
+
+			ingressGateway, err := testutils.GetIngressGateway(ctx, k8sClient, ingressGatewayA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ingressGateway).NotTo(BeNil())
+			Expect(ingressGateway.Spec.Listeners).To(HaveLen(1))
+			Expect(ingressGateway.Spec.Listeners[0].Services).To(HaveLen(1))
+		})
+	})
+
+	Context("Conflicts", func() {
+		It("should set the conflict condition on the later service added to the same listener", func() {
+			serviceAV1Service := testutils.CreateIngressService(serviceAV1, "a-v1.example.com")
+
+			_, err := testutils.CreateNamedConsulIngressGatewayService(ctx, k8sClient, "service-a-v1-first", ingressGatewayA, 8080, ingressListenerProtocolHTTP, serviceAV1Service)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = testutils.CreateNamedConsulIngressGatewayService(ctx, k8sClient, "service-a-v1-second", ingressGatewayA, 8080, ingressListenerProtocolHTTP, serviceAV1Service)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForConsulIngressGatewayServiceCondition(ctx, k8sClient, "service-a-v1-second", v1alpha1.ConditionTypeConflict, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			second, err := testutils.GetConsulIngressGatewayService(ctx, k8sClient, "service-a-v1-second")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(meta.FindStatusCondition(second.Status.Conditions, v1alpha1.ConditionTypeConflict).Reason).To(Equal(strategies.ConflictReasonDuplicateService))
+
+			first, err := testutils.GetConsulIngressGatewayService(ctx, k8sClient, "service-a-v1-first")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(meta.IsStatusConditionTrue(first.Status.Conditions, v1alpha1.ConditionTypeConflict)).To(BeFalse())
+
+			ingressGateway, err := testutils.GetIngressGateway(ctx, k8sClient, ingressGatewayA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ingressGateway.Spec.Listeners).To(Equal([]consulk8s.IngressListener{
+				{
+					Port:     8080,
+					Protocol: ingressListenerProtocolHTTP,
+					Services: []consulk8s.IngressService{serviceAV1Service},
+				},
+			}))
+
+			By("deleting the first service")
+			err = testutils.DeleteConsulIngressGatewayService(ctx, k8sClient, "service-a-v1-first")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForConsulIngressGatewayServiceCondition(ctx, k8sClient, "service-a-v1-second", v1alpha1.ConditionTypeConflict, false)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	It("should delete the ingress gateway if all services for it are deleted.", func() {
+		serviceAName, err := testutils.CreateConsulIngressGatewayService(ctx, k8sClient, ingressGatewayA, 8080, ingressListenerProtocolHTTP, testutils.CreateIngressService(serviceAV1))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = testutils.CreateConsulIngressGatewayService(ctx, k8sClient, ingressGatewayB, 8080, ingressListenerProtocolHTTP, testutils.CreateIngressService(serviceBV1))
+		Expect(err).NotTo(HaveOccurred())
+
+		err = testutils.DeleteConsulIngressGatewayService(ctx, k8sClient, serviceAName)
+		Expect(err).NotTo(HaveOccurred())
+
+		time.Sleep(time.Second)
+
+		ingressGatewayAResource, err := testutils.GetIngressGateway(ctx, k8sClient, ingressGatewayA)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ingressGatewayAResource).To(BeNil())
+
+		ingressGatewayBResource, err := testutils.GetIngressGateway(ctx, k8sClient, ingressGatewayB)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ingressGatewayBResource).NotTo(BeNil())
+	})
+})
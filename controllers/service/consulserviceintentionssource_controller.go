@@ -18,20 +18,20 @@ package service
 
 import (
 	"context"
-	"reflect"
 
 	"github.com/go-logr/logr"
 	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 
-	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
 	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
-	"github.com/NativeChat/consul-merge-controller/pkg/finalizers"
 	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
 	"github.com/NativeChat/consul-merge-controller/pkg/reconcile"
 	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	"github.com/NativeChat/consul-merge-controller/pkg/strategies"
 )
 
 // ConsulServiceIntentionsSourceReconciler reconciles a ConsulServiceIntentionsSource object
@@ -39,6 +39,18 @@ type ConsulServiceIntentionsSourceReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+
+	// AdoptionMode selects how the service intentions which weren't created by the controller are handled.
+	AdoptionMode string
+
+	Recorder record.EventRecorder
+
+	// MaxConcurrentReconciles is the maximum number of concurrent reconciles. Defaults to 1.
+	MaxConcurrentReconciles int
+
+	// ConsulBackend writes the merged destinations directly to Consul when it is set.
+	// The consul-k8s resources are neither created nor watched then.
+	ConsulBackend *services.ConsulBackend
 }
 
 // +kubebuilder:rbac:groups=service.consul.k8s.nativechat.com,resources=consulserviceintentionssources,verbs=get;list;watch;create;update;patch;delete
@@ -52,39 +64,24 @@ type ConsulServiceIntentionsSourceReconciler struct {
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 func (r *ConsulServiceIntentionsSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := r.Log.WithValues("consulserviceintentionssource", req.NamespacedName)
-
-	patchExpectedDefinition := func(obj client.Object) client.Object {
-		serviceIntentions := obj.(*consulk8s.ServiceIntentions)
-
-		serviceIntentions.Spec.Destination.Name = obj.GetName()
-
-		return serviceIntentions
+	log := r.Log.WithValues("serviceintentions", req.NamespacedName)
+
+	crdService := r.newCRDService(log)
+	kind := strategies.GetMergeKind(controllerlabels.ServiceIntentions)
+	options := strategies.MergeOptions{AdoptionMode: r.AdoptionMode}
+	merger := kind.NewMerger(r.Client, r.Client, log, r.Recorder, options)
+	if r.ConsulBackend != nil {
+		merger = services.NewConsulMerger(merger, r.ConsulBackend)
 	}
 
-	crdService := services.NewCRDService(
-		r.Client,
-		r.Client,
-		log,
-		finalizers.ConsulServiceRouteFinalizerName,
-		reflect.TypeOf(v1alpha1.ConsulServiceIntentionsSource{}),
-		reflect.TypeOf(v1alpha1.ConsulServiceIntentionsSourceList{}),
-	)
-	merger := services.NewMerger(
-		r.Client,
-		r.Client,
-		log,
-		patchExpectedDefinition,
-		"Sources",
-		"Source",
-		reflect.TypeOf(consulk8s.ServiceIntentions{}),
-	)
 	reconciler := reconcile.NewReconciler(
 		r,
 		crdService,
 		merger,
 		log,
+		r.Recorder,
 		controllerlabels.ServiceIntentions,
+		kind.NewFindConflictsFunc(options),
 	)
 
 	res, err := reconciler.Reconcile(ctx, req)
@@ -92,9 +89,22 @@ func (r *ConsulServiceIntentionsSourceReconciler) Reconcile(ctx context.Context,
 	return res, err
 }
 
+func (r *ConsulServiceIntentionsSourceReconciler) newCRDService(log logr.Logger) services.CRDService {
+	return strategies.GetMergeKind(controllerlabels.ServiceIntentions).NewCRDService(r.Client, r.Client, log)
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ConsulServiceIntentionsSourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&servicev1alpha1.ConsulServiceIntentionsSource{}).
-		Complete(r)
+	mapSource := reconcile.NewSourceMapFunc(r.newCRDService(r.Log), controllerlabels.ServiceIntentions)
+
+	// The requests are for the merge destinations, so the changes of all resources
+	// with the same destination are merged at once.
+	return reconcile.SetupWithManager(
+		mgr,
+		controller.Options{Reconciler: r, MaxConcurrentReconciles: r.MaxConcurrentReconciles},
+		&consulk8s.ServiceIntentions{},
+		&servicev1alpha1.ConsulServiceIntentionsSource{},
+		mapSource,
+		r.ConsulBackend == nil,
+	)
 }
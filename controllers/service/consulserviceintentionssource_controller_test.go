@@ -20,10 +20,13 @@ import (
 	"context"
 	"time"
 
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/adoption"
 	"github.com/NativeChat/consul-merge-controller/testutils"
 	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
 )
 
 const (
@@ -83,6 +86,144 @@ var _ = Describe("ConsulServiceIntentionsSource controller", func() {
 		})
 	})
 
+	Context("Permissions", func() {
+		It("should merge the permissions of the same source from multiple resources", func() {
+			getPermission := &consulk8s.IntentionPermission{
+				Action: serviceIntentionsSourceActionAllow,
+				HTTP:   &consulk8s.IntentionHTTPPermission{PathPrefix: "/v1", Methods: []string{"GET"}},
+			}
+			postPermission := &consulk8s.IntentionPermission{
+				Action: serviceIntentionsSourceActionAllow,
+				HTTP:   &consulk8s.IntentionHTTPPermission{PathPrefix: "/v2", Methods: []string{"POST"}},
+			}
+
+			_, err := testutils.CreateNamedConsulServiceIntentionsSource(ctx, k8sClient, "team-a-b-to-a", serviceA, &consulk8s.SourceIntention{
+				Name:        serviceB,
+				Permissions: consulk8s.IntentionPermissions{getPermission},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = testutils.CreateNamedConsulServiceIntentionsSource(ctx, k8sClient, "team-b-b-to-a", serviceA, &consulk8s.SourceIntention{
+				Name:        serviceB,
+				Permissions: consulk8s.IntentionPermissions{postPermission},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForServiceIntentionsToBeCreated(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+
+			serviceIntentions, err := testutils.GetServiceIntentions(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceIntentions).NotTo(BeNil())
+
+			Expect(serviceIntentions.Spec.Sources).To(HaveLen(1))
+			Expect(serviceIntentions.Spec.Sources[0].Name).To(Equal(serviceB))
+			Expect(serviceIntentions.Spec.Sources[0].Action).To(BeEmpty())
+			Expect(serviceIntentions.Spec.Sources[0].Permissions).To(ConsistOf(getPermission, postPermission))
+		})
+
+		It("should not merge a source with action and the same source with permissions", func() {
+			_, err := testutils.CreateConsulServiceIntentionsSource(ctx, k8sClient, serviceA, &consulk8s.SourceIntention{
+				Name:   serviceB,
+				Action: serviceIntentionsSourceActionAllow,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForServiceIntentionsToBeCreated(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = testutils.CreateNamedConsulServiceIntentionsSource(ctx, k8sClient, "permissions-b-to-a", serviceA, &consulk8s.SourceIntention{
+				Name: serviceB,
+				Permissions: consulk8s.IntentionPermissions{
+					{Action: serviceIntentionsSourceActionAllow, HTTP: &consulk8s.IntentionHTTPPermission{PathPrefix: "/v1"}},
+				},
+			})
+			Expect(err).To(HaveOccurred())
+
+			err = testutils.WaitForConsulServiceIntentionsSourceCondition(ctx, k8sClient, "permissions-b-to-a", v1alpha1.ConditionTypeConflict, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForConsulServiceIntentionsSourceCondition(ctx, k8sClient, "permissions-b-to-a", v1alpha1.ConditionTypeMerged, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			serviceIntentions, err := testutils.GetServiceIntentions(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceIntentions).NotTo(BeNil())
+
+			Expect(serviceIntentions.Spec.Sources).To(HaveLen(1))
+			Expect(serviceIntentions.Spec.Sources[0].Action).To(BeEquivalentTo(serviceIntentionsSourceActionAllow))
+			Expect(serviceIntentions.Spec.Sources[0].Permissions).To(BeEmpty())
+		})
+	})
+
+	Context("Overwrite adoption mode", func() {
+		It("should replace the sources of service intentions which weren't created by the controller", func() {
+			unmanagedSource := &consulk8s.SourceIntention{Name: serviceB, Action: serviceIntentionsSourceActionAllow}
+
+			err := testutils.CreateServiceIntentions(ctx, k8sClient, serviceA, []*consulk8s.SourceIntention{unmanagedSource})
+			Expect(err).NotTo(HaveOccurred())
+
+			managedSource := &consulk8s.SourceIntention{Name: serviceBV1, Action: serviceIntentionsSourceActionAllow}
+
+			_, err = testutils.CreateNamedConsulServiceIntentionsSource(ctx, k8sClient, "unmanaged-a", serviceA, managedSource)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForConsulServiceIntentionsSourceCondition(ctx, k8sClient, "unmanaged-a", v1alpha1.ConditionTypeMerged, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			serviceIntentions, err := testutils.GetServiceIntentions(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceIntentions.Spec.Sources).To(Equal([]*consulk8s.SourceIntention{managedSource}))
+			Expect(serviceIntentions.Labels).To(HaveKey(testutils.ManagedByLabel))
+
+			err = testutils.DeleteConsulServiceIntentionsSource(ctx, k8sClient, "unmanaged-a")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.DeleteServiceIntentions(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("Strict adoption mode", func() {
+		BeforeEach(func() {
+			stopControllers = testutils.RestartControllers(k8sClient, stopControllers, adoption.ModeStrict)
+		})
+
+		AfterEach(func() {
+			stopControllers = testutils.RestartControllers(k8sClient, stopControllers, adoption.ModeOverwrite)
+		})
+
+		It("should not change service intentions which weren't created by the controller", func() {
+			unmanagedSource := &consulk8s.SourceIntention{Name: serviceB, Action: serviceIntentionsSourceActionAllow}
+
+			err := testutils.CreateServiceIntentions(ctx, k8sClient, serviceA, []*consulk8s.SourceIntention{unmanagedSource})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = testutils.CreateNamedConsulServiceIntentionsSource(ctx, k8sClient, "unmanaged-a", serviceA, &consulk8s.SourceIntention{
+				Name:   serviceBV1,
+				Action: serviceIntentionsSourceActionAllow,
+			})
+			Expect(err).To(HaveOccurred())
+
+			err = testutils.WaitForConsulServiceIntentionsSourceCondition(ctx, k8sClient, "unmanaged-a", v1alpha1.ConditionTypeMerged, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			source, err := testutils.GetConsulServiceIntentionsSource(ctx, k8sClient, "unmanaged-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(meta.FindStatusCondition(source.Status.Conditions, v1alpha1.ConditionTypeMerged).Reason).To(Equal(v1alpha1.ConditionReasonUnmanagedDestination))
+
+			serviceIntentions, err := testutils.GetServiceIntentions(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceIntentions.Spec.Sources).To(Equal([]*consulk8s.SourceIntention{unmanagedSource}))
+
+			err = testutils.DeleteConsulServiceIntentionsSource(ctx, k8sClient, "unmanaged-a")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.DeleteServiceIntentions(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
 	Context("Multiple sources in single service intentions", func() {
 		var serviceIntentionsSources []*consulk8s.SourceIntention
 		var serviceIntentionsNames []string
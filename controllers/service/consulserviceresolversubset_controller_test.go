@@ -0,0 +1,124 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/NativeChat/consul-merge-controller/testutils"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConsulServiceResolverSubset controller", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		for _, serviceDefaultsName := range serviceDefaults {
+			err := testutils.CreateServiceDefaults(ctx, k8sClient, serviceDefaultsName)
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	AfterEach(func() {
+		testutils.DeleteConsulServiceResolverSubsets(ctx, k8sClient, serviceA)
+		testutils.DeleteConsulServiceResolverSubsets(ctx, k8sClient, serviceB)
+
+		for _, serviceDefaultsName := range serviceDefaults {
+			err := testutils.DeleteServiceDefaults(ctx, k8sClient, serviceDefaultsName)
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	Context("Merge", func() {
+		It("should merge the subsets of multiple resources correctly", func() {
+			serviceASubsets := map[string]consulk8s.ServiceResolverSubsetMap{
+				serviceAV1: {"v1": testutils.CreateVersionSubset("v1")},
+				serviceAV2: {"v2": testutils.CreateVersionSubset("v2"), "pr-123": testutils.CreateVersionSubset("pr-123")},
+			}
+
+			for name, subsets := range serviceASubsets {
+				err := testutils.CreateConsulServiceResolverSubset(ctx, k8sClient, name, serviceA, subsets)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			err := testutils.WaitForServiceResolverToBeCreated(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+
+			serviceResolver, err := testutils.GetServiceResolver(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceResolver).NotTo(BeNil())
+
+			Expect(serviceResolver.Spec.Subsets).To(Equal(consulk8s.ServiceResolverSubsetMap{
+				"v1":     testutils.CreateVersionSubset("v1"),
+				"v2":     testutils.CreateVersionSubset("v2"),
+				"pr-123": testutils.CreateVersionSubset("pr-123"),
+			}))
+		})
+
+		It("should not merge subsets with the same name and different definitions", func() {
+			err := testutils.CreateConsulServiceResolverSubset(ctx, k8sClient, serviceAV1, serviceA, consulk8s.ServiceResolverSubsetMap{
+				"v1": testutils.CreateVersionSubset("v1"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForServiceResolverToBeCreated(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.CreateConsulServiceResolverSubset(ctx, k8sClient, serviceAV2, serviceA, consulk8s.ServiceResolverSubsetMap{
+				"v1": testutils.CreateVersionSubset("v2"),
+			})
+			Expect(err).To(HaveOccurred())
+
+			serviceResolver, err := testutils.GetServiceResolver(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceResolver).NotTo(BeNil())
+			Expect(serviceResolver.Spec.Subsets).To(Equal(consulk8s.ServiceResolverSubsetMap{
+				"v1": testutils.CreateVersionSubset("v1"),
+			}))
+		})
+	})
+
+	It("should delete the service resolver if all subsets for it are deleted.", func() {
+		err := testutils.CreateConsulServiceResolverSubset(ctx, k8sClient, serviceAV1, serviceA, consulk8s.ServiceResolverSubsetMap{
+			"v1": testutils.CreateVersionSubset("v1"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = testutils.CreateConsulServiceResolverSubset(ctx, k8sClient, serviceBV1, serviceB, consulk8s.ServiceResolverSubsetMap{
+			"v1": testutils.CreateVersionSubset("v1"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = testutils.DeleteConsulServiceResolverSubset(ctx, k8sClient, serviceAV1)
+		Expect(err).NotTo(HaveOccurred())
+
+		time.Sleep(time.Second)
+
+		serviceAServiceResolver, err := testutils.GetServiceResolver(ctx, k8sClient, serviceA)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(serviceAServiceResolver).To(BeNil())
+
+		serviceBServiceResolver, err := testutils.GetServiceResolver(ctx, k8sClient, serviceB)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(serviceBServiceResolver).NotTo(BeNil())
+	})
+})
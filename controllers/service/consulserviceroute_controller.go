@@ -18,20 +18,20 @@ package service
 
 import (
 	"context"
-	"reflect"
 
 	"github.com/go-logr/logr"
 	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 
-	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
 	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
-	"github.com/NativeChat/consul-merge-controller/pkg/finalizers"
 	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
 	"github.com/NativeChat/consul-merge-controller/pkg/reconcile"
 	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	"github.com/NativeChat/consul-merge-controller/pkg/strategies"
 )
 
 // ConsulServiceRouteReconciler reconciles a ConsulServiceRoute object
@@ -39,6 +39,25 @@ type ConsulServiceRouteReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+
+	// RouteOrdering is the ordering of the routes in the service routers
+	// which don't select one with the route ordering annotation.
+	RouteOrdering string
+
+	// ExcludeConflictingRoutes excludes the duplicated and fully shadowed routes from the service routers.
+	ExcludeConflictingRoutes bool
+
+	// AdoptionMode selects how the service routers which weren't created by the controller are handled.
+	AdoptionMode string
+
+	Recorder record.EventRecorder
+
+	// MaxConcurrentReconciles is the maximum number of concurrent reconciles. Defaults to 1.
+	MaxConcurrentReconciles int
+
+	// ConsulBackend writes the merged destinations directly to Consul when it is set.
+	// The consul-k8s resources are neither created nor watched then.
+	ConsulBackend *services.ConsulBackend
 }
 
 // +kubebuilder:rbac:groups=service.consul.k8s.nativechat.com,resources=consulserviceroutes,verbs=get;list;watch;create;update;patch;delete
@@ -49,37 +68,36 @@ type ConsulServiceRouteReconciler struct {
 // +kubebuilder:rbac:groups=consul.hashicorp.com,resources=servicerouters/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=consul.hashicorp.com,resources=servicerouters/finalizers,verbs=update
 
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.9.0/pkg/reconcile
 func (r *ConsulServiceRouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := r.Log.WithValues("consulserviceroute", req.NamespacedName)
+	log := r.Log.WithValues("servicerouter", req.NamespacedName)
+
+	crdService := r.newCRDService(log)
+	kind := strategies.GetMergeKind(controllerlabels.ServiceRouter)
+	options := strategies.MergeOptions{
+		AdoptionMode:             r.AdoptionMode,
+		RouteOrdering:            r.RouteOrdering,
+		ExcludeConflictingRoutes: r.ExcludeConflictingRoutes,
+	}
+	merger := kind.NewMerger(r.Client, r.Client, log, r.Recorder, options)
+	if r.ConsulBackend != nil {
+		merger = services.NewConsulMerger(merger, r.ConsulBackend)
+	}
 
-	crdService := services.NewCRDService(
-		r.Client,
-		r.Client,
-		log,
-		finalizers.ConsulServiceRouteFinalizerName,
-		reflect.TypeOf(v1alpha1.ConsulServiceRoute{}),
-		reflect.TypeOf(v1alpha1.ConsulServiceRouteList{}),
-	)
-	merger := services.NewMerger(
-		r.Client,
-		r.Client,
-		log,
-		nil,
-		"Routes",
-		"Route",
-		reflect.TypeOf(consulk8s.ServiceRouter{}),
-	)
 	reconciler := reconcile.NewReconciler(
 		r,
 		crdService,
 		merger,
 		log,
+		r.Recorder,
 		controllerlabels.ServiceRouter,
+		kind.NewFindConflictsFunc(options),
 	)
 
 	res, err := reconciler.Reconcile(ctx, req)
@@ -87,10 +105,22 @@ func (r *ConsulServiceRouteReconciler) Reconcile(ctx context.Context, req ctrl.R
 	return res, err
 }
 
+func (r *ConsulServiceRouteReconciler) newCRDService(log logr.Logger) services.CRDService {
+	return strategies.GetMergeKind(controllerlabels.ServiceRouter).NewCRDService(r.Client, r.Client, log)
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ConsulServiceRouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&servicev1alpha1.ConsulServiceRoute{}).
-		Owns(&consulk8s.ServiceRouter{}).
-		Complete(r)
+	mapSource := reconcile.NewSourceMapFunc(r.newCRDService(r.Log), controllerlabels.ServiceRouter)
+
+	// The requests are for the merge destinations, so the changes of all resources
+	// with the same destination are merged at once.
+	return reconcile.SetupWithManager(
+		mgr,
+		controller.Options{Reconciler: r, MaxConcurrentReconciles: r.MaxConcurrentReconciles},
+		&consulk8s.ServiceRouter{},
+		&servicev1alpha1.ConsulServiceRoute{},
+		mapSource,
+		r.ConsulBackend == nil,
+	)
 }
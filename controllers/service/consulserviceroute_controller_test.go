@@ -21,10 +21,15 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/adoption"
+	"github.com/NativeChat/consul-merge-controller/pkg/metrics"
 	"github.com/NativeChat/consul-merge-controller/testutils"
 	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/api/meta"
 )
 
 var serviceA = "service-a"
@@ -101,6 +106,333 @@ var _ = Describe("ConsulServiceRoute controller", func() {
 		})
 	})
 
+	Context("Priority", func() {
+		It("should sort the routes by priority and name", func() {
+			catchAllRoute := testutils.CreateHTTPPathPrefixRoute(serviceAV1, "/")
+			serviceAV2Route := testutils.CreateHTTPPathPrefixRoute(serviceAV2, "/v2")
+			serviceAV3Route := testutils.CreateHTTPPathPrefixRoute(serviceAV3, "/v3")
+
+			err := testutils.CreateConsulServiceRouteWithPriority(ctx, k8sClient, serviceA, catchAllRoute, 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.CreateConsulServiceRouteWithPriority(ctx, k8sClient, serviceA, serviceAV3Route, 10)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.CreateConsulServiceRouteWithPriority(ctx, k8sClient, serviceA, serviceAV2Route, 10)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForServiceRouterToBeCreated(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+
+			serviceRouter, err := testutils.GetServiceRouter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceRouter).NotTo(BeNil())
+
+			Expect(serviceRouter.Spec.Routes).To(Equal([]consulk8s.ServiceRoute{serviceAV2Route, serviceAV3Route, catchAllRoute}))
+		})
+	})
+
+	Context("Specificity", func() {
+		It("should sort the routes by specificity when the route ordering annotation is set", func() {
+			catchAllRoute := testutils.CreateHTTPPathPrefixRoute(serviceAV1, "/")
+			headerRoute := testutils.CreateHTTPPathPrefixRoute(serviceAV2, "/v2")
+			headerRoute.Match.HTTP.Header = []consulk8s.ServiceRouteHTTPMatchHeader{{Name: "x-api-version", Exact: "pr1"}}
+			exactRoute := consulk8s.ServiceRoute{
+				Match:       &consulk8s.ServiceRouteMatch{HTTP: &consulk8s.ServiceRouteHTTPMatch{PathExact: "/v3"}},
+				Destination: &consulk8s.ServiceRouteDestination{Service: serviceAV3},
+			}
+			prefixRoute := testutils.CreateHTTPPathPrefixRoute(serviceBV1, "/v2")
+
+			err := testutils.CreateConsulServiceRouteWithAnnotations(ctx, k8sClient, serviceA, catchAllRoute, map[string]string{
+				testutils.RouteOrderingAnnotation: "specificity",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, route := range []consulk8s.ServiceRoute{prefixRoute, headerRoute, exactRoute} {
+				err = testutils.CreateConsulServiceRoute(ctx, k8sClient, serviceA, route)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			err = testutils.WaitForServiceRouterToBeCreated(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+
+			serviceRouter, err := testutils.GetServiceRouter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceRouter).NotTo(BeNil())
+
+			Expect(serviceRouter.Spec.Routes).To(Equal([]consulk8s.ServiceRoute{exactRoute, headerRoute, prefixRoute, catchAllRoute}))
+		})
+	})
+
+	Context("Status", func() {
+		It("should report the merge in the status conditions", func() {
+			serviceAV1Route := testutils.CreateHTTPPathPrefixRoute(serviceAV1, "/v1")
+
+			testutils.CreateConsulServiceRoutes(ctx, k8sClient, serviceA, []consulk8s.ServiceRoute{serviceAV1Route})
+
+			err := testutils.WaitForConsulServiceRouteCondition(ctx, k8sClient, serviceAV1, v1alpha1.ConditionTypeMerged, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			route, err := testutils.GetConsulServiceRoute(ctx, k8sClient, serviceAV1)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(route.Status.ObservedGeneration).To(Equal(route.Generation))
+			Expect(route.Status.Destination).To(Equal(&v1alpha1.DestinationReference{
+				APIVersion: consulk8s.GroupVersion.String(),
+				Kind:       "ServiceRouter",
+				Name:       serviceA,
+				Namespace:  testutils.DefaultK8sNamespace,
+			}))
+			Expect(meta.IsStatusConditionTrue(route.Status.Conditions, v1alpha1.ConditionTypeInvalid)).To(BeFalse())
+			Expect(meta.IsStatusConditionTrue(route.Status.Conditions, v1alpha1.ConditionTypeConflict)).To(BeFalse())
+			Expect(meta.FindStatusCondition(route.Status.Conditions, v1alpha1.ConditionTypeSynced)).NotTo(BeNil())
+		})
+
+		It("should record events for the merge on the route and the service router", func() {
+			serviceAV1Route := testutils.CreateHTTPPathPrefixRoute(serviceAV1, "/v1")
+
+			testutils.CreateConsulServiceRoutes(ctx, k8sClient, serviceA, []consulk8s.ServiceRoute{serviceAV1Route})
+
+			route, err := testutils.GetConsulServiceRoute(ctx, k8sClient, serviceAV1)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForEvent(ctx, k8sClient, route, "Merged")
+			Expect(err).NotTo(HaveOccurred())
+
+			serviceRouter, err := testutils.GetServiceRouter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForEvent(ctx, k8sClient, serviceRouter, "Created")
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("Label change", func() {
+		It("should move the route to the new service router", func() {
+			serviceAV1Route := testutils.CreateHTTPPathPrefixRoute(serviceAV1, "/v1")
+			serviceAV2Route := testutils.CreateHTTPPathPrefixRoute(serviceAV2, "/v2")
+
+			testutils.CreateConsulServiceRoutes(ctx, k8sClient, serviceA, []consulk8s.ServiceRoute{serviceAV1Route, serviceAV2Route})
+
+			err := testutils.WaitForConsulServiceRouteCondition(ctx, k8sClient, serviceAV1, v1alpha1.ConditionTypeMerged, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			moved, err := testutils.GetConsulServiceRoute(ctx, k8sClient, serviceAV1)
+			Expect(err).NotTo(HaveOccurred())
+
+			moved.Labels[testutils.ServiceRouterLabel] = serviceB
+			err = testutils.UpdateConsulServiceRoute(ctx, k8sClient, moved)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForServiceRouterToBeCreated(ctx, k8sClient, serviceB)
+			Expect(err).NotTo(HaveOccurred())
+
+			time.Sleep(time.Second)
+
+			serviceRouterA, err := testutils.GetServiceRouter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceRouterA).NotTo(BeNil())
+			Expect(serviceRouterA.Spec.Routes).To(Equal([]consulk8s.ServiceRoute{serviceAV2Route}))
+			for _, ownerReference := range serviceRouterA.OwnerReferences {
+				Expect(ownerReference.Name).NotTo(Equal(serviceAV1))
+			}
+
+			serviceRouterB, err := testutils.GetServiceRouter(ctx, k8sClient, serviceB)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceRouterB).NotTo(BeNil())
+			Expect(serviceRouterB.Spec.Routes).To(Equal([]consulk8s.ServiceRoute{serviceAV1Route}))
+
+			moved, err = testutils.GetConsulServiceRoute(ctx, k8sClient, serviceAV1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(moved.Status.Destination.Name).To(Equal(serviceB))
+		})
+
+		It("should delete the last service router when its only route is moved", func() {
+			serviceAV1Route := testutils.CreateHTTPPathPrefixRoute(serviceAV1, "/v1")
+
+			testutils.CreateConsulServiceRoutes(ctx, k8sClient, serviceA, []consulk8s.ServiceRoute{serviceAV1Route})
+
+			err := testutils.WaitForConsulServiceRouteCondition(ctx, k8sClient, serviceAV1, v1alpha1.ConditionTypeMerged, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			moved, err := testutils.GetConsulServiceRoute(ctx, k8sClient, serviceAV1)
+			Expect(err).NotTo(HaveOccurred())
+
+			moved.Labels[testutils.ServiceRouterLabel] = serviceB
+			err = testutils.UpdateConsulServiceRoute(ctx, k8sClient, moved)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForServiceRouterToBeCreated(ctx, k8sClient, serviceB)
+			Expect(err).NotTo(HaveOccurred())
+
+			time.Sleep(time.Second)
+
+			serviceRouterA, err := testutils.GetServiceRouter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceRouterA).To(BeNil())
+		})
+	})
+
+	Context("Drift", func() {
+		It("should revert the changes of the service router made outside of the controller", func() {
+			serviceAV1Route := testutils.CreateHTTPPathPrefixRoute(serviceAV1, "/v1")
+
+			testutils.CreateConsulServiceRoutes(ctx, k8sClient, serviceA, []consulk8s.ServiceRoute{serviceAV1Route})
+
+			serviceRouter, err := testutils.GetServiceRouter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+
+			serviceRouter.Spec.Routes = append(serviceRouter.Spec.Routes, testutils.CreateHTTPPathPrefixRoute(serviceAV2, "/v2"))
+			err = k8sClient.Update(ctx, serviceRouter)
+			Expect(err).NotTo(HaveOccurred())
+
+			time.Sleep(time.Second)
+
+			serviceRouter, err = testutils.GetServiceRouter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceRouter.Spec.Routes).To(Equal([]consulk8s.ServiceRoute{serviceAV1Route}))
+			Expect(serviceRouter.Annotations).To(HaveKeyWithValue(testutils.RevertedDriftsAnnotation, "1"))
+		})
+
+		It("should recreate the service router if it is deleted outside of the controller", func() {
+			serviceAV1Route := testutils.CreateHTTPPathPrefixRoute(serviceAV1, "/v1")
+
+			testutils.CreateConsulServiceRoutes(ctx, k8sClient, serviceA, []consulk8s.ServiceRoute{serviceAV1Route})
+
+			err := testutils.WaitForConsulServiceRouteCondition(ctx, k8sClient, serviceAV1, v1alpha1.ConditionTypeMerged, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			serviceRouter, err := testutils.GetServiceRouter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Delete(ctx, serviceRouter)
+			Expect(err).NotTo(HaveOccurred())
+
+			time.Sleep(time.Second)
+
+			serviceRouter, err = testutils.GetServiceRouter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceRouter).NotTo(BeNil())
+			Expect(serviceRouter.Spec.Routes).To(Equal([]consulk8s.ServiceRoute{serviceAV1Route}))
+			Expect(serviceRouter.Annotations).To(HaveKeyWithValue(testutils.RevertedDriftsAnnotation, "1"))
+		})
+	})
+
+	Context("Overwrite adoption mode", func() {
+		It("should replace the routes of a service router which wasn't created by the controller", func() {
+			unmanagedRoute := testutils.CreateHTTPPathPrefixRoute(serviceAV2, "/unmanaged")
+
+			err := testutils.CreateServiceRouter(ctx, k8sClient, serviceA, []consulk8s.ServiceRoute{unmanagedRoute})
+			Expect(err).NotTo(HaveOccurred())
+
+			serviceAV1Route := testutils.CreateHTTPPathPrefixRoute(serviceAV1, "/v1")
+
+			testutils.CreateConsulServiceRoutes(ctx, k8sClient, serviceA, []consulk8s.ServiceRoute{serviceAV1Route})
+
+			serviceRouter, err := testutils.GetServiceRouter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceRouter.Spec.Routes).To(Equal([]consulk8s.ServiceRoute{serviceAV1Route}))
+			Expect(serviceRouter.Annotations).NotTo(HaveKey(testutils.UnmanagedEntriesAnnotation))
+			Expect(serviceRouter.Labels).To(HaveKey(testutils.ManagedByLabel))
+
+			err = testutils.DeleteConsulServiceRoute(ctx, k8sClient, serviceAV1)
+			Expect(err).NotTo(HaveOccurred())
+
+			time.Sleep(time.Second)
+
+			serviceRouter, err = testutils.GetServiceRouter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceRouter).To(BeNil())
+		})
+	})
+
+	Context("Adopt adoption mode", func() {
+		BeforeEach(func() {
+			stopControllers = testutils.RestartControllers(k8sClient, stopControllers, adoption.ModeAdopt)
+		})
+
+		AfterEach(func() {
+			stopControllers = testutils.RestartControllers(k8sClient, stopControllers, adoption.ModeOverwrite)
+		})
+
+		It("should keep the routes of a service router which wasn't created by the controller", func() {
+			unmanagedRoute := testutils.CreateHTTPPathPrefixRoute(serviceAV2, "/unmanaged")
+
+			err := testutils.CreateServiceRouter(ctx, k8sClient, serviceA, []consulk8s.ServiceRoute{unmanagedRoute})
+			Expect(err).NotTo(HaveOccurred())
+
+			serviceAV1Route := testutils.CreateHTTPPathPrefixRoute(serviceAV1, "/v1")
+
+			testutils.CreateConsulServiceRoutes(ctx, k8sClient, serviceA, []consulk8s.ServiceRoute{serviceAV1Route})
+
+			serviceRouter, err := testutils.GetServiceRouter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceRouter.Spec.Routes).To(Equal([]consulk8s.ServiceRoute{unmanagedRoute, serviceAV1Route}))
+			Expect(serviceRouter.Annotations).To(HaveKey(testutils.UnmanagedEntriesAnnotation))
+			Expect(serviceRouter.Labels).To(HaveKey(testutils.ManagedByLabel))
+
+			err = testutils.DeleteConsulServiceRoute(ctx, k8sClient, serviceAV1)
+			Expect(err).NotTo(HaveOccurred())
+
+			time.Sleep(time.Second)
+
+			serviceRouter, err = testutils.GetServiceRouter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceRouter).NotTo(BeNil())
+			Expect(serviceRouter.Spec.Routes).To(Equal([]consulk8s.ServiceRoute{unmanagedRoute}))
+
+			err = testutils.DeleteServiceRouter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("Metrics", func() {
+		It("should record the merges of the service router", func() {
+			created := testutil.ToFloat64(metrics.Merges.WithLabelValues("ServiceRouter", metrics.MergeResultCreated))
+
+			serviceAV1Route := testutils.CreateHTTPPathPrefixRoute(serviceAV1, "/v1")
+			serviceAV2Route := testutils.CreateHTTPPathPrefixRoute(serviceAV2, "/v2")
+
+			testutils.CreateConsulServiceRoutes(ctx, k8sClient, serviceA, []consulk8s.ServiceRoute{serviceAV1Route, serviceAV2Route})
+
+			Expect(testutil.ToFloat64(metrics.Merges.WithLabelValues("ServiceRouter", metrics.MergeResultCreated))).To(BeNumerically(">", created))
+			Expect(testutil.ToFloat64(metrics.DestinationItems.WithLabelValues("ServiceRouter", testutils.DefaultK8sNamespace, serviceA))).To(BeEquivalentTo(2))
+			Expect(testutil.ToFloat64(metrics.LastSuccessfulMerge.WithLabelValues("ServiceRouter", testutils.DefaultK8sNamespace, serviceA))).To(BeNumerically(">", 0))
+		})
+	})
+
+	Context("Conflicts", func() {
+		It("should set the conflict condition on the route with a duplicate match", func() {
+			serviceAV1Route := testutils.CreateHTTPPathPrefixRoute(serviceAV1, "/pr1")
+			serviceAV2Route := testutils.CreateHTTPPathPrefixRoute(serviceAV2, "/pr1")
+
+			testutils.CreateConsulServiceRoutes(ctx, k8sClient, serviceA, []consulk8s.ServiceRoute{serviceAV1Route, serviceAV2Route})
+
+			err := testutils.WaitForConsulServiceRouteCondition(ctx, k8sClient, serviceAV2, v1alpha1.ConditionTypeConflict, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			route, err := testutils.GetConsulServiceRoute(ctx, k8sClient, serviceAV1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(meta.IsStatusConditionTrue(route.Status.Conditions, v1alpha1.ConditionTypeConflict)).To(BeFalse())
+		})
+
+		It("should remove the conflict condition when the conflict is resolved", func() {
+			serviceAV1Route := testutils.CreateHTTPPathPrefixRoute(serviceAV1, "/")
+			serviceAV2Route := testutils.CreateHTTPPathPrefixRoute(serviceAV2, "/v2")
+
+			testutils.CreateConsulServiceRoutes(ctx, k8sClient, serviceA, []consulk8s.ServiceRoute{serviceAV1Route, serviceAV2Route})
+
+			err := testutils.WaitForConsulServiceRouteCondition(ctx, k8sClient, serviceAV2, v1alpha1.ConditionTypeConflict, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.DeleteConsulServiceRoute(ctx, k8sClient, serviceAV1)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.WaitForConsulServiceRouteCondition(ctx, k8sClient, serviceAV2, v1alpha1.ConditionTypeConflict, false)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
 	Context("Multiple routes in single service router", func() {
 		var serviceRoutes []consulk8s.ServiceRoute
 
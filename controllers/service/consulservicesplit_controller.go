@@ -0,0 +1,158 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/reconcile"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	"github.com/NativeChat/consul-merge-controller/pkg/splits"
+	"github.com/NativeChat/consul-merge-controller/pkg/strategies"
+)
+
+// ConsulServiceSplitReconciler reconciles a ConsulServiceSplit object
+type ConsulServiceSplitReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	Recorder record.EventRecorder
+
+	// MaxConcurrentReconciles is the maximum number of concurrent reconciles. Defaults to 1.
+	MaxConcurrentReconciles int
+
+	// ConsulBackend writes the merged destinations directly to Consul when it is set.
+	// The consul-k8s resources are neither created nor watched then.
+	ConsulBackend *services.ConsulBackend
+}
+
+// +kubebuilder:rbac:groups=service.consul.k8s.nativechat.com,resources=consulservicesplits,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=service.consul.k8s.nativechat.com,resources=consulservicesplits/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=service.consul.k8s.nativechat.com,resources=consulservicesplits/finalizers,verbs=update
+
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=servicesplitters,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=servicesplitters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=servicesplitters/finalizers,verbs=update
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *ConsulServiceSplitReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("servicesplitter", req.NamespacedName)
+
+	crdService := r.newCRDService(log)
+
+	kind := strategies.GetMergeKind(controllerlabels.ServiceSplitter)
+	options := strategies.MergeOptions{}
+	merger := kind.NewMerger(r.Client, r.Client, log, r.Recorder, options)
+	if r.ConsulBackend != nil {
+		merger = services.NewConsulMerger(merger, r.ConsulBackend)
+	}
+
+	reconciler := reconcile.NewReconciler(
+		r,
+		crdService,
+		merger,
+		log,
+		r.Recorder,
+		controllerlabels.ServiceSplitter,
+		kind.NewFindConflictsFunc(options),
+	)
+
+	res, err := reconciler.Reconcile(ctx, req)
+
+	// The weight policy outcome depends on all splits of the service splitter
+	// so it is reported on each of them even if the merge has failed.
+	statusErr := r.updateWeightPolicyStatus(ctx, log, crdService, req)
+	if statusErr != nil && err == nil {
+		return ctrl.Result{Requeue: true}, statusErr
+	}
+
+	return res, err
+}
+
+func (r *ConsulServiceSplitReconciler) updateWeightPolicyStatus(ctx context.Context, log logr.Logger, crdService services.CRDService, req ctrl.Request) error {
+	if !reconcile.IsDestinationRequest(req) {
+		return nil
+	}
+
+	serviceSplitterName := req.Name
+
+	resources, err := crdService.GetAllResourcesForService(ctx, controllerlabels.ServiceSplitter, serviceSplitterName, req.Namespace)
+	if err != nil {
+		log.Error(err, "failed to get all splits for the service splitter")
+
+		return err
+	}
+
+	results, _ := splits.ApplyWeightPolicy(resources)
+	for _, resource := range resources {
+		split := resource.(*servicev1alpha1.ConsulServiceSplit)
+		result := results[split.Name]
+
+		if split.Status.WeightPolicy == result.WeightPolicy &&
+			split.Status.EffectiveWeight == result.EffectiveWeight() &&
+			split.Status.Message == result.Message {
+			continue
+		}
+
+		patch := client.MergeFrom(split.DeepCopy())
+		split.Status.WeightPolicy = result.WeightPolicy
+		split.Status.EffectiveWeight = result.EffectiveWeight()
+		split.Status.Message = result.Message
+
+		log.Info("updating the weight policy status", "split", split.Name, "weightPolicy", result.WeightPolicy)
+		err = r.Status().Patch(ctx, split, patch)
+		if err != nil {
+			log.Error(err, "failed to update the weight policy status", "split", split.Name)
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *ConsulServiceSplitReconciler) newCRDService(log logr.Logger) services.CRDService {
+	return strategies.GetMergeKind(controllerlabels.ServiceSplitter).NewCRDService(r.Client, r.Client, log)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ConsulServiceSplitReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	mapSource := reconcile.NewSourceMapFunc(r.newCRDService(r.Log), controllerlabels.ServiceSplitter)
+
+	// The requests are for the merge destinations, so the changes of all resources
+	// with the same destination are merged at once.
+	return reconcile.SetupWithManager(
+		mgr,
+		controller.Options{Reconciler: r, MaxConcurrentReconciles: r.MaxConcurrentReconciles},
+		&consulk8s.ServiceSplitter{},
+		&servicev1alpha1.ConsulServiceSplit{},
+		mapSource,
+		r.ConsulBackend == nil,
+	)
+}
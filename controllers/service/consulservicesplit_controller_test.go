@@ -0,0 +1,149 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+
+	"github.com/NativeChat/consul-merge-controller/pkg/splits"
+	"github.com/NativeChat/consul-merge-controller/testutils"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConsulServiceSplit controller", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		for _, serviceDefaultsName := range serviceDefaults {
+			err := testutils.CreateServiceDefaults(ctx, k8sClient, serviceDefaultsName)
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	AfterEach(func() {
+		testutils.DeleteConsulServiceSplits(ctx, k8sClient, serviceA)
+		testutils.DeleteConsulServiceSplits(ctx, k8sClient, serviceB)
+
+		for _, serviceDefaultsName := range serviceDefaults {
+			err := testutils.DeleteServiceDefaults(ctx, k8sClient, serviceDefaultsName)
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	Context("Merge", func() {
+		It("should merge splits whose weights add up to 100", func() {
+			serviceASplits := []consulk8s.ServiceSplit{
+				testutils.CreateServiceSplit(serviceAV1, 90),
+				testutils.CreateServiceSplit(serviceAV2, 10),
+			}
+
+			for _, split := range serviceASplits {
+				err := testutils.CreateConsulServiceSplit(ctx, k8sClient, serviceA, split, false)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			err := testutils.WaitForServiceSplitterToBeCreated(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+
+			serviceSplitter, err := testutils.GetServiceSplitter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceSplitter).NotTo(BeNil())
+			Expect(serviceSplitter.Spec.Splits).To(ConsistOf(serviceASplits))
+
+			testutils.ExpectConsulServiceSplitWeightPolicy(ctx, k8sClient, serviceAV1, splits.WeightPolicyAccepted, "90")
+			testutils.ExpectConsulServiceSplitWeightPolicy(ctx, k8sClient, serviceAV2, splits.WeightPolicyAccepted, "10")
+		})
+
+		It("should assign the remainder of the weights to the default split", func() {
+			err := testutils.CreateConsulServiceSplit(ctx, k8sClient, serviceA, testutils.CreateServiceSplit(serviceAV1, 0), true)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.CreateConsulServiceSplit(ctx, k8sClient, serviceA, testutils.CreateServiceSplit(serviceAV2, 10), false)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testutils.CreateConsulServiceSplit(ctx, k8sClient, serviceA, testutils.CreateServiceSplit(serviceAV3, 5.5), false)
+			Expect(err).NotTo(HaveOccurred())
+
+			testutils.ExpectConsulServiceSplitWeightPolicy(ctx, k8sClient, serviceAV1, splits.WeightPolicyRemainderAssigned, "84.5")
+			testutils.ExpectConsulServiceSplitWeightPolicy(ctx, k8sClient, serviceAV2, splits.WeightPolicyAccepted, "10")
+			testutils.ExpectConsulServiceSplitWeightPolicy(ctx, k8sClient, serviceAV3, splits.WeightPolicyAccepted, "5.5")
+
+			serviceSplitter, err := testutils.GetServiceSplitter(ctx, k8sClient, serviceA)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceSplitter).NotTo(BeNil())
+			Expect(serviceSplitter.Spec.Splits).To(ConsistOf(
+				testutils.CreateServiceSplit(serviceAV1, 84.5),
+				testutils.CreateServiceSplit(serviceAV2, 10),
+				testutils.CreateServiceSplit(serviceAV3, 5.5),
+			))
+		})
+
+		It("should reject splits whose weights don't add up to 100", func() {
+			serviceBSplits := []consulk8s.ServiceSplit{
+				testutils.CreateServiceSplit(serviceBV1, 50),
+				testutils.CreateServiceSplit(serviceBV2, 40),
+			}
+
+			for _, split := range serviceBSplits {
+				err := testutils.CreateConsulServiceSplit(ctx, k8sClient, serviceB, split, false)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			testutils.ExpectConsulServiceSplitWeightPolicy(ctx, k8sClient, serviceBV1, splits.WeightPolicyRejected, "")
+			testutils.ExpectConsulServiceSplitWeightPolicy(ctx, k8sClient, serviceBV2, splits.WeightPolicyRejected, "")
+
+			serviceSplitter, err := testutils.GetServiceSplitter(ctx, k8sClient, serviceB)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceSplitter).To(BeNil())
+		})
+
+		It("should keep the last accepted service splitter when the weights are rejected", func() {
+			serviceBSplits := []consulk8s.ServiceSplit{
+				testutils.CreateServiceSplit(serviceBV1, 50),
+				testutils.CreateServiceSplit(serviceBV2, 50),
+			}
+
+			for _, split := range serviceBSplits {
+				err := testutils.CreateConsulServiceSplit(ctx, k8sClient, serviceB, split, false)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			err := testutils.WaitForServiceSplitterToBeCreated(ctx, k8sClient, serviceB)
+			Expect(err).NotTo(HaveOccurred())
+
+			updated, err := testutils.GetConsulServiceSplit(ctx, k8sClient, serviceBV2)
+			Expect(err).NotTo(HaveOccurred())
+
+			updated.Spec.Split.Weight = 60
+
+			err = testutils.UpdateConsulServiceSplit(ctx, k8sClient, updated)
+			Expect(err).NotTo(HaveOccurred())
+
+			testutils.ExpectConsulServiceSplitWeightPolicy(ctx, k8sClient, serviceBV1, splits.WeightPolicyRejected, "")
+			testutils.ExpectConsulServiceSplitWeightPolicy(ctx, k8sClient, serviceBV2, splits.WeightPolicyRejected, "")
+
+			serviceSplitter, err := testutils.GetServiceSplitter(ctx, k8sClient, serviceB)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceSplitter).NotTo(BeNil())
+			Expect(serviceSplitter.Spec.Splits).To(ConsistOf(serviceBSplits))
+		})
+	})
+})
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/NativeChat/consul-merge-controller/testutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	terminatingGatewayA = "terminating-gateway-a"
+	terminatingGatewayB = "terminating-gateway-b"
+
+	externalServiceA = "external-service-a"
+	externalServiceB = "external-service-b"
+)
+
+var _ = Describe("ConsulTerminatingGatewayService controller", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		testutils.DeleteConsulTerminatingGatewayServices(ctx, k8sClient, terminatingGatewayA)
+		testutils.DeleteConsulTerminatingGatewayServices(ctx, k8sClient, terminatingGatewayB)
+	})
+
+	It("should merge the linked services into the terminating gateway", func() {
+		externalServiceALinkedService := testutils.CreateLinkedService(externalServiceA, "a.example.com")
+		externalServiceBLinkedService := testutils.CreateLinkedService(externalServiceB, "b.example.com")
+
+		_, err := testutils.CreateConsulTerminatingGatewayService(ctx, k8sClient, terminatingGatewayA, externalServiceALinkedService)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = testutils.CreateConsulTerminatingGatewayService(ctx, k8sClient, terminatingGatewayA, externalServiceBLinkedService)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = testutils.WaitForTerminatingGatewayToBeCreated(ctx, k8sClient, terminatingGatewayA)
+		Expect(err).NotTo(HaveOccurred())
+
+		terminatingGateway, err := testutils.GetTerminatingGateway(ctx, k8sClient, terminatingGatewayA)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(terminatingGateway).NotTo(BeNil())
+
+		Expect(terminatingGateway.Spec.Services).To(HaveLen(2))
+		Expect(terminatingGateway.Spec.Services).To(ContainElements(externalServiceALinkedService, externalServiceBLinkedService))
+	})
+
+	It("should delete the terminating gateway if all services for it are deleted.", func() {
+		externalServiceAName, err := testutils.CreateConsulTerminatingGatewayService(ctx, k8sClient, terminatingGatewayA, testutils.CreateLinkedService(externalServiceA, ""))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = testutils.CreateConsulTerminatingGatewayService(ctx, k8sClient, terminatingGatewayB, testutils.CreateLinkedService(externalServiceB, ""))
+		Expect(err).NotTo(HaveOccurred())
+
+		err = testutils.DeleteConsulTerminatingGatewayService(ctx, k8sClient, externalServiceAName)
+		Expect(err).NotTo(HaveOccurred())
+
+		time.Sleep(time.Second)
+
+		terminatingGatewayAResource, err := testutils.GetTerminatingGateway(ctx, k8sClient, terminatingGatewayA)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(terminatingGatewayAResource).To(BeNil())
+
+		terminatingGatewayBResource, err := testutils.GetTerminatingGateway(ctx, k8sClient, terminatingGatewayB)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(terminatingGatewayBResource).NotTo(BeNil())
+	})
+})
@@ -17,6 +17,7 @@ limitations under the License.
 package service_test
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -31,6 +32,7 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	"github.com/NativeChat/consul-merge-controller/pkg/adoption"
 	"github.com/NativeChat/consul-merge-controller/testutils"
 
 	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
@@ -44,6 +46,13 @@ var cfg *rest.Config
 var k8sClient client.Client
 var testEnv *envtest.Environment
 
+// stopControllers stops the controllers. The controllers run with the default overwrite adoption mode,
+// the contexts which test the other modes restart them.
+var stopControllers func()
+
+// useLocalConsul runs the suite against real consul and consul-k8s binaries instead of the in-process fake consul-k8s.
+var useLocalConsul = os.Getenv("USE_LOCAL_CONSUL") == "true"
+
 func TestAPIs(t *testing.T) {
 	RegisterFailHandler(Fail)
 
@@ -57,7 +66,10 @@ var _ = BeforeSuite(func() {
 
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
-		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "bases")},
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "..", "config", "crd", "bases"),
+			filepath.Join("..", "..", "config", "crd", "consul-k8s"),
+		},
 		ErrorIfCRDPathMissing: true,
 	}
 
@@ -84,15 +96,22 @@ var _ = BeforeSuite(func() {
 	Expect(k8sClient).NotTo(BeNil())
 
 	// Start test consul env.
-	err = testutils.StartConsulLocalEnv(testEnv.Config)
+	if useLocalConsul {
+		err = testutils.StartConsulLocalEnv(testEnv.Config)
+	} else {
+		err = testutils.StartFakeConsulK8s(k8sClient, true)
+	}
 	Expect(err).NotTo(HaveOccurred())
 
-	testutils.StartControllers(k8sClient)
+	stopControllers = testutils.StartControllers(k8sClient, adoption.ModeOverwrite)
 }, 60)
 
 var _ = AfterSuite(func() {
 	By("tearing down the test environment")
 
+	stopControllers()
+
+	testutils.StopFakeConsulK8s()
 	stopConsulErr := testutils.StopConsulLocalEnv()
 	stopTestEnvErr := testEnv.Stop()
 
@@ -5,9 +5,15 @@ go 1.16
 require (
 	github.com/go-logr/logr v0.4.0
 	github.com/hashicorp/consul-k8s v0.26.0
+	github.com/hashicorp/consul/api v1.9.0
 	github.com/onsi/ginkgo v1.16.4
 	github.com/onsi/gomega v1.13.0
+	github.com/prometheus/client_golang v1.11.0
+	k8s.io/api v0.21.1
+	k8s.io/apiextensions-apiserver v0.21.1
 	k8s.io/apimachinery v0.21.1
 	k8s.io/client-go v0.21.1
+	k8s.io/component-base v0.21.1
 	sigs.k8s.io/controller-runtime v0.9.0
+	sigs.k8s.io/yaml v1.2.0
 )
@@ -18,7 +18,9 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -26,14 +28,26 @@ import (
 
 	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	componentconfigv1alpha1 "k8s.io/component-base/config/v1alpha1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	configv1alpha1 "github.com/NativeChat/consul-merge-controller/apis/config/v1alpha1"
 	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
 	servicecontrollers "github.com/NativeChat/consul-merge-controller/controllers/service"
+	"github.com/NativeChat/consul-merge-controller/pkg/adoption"
+	"github.com/NativeChat/consul-merge-controller/pkg/consul"
+	"github.com/NativeChat/consul-merge-controller/pkg/finalizers"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/routes"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	"github.com/NativeChat/consul-merge-controller/pkg/webhooks"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -47,6 +61,7 @@ func init() {
 	utilruntime.Must(consulk8s.AddToScheme(scheme))
 
 	utilruntime.Must(servicev1alpha1.AddToScheme(scheme))
+	utilruntime.Must(configv1alpha1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -54,11 +69,47 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var routeOrdering string
+	var excludeConflictingRoutes bool
+	var adoptionMode string
+	var enableWebhooks bool
+	var configFile string
+	var backend string
+	var consulAddress string
+	var consulDatacenter string
+	var consulTokenSecret string
+	var consulTokenSecretKey string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&routeOrdering, "route-ordering", routes.OrderingPriority,
+		fmt.Sprintf("The ordering of the routes in the service routers which don't set the %s annotation. "+
+			"One of %s or %s.", routes.GetOrderingAnnotation(), routes.OrderingPriority, routes.OrderingSpecificity))
+	flag.BoolVar(&excludeConflictingRoutes, "exclude-conflicting-routes", false,
+		"Exclude the duplicated and fully shadowed routes from the service routers.")
+	flag.StringVar(&adoptionMode, "adoption-mode", adoption.ModeOverwrite,
+		fmt.Sprintf("The handling of the service routers and service intentions which weren't created by the controller. "+
+			"One of %s, %s or %s.", adoption.ModeOverwrite, adoption.ModeAdopt, adoption.ModeStrict))
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Enable the validating webhooks for ConsulServiceRoute and ConsulServiceIntentionsSource.")
+	flag.StringVar(&configFile, "config", "",
+		"The controller will load its initial configuration from this file. "+
+			"Omit this flag to use the default configuration values. "+
+			"The flags are used for the options which aren't set in the file.")
+	flag.StringVar(&backend, "backend", backendKubernetes,
+		fmt.Sprintf("Where the merged resources are written. One of %s, which creates the consul-k8s resources, "+
+			"or %s, which writes the config entries directly to the Consul HTTP API.", backendKubernetes, backendConsul))
+	flag.StringVar(&consulAddress, "consul-address", "",
+		"The address of the Consul HTTP API used with the consul backend. Defaults to CONSUL_HTTP_ADDR or 127.0.0.1:8500.")
+	flag.StringVar(&consulDatacenter, "consul-datacenter", "dc1",
+		"The datacenter stored in the meta of the config entries written with the consul backend.")
+	flag.StringVar(&consulTokenSecret, "consul-token-secret", "",
+		"The namespace/name of the Secret with the Consul ACL token used with the consul backend. "+
+			"Defaults to the CONSUL_HTTP_TOKEN environment variable.")
+	flag.StringVar(&consulTokenSecretKey, "consul-token-secret-key", "token",
+		"The key of the Consul ACL token in the Secret.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -67,35 +118,163 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
-		MetricsBindAddress:     metricsAddr,
-		Port:                   9443,
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "db3a0810.consul.k8s.nativechat.com",
-	})
+	if !routes.IsValidOrdering(routeOrdering) {
+		setupLog.Error(fmt.Errorf("invalid route ordering %s", routeOrdering), "unable to start manager")
+		os.Exit(1)
+	}
+
+	if !adoption.IsValidMode(adoptionMode) {
+		setupLog.Error(fmt.Errorf("invalid adoption mode %s", adoptionMode), "unable to start manager")
+		os.Exit(1)
+	}
+
+	options, ctrlConfig, err := loadConfig(configFile, metricsAddr, probeAddr, enableLeaderElection)
 	if err != nil {
-		setupLog.Error(err, "unable to start manager")
+		setupLog.Error(err, "unable to load the config file")
 		os.Exit(1)
 	}
 
-	if err = (&servicecontrollers.ConsulServiceRouteReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulServiceRoute"),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ConsulServiceRoute")
+	err = checkBackend(backend, adoptionMode, ctrlConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
-	if err = (&servicecontrollers.ConsulServiceIntentionsSourceReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulServiceIntentionsSource"),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ConsulServiceIntentionsSource")
+
+	if len(ctrlConfig.Namespaces) > 0 {
+		options.NewCache = cache.MultiNamespacedCacheBuilder(ctrlConfig.Namespaces)
+	}
+
+	if len(ctrlConfig.LabelPrefix) > 0 {
+		controllerlabels.SetPrefix(ctrlConfig.LabelPrefix)
+	}
+
+	if len(ctrlConfig.FinalizerPrefix) > 0 {
+		finalizers.SetPrefix(ctrlConfig.FinalizerPrefix)
+	}
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
+
+	var consulBackend *services.ConsulBackend
+	if backend == backendConsul {
+		consulBackend, err = newConsulBackend(mgr, consulAddress, consulDatacenter, consulTokenSecret, consulTokenSecretKey)
+		if err != nil {
+			setupLog.Error(err, "unable to create the consul backend")
+			os.Exit(1)
+		}
+	}
+
+	controllers := []struct {
+		kind       string
+		reconciler reconciler
+	}{
+		{
+			kind: "ConsulServiceRoute",
+			reconciler: &servicecontrollers.ConsulServiceRouteReconciler{
+				Client:                   mgr.GetClient(),
+				Log:                      ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulServiceRoute"),
+				Scheme:                   mgr.GetScheme(),
+				RouteOrdering:            routeOrdering,
+				ExcludeConflictingRoutes: excludeConflictingRoutes,
+				AdoptionMode:             adoptionMode,
+				Recorder:                 mgr.GetEventRecorderFor("consulserviceroute-controller"),
+				MaxConcurrentReconciles:  ctrlConfig.GetControllerConfig("ConsulServiceRoute").MaxConcurrentReconciles,
+				ConsulBackend:            consulBackend,
+			},
+		},
+		{
+			kind: "ConsulServiceIntentionsSource",
+			reconciler: &servicecontrollers.ConsulServiceIntentionsSourceReconciler{
+				Client:                  mgr.GetClient(),
+				Log:                     ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulServiceIntentionsSource"),
+				Scheme:                  mgr.GetScheme(),
+				AdoptionMode:            adoptionMode,
+				Recorder:                mgr.GetEventRecorderFor("consulserviceintentionssource-controller"),
+				MaxConcurrentReconciles: ctrlConfig.GetControllerConfig("ConsulServiceIntentionsSource").MaxConcurrentReconciles,
+				ConsulBackend:           consulBackend,
+			},
+		},
+		{
+			kind: "ConsulServiceSplit",
+			reconciler: &servicecontrollers.ConsulServiceSplitReconciler{
+				Client:                  mgr.GetClient(),
+				Log:                     ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulServiceSplit"),
+				Scheme:                  mgr.GetScheme(),
+				Recorder:                mgr.GetEventRecorderFor("consulservicesplit-controller"),
+				MaxConcurrentReconciles: ctrlConfig.GetControllerConfig("ConsulServiceSplit").MaxConcurrentReconciles,
+				ConsulBackend:           consulBackend,
+			},
+		},
+		{
+			kind: "ConsulServiceResolverSubset",
+			reconciler: &servicecontrollers.ConsulServiceResolverSubsetReconciler{
+				Client:                  mgr.GetClient(),
+				Log:                     ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulServiceResolverSubset"),
+				Scheme:                  mgr.GetScheme(),
+				Recorder:                mgr.GetEventRecorderFor("consulserviceresolversubset-controller"),
+				MaxConcurrentReconciles: ctrlConfig.GetControllerConfig("ConsulServiceResolverSubset").MaxConcurrentReconciles,
+				ConsulBackend:           consulBackend,
+			},
+		},
+		{
+			kind: "ConsulIngressGatewayService",
+			reconciler: &servicecontrollers.ConsulIngressGatewayServiceReconciler{
+				Client:                  mgr.GetClient(),
+				Log:                     ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulIngressGatewayService"),
+				Scheme:                  mgr.GetScheme(),
+				Recorder:                mgr.GetEventRecorderFor("consulingressgatewayservice-controller"),
+				MaxConcurrentReconciles: ctrlConfig.GetControllerConfig("ConsulIngressGatewayService").MaxConcurrentReconciles,
+				ConsulBackend:           consulBackend,
+			},
+		},
+		{
+			kind: "ConsulTerminatingGatewayService",
+			reconciler: &servicecontrollers.ConsulTerminatingGatewayServiceReconciler{
+				Client:                  mgr.GetClient(),
+				Log:                     ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulTerminatingGatewayService"),
+				Scheme:                  mgr.GetScheme(),
+				Recorder:                mgr.GetEventRecorderFor("consulterminatinggatewayservice-controller"),
+				MaxConcurrentReconciles: ctrlConfig.GetControllerConfig("ConsulTerminatingGatewayService").MaxConcurrentReconciles,
+				ConsulBackend:           consulBackend,
+			},
+		},
+		{
+			kind: "ConsulConfigFragment",
+			reconciler: &servicecontrollers.ConsulConfigFragmentReconciler{
+				Client:                  mgr.GetClient(),
+				Log:                     ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulConfigFragment"),
+				Scheme:                  mgr.GetScheme(),
+				Recorder:                mgr.GetEventRecorderFor("consulconfigfragment-controller"),
+				MaxConcurrentReconciles: ctrlConfig.GetControllerConfig("ConsulConfigFragment").MaxConcurrentReconciles,
+			},
+		},
+	}
+
+	for _, c := range controllers {
+		if !ctrlConfig.GetControllerConfig(c.kind).IsEnabled() {
+			setupLog.Info("the controller is disabled", "controller", c.kind)
+
+			continue
+		}
+
+		if err = c.reconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", c.kind)
+			os.Exit(1)
+		}
+	}
+
+	if enableWebhooks {
+		webhookServer := mgr.GetWebhookServer()
+		webhookServer.Register(webhooks.ConsulServiceRouteValidatePath, &webhook.Admission{
+			Handler: webhooks.NewConsulServiceRouteValidator(mgr.GetClient(), ctrl.Log.WithName("webhooks").WithName("ConsulServiceRoute")),
+		})
+		webhookServer.Register(webhooks.ConsulServiceIntentionsSourceValidatePath, &webhook.Admission{
+			Handler: webhooks.NewConsulServiceIntentionsSourceValidator(ctrl.Log.WithName("webhooks").WithName("ConsulServiceIntentionsSource")),
+		})
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("health", healthz.Ping); err != nil {
@@ -113,3 +292,136 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+const (
+	backendKubernetes = "kubernetes"
+	backendConsul     = "consul"
+)
+
+type reconciler interface {
+	SetupWithManager(mgr ctrl.Manager) error
+}
+
+// controllerKinds are the kinds of the resources merged by the controllers, which configure the controllers in the config file.
+var controllerKinds = []string{
+	"ConsulServiceRoute",
+	"ConsulServiceIntentionsSource",
+	"ConsulServiceSplit",
+	"ConsulServiceResolverSubset",
+	"ConsulIngressGatewayService",
+	"ConsulTerminatingGatewayService",
+	"ConsulConfigFragment",
+}
+
+// loadConfig loads the options of the manager and the configuration of the controllers from the config file
+// and sets the options which aren't set in the file from the flags. The config file is optional.
+func loadConfig(configFile, metricsAddr, probeAddr string, enableLeaderElection bool) (ctrl.Options, configv1alpha1.ControllerManagerConfig, error) {
+	// The leader election is set, so the options can be loaded from a file without it.
+	ctrlConfig := configv1alpha1.ControllerManagerConfig{}
+	ctrlConfig.LeaderElection = &componentconfigv1alpha1.LeaderElectionConfiguration{}
+	options := ctrl.Options{Scheme: scheme}
+	if configFile != "" {
+		var err error
+		options, err = options.AndFrom(ctrl.ConfigFile().AtPath(configFile).OfKind(&ctrlConfig))
+		if err != nil {
+			return options, ctrlConfig, err
+		}
+	}
+
+	setDefaultOptions(&options, metricsAddr, probeAddr, enableLeaderElection)
+
+	// The controllers are checked before any of them is set up, so a typo doesn't leave some of them running.
+	for kind := range ctrlConfig.Controllers {
+		if !isControllerKind(kind) {
+			return options, ctrlConfig, fmt.Errorf("unknown controller %s in the config file", kind)
+		}
+	}
+
+	return options, ctrlConfig, nil
+}
+
+// checkBackend checks that the backend supports the adoption mode and the enabled controllers.
+// The config fragments are merged into Kubernetes resources, so they can't be enabled with the consul backend
+// which would write the other config entries directly to Consul.
+func checkBackend(backend, adoptionMode string, ctrlConfig configv1alpha1.ControllerManagerConfig) error {
+	if backend != backendKubernetes && backend != backendConsul {
+		return fmt.Errorf("invalid backend %s", backend)
+	}
+
+	if backend != backendConsul {
+		return nil
+	}
+
+	if adoptionMode == adoption.ModeAdopt {
+		return fmt.Errorf("the adoption mode %s isn't supported by the %s backend", adoptionMode, backendConsul)
+	}
+
+	if ctrlConfig.GetControllerConfig("ConsulConfigFragment").IsEnabled() {
+		return fmt.Errorf("the ConsulConfigFragment controller isn't supported by the %s backend, disable it in the config file", backendConsul)
+	}
+
+	return nil
+}
+
+func isControllerKind(kind string) bool {
+	for _, controllerKind := range controllerKinds {
+		if controllerKind == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setDefaultOptions sets the options which aren't set in the config file from the flags.
+func setDefaultOptions(options *ctrl.Options, metricsAddr, probeAddr string, enableLeaderElection bool) {
+	if options.MetricsBindAddress == "" {
+		options.MetricsBindAddress = metricsAddr
+	}
+
+	if options.HealthProbeBindAddress == "" {
+		options.HealthProbeBindAddress = probeAddr
+	}
+
+	if options.Port == 0 {
+		options.Port = 9443
+	}
+
+	if !options.LeaderElection {
+		options.LeaderElection = enableLeaderElection
+	}
+
+	if options.LeaderElectionID == "" {
+		options.LeaderElectionID = "db3a0810.consul.k8s.nativechat.com"
+	}
+}
+
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// newConsulBackend creates the backend which writes the config entries to the Consul HTTP API.
+// The ACL token is read from the Secret when its name is set.
+func newConsulBackend(mgr ctrl.Manager, address, datacenter, tokenSecret, tokenSecretKey string) (*services.ConsulBackend, error) {
+	configEntries, err := consul.NewConfigEntries(address)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenSource := consul.NewStaticTokenSource("")
+	if len(tokenSecret) > 0 {
+		parts := strings.SplitN(tokenSecret, "/", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf("invalid Consul ACL token secret %s, expected namespace/name", tokenSecret)
+		}
+
+		// The Secret is read without the cache, so the controller doesn't watch all secrets.
+		tokenSource = consul.NewSecretTokenSource(mgr.GetAPIReader(), types.NamespacedName{Namespace: parts[0], Name: parts[1]}, tokenSecretKey)
+	}
+
+	backend := &services.ConsulBackend{
+		ConfigEntries: configEntries,
+		TokenSource:   tokenSource,
+		Datacenter:    datacenter,
+	}
+
+	return backend, nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	configv1alpha1 "github.com/NativeChat/consul-merge-controller/apis/config/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/adoption"
+)
+
+var _ = Describe("loadConfig", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "consul-merge-controller")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	writeConfig := func(content string) string {
+		path := filepath.Join(dir, "config.yaml")
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+
+		return path
+	}
+
+	It("sets the options from the flags without a config file", func() {
+		options, ctrlConfig, err := loadConfig("", ":9090", ":9091", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options.MetricsBindAddress).To(Equal(":9090"))
+		Expect(options.HealthProbeBindAddress).To(Equal(":9091"))
+		Expect(options.LeaderElection).To(BeTrue())
+		Expect(options.LeaderElectionID).To(Equal("db3a0810.consul.k8s.nativechat.com"))
+		Expect(options.Port).To(Equal(9443))
+		Expect(ctrlConfig.Controllers).To(BeEmpty())
+		Expect(ctrlConfig.GetControllerConfig("ConsulServiceRoute").IsEnabled()).To(BeTrue())
+	})
+
+	It("prefers the options from the config file to the flags", func() {
+		path := writeConfig(`apiVersion: config.consul.k8s.nativechat.com/v1alpha1
+kind: ControllerManagerConfig
+metrics:
+  bindAddress: 127.0.0.1:8080
+namespaces:
+- default
+labelPrefix: example.com
+finalizerPrefix: example.org
+controllers:
+  ConsulServiceRoute:
+    maxConcurrentReconciles: 4
+  ConsulConfigFragment:
+    enabled: false
+`)
+
+		options, ctrlConfig, err := loadConfig(path, ":9090", ":9091", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options.MetricsBindAddress).To(Equal("127.0.0.1:8080"))
+		Expect(options.HealthProbeBindAddress).To(Equal(":9091"))
+		Expect(options.LeaderElection).To(BeFalse())
+		Expect(ctrlConfig.Namespaces).To(Equal([]string{"default"}))
+		Expect(ctrlConfig.LabelPrefix).To(Equal("example.com"))
+		Expect(ctrlConfig.FinalizerPrefix).To(Equal("example.org"))
+		Expect(ctrlConfig.GetControllerConfig("ConsulServiceRoute").MaxConcurrentReconciles).To(Equal(4))
+		Expect(ctrlConfig.GetControllerConfig("ConsulServiceRoute").IsEnabled()).To(BeTrue())
+		Expect(ctrlConfig.GetControllerConfig("ConsulConfigFragment").IsEnabled()).To(BeFalse())
+		Expect(ctrlConfig.GetControllerConfig("ConsulServiceSplit").IsEnabled()).To(BeTrue())
+	})
+
+	It("loads the example config file", func() {
+		options, ctrlConfig, err := loadConfig(filepath.Join("config", "manager", "controller_manager_config.yaml"), ":9090", ":9091", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options.LeaderElection).To(BeTrue())
+		Expect(ctrlConfig.Controllers).To(HaveLen(len(controllerKinds)))
+	})
+
+	It("fails for an unknown controller", func() {
+		path := writeConfig(`apiVersion: config.consul.k8s.nativechat.com/v1alpha1
+kind: ControllerManagerConfig
+controllers:
+  ConsulServiceRouter:
+    enabled: false
+`)
+
+		_, _, err := loadConfig(path, ":9090", ":9091", false)
+		Expect(err).To(MatchError("unknown controller ConsulServiceRouter in the config file"))
+	})
+
+	It("fails for a missing config file", func() {
+		_, _, err := loadConfig(filepath.Join(dir, "missing.yaml"), ":9090", ":9091", false)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("checkBackend", func() {
+	newConfig := func(fragmentsEnabled bool) configv1alpha1.ControllerManagerConfig {
+		ctrlConfig := configv1alpha1.ControllerManagerConfig{
+			Controllers: map[string]configv1alpha1.ControllerConfig{
+				"ConsulConfigFragment": {Enabled: &fragmentsEnabled},
+			},
+		}
+
+		return ctrlConfig
+	}
+
+	DescribeTable("checks the backend",
+		func(backend, adoptionMode string, ctrlConfig configv1alpha1.ControllerManagerConfig, expectedErr string) {
+			err := checkBackend(backend, adoptionMode, ctrlConfig)
+			if len(expectedErr) == 0 {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedErr))
+			}
+		},
+		Entry("allows the config fragments with the kubernetes backend",
+			backendKubernetes, adoption.ModeAdopt, configv1alpha1.ControllerManagerConfig{}, ""),
+		Entry("allows the consul backend when the config fragments are disabled",
+			backendConsul, adoption.ModeStrict, newConfig(false), ""),
+		Entry("rejects an unknown backend",
+			"etcd", adoption.ModeOverwrite, newConfig(false), "invalid backend etcd"),
+		Entry("rejects the adopt mode with the consul backend",
+			backendConsul, adoption.ModeAdopt, newConfig(false), "the adoption mode adopt isn't supported by the consul backend"),
+		Entry("rejects the config fragments with the consul backend",
+			backendConsul, adoption.ModeOverwrite, newConfig(true),
+			"the ConsulConfigFragment controller isn't supported by the consul backend, disable it in the config file"),
+		Entry("rejects the config fragments which are enabled by default with the consul backend",
+			backendConsul, adoption.ModeOverwrite, configv1alpha1.ControllerManagerConfig{},
+			"the ConsulConfigFragment controller isn't supported by the consul backend, disable it in the config file"),
+	)
+})
@@ -0,0 +1,34 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adoption
+
+const (
+	// ModeOverwrite replaces the content of a merge destination which wasn't created by the controller.
+	ModeOverwrite = "overwrite"
+
+	// ModeAdopt keeps the entries of a merge destination which wasn't created by the controller
+	// before the entries merged from the custom resources.
+	ModeAdopt = "adopt"
+
+	// ModeStrict refuses to change a merge destination which wasn't created by the controller.
+	ModeStrict = "strict"
+)
+
+// IsValidMode checks if mode is one of the supported adoption modes.
+func IsValidMode(mode string) bool {
+	return mode == ModeOverwrite || mode == ModeAdopt || mode == ModeStrict
+}
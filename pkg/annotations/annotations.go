@@ -0,0 +1,41 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"fmt"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+)
+
+var (
+	// LastAppliedSpecSHA is the name of the annotation which stores the SHA of the spec
+	// which was last written to a merge destination by the controller.
+	LastAppliedSpecSHA = fmt.Sprintf("%s/last-applied-spec-sha", servicev1alpha1.GroupVersion.Group)
+
+	// RevertedDrifts is the name of the annotation which stores the number of changes of a merge destination
+	// made outside of the controller which were reverted by the controller.
+	RevertedDrifts = fmt.Sprintf("%s/reverted-drifts", servicev1alpha1.GroupVersion.Group)
+
+	// UnmanagedEntries is the name of the annotation which stores as JSON the entries of a merge destination
+	// which don't come from a custom resource. They are kept before the merged entries in the same order.
+	UnmanagedEntries = fmt.Sprintf("%s/unmanaged-entries", servicev1alpha1.GroupVersion.Group)
+
+	// UnmanagedFragmentFields is the name of the annotation which stores as JSON the values of the fields of a resource
+	// before any config fragments were merged into them. A field is restored when no fragments are merged into it.
+	UnmanagedFragmentFields = fmt.Sprintf("%s/unmanaged-fragment-fields", servicev1alpha1.GroupVersion.Group)
+)
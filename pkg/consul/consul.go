@@ -0,0 +1,182 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consul
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	capi "github.com/hashicorp/consul/api"
+)
+
+// ConfigEntries reads and writes the Consul config entries. It is implemented by the ConfigEntries of the Consul API client.
+type ConfigEntries interface {
+	Get(kind string, name string, q *capi.QueryOptions) (capi.ConfigEntry, *capi.QueryMeta, error)
+
+	// CAS writes the entry only if its modify index is still the given index.
+	// An index of 0 writes the entry only if it doesn't exist.
+	CAS(entry capi.ConfigEntry, index uint64, w *capi.WriteOptions) (bool, *capi.WriteMeta, error)
+
+	// DeleteCAS deletes the entry only if its modify index is still the given index.
+	DeleteCAS(kind string, name string, index uint64, w *capi.WriteOptions) (bool, *capi.WriteMeta, error)
+}
+
+// StatusError is returned for a response of the Consul API with an unexpected status code.
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected response code: %d (%s)", e.Code, e.Body)
+}
+
+// IsNotFound checks if the error is returned by ConfigEntries for a config entry which doesn't exist.
+func IsNotFound(err error) bool {
+	statusErr := new(StatusError)
+
+	return errors.As(err, &statusErr) && statusErr.Code == http.StatusNotFound
+}
+
+// configEntries sends the requests which aren't supported by the Consul API client, or whose status code
+// isn't returned by it, directly to the config entries endpoints.
+type configEntries struct {
+	*capi.ConfigEntries
+
+	client *capi.Client
+	config *capi.Config
+}
+
+func (c *configEntries) Get(kind string, name string, q *capi.QueryOptions) (capi.ConfigEntry, *capi.QueryMeta, error) {
+	params, token := url.Values{}, ""
+	if q != nil {
+		setParams(params, q.Datacenter, q.Namespace)
+		token = q.Token
+	}
+
+	body, err := c.do(http.MethodGet, kind, name, params, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry, err := capi.DecodeConfigEntryFromJSON(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return entry, &capi.QueryMeta{}, nil
+}
+
+func (c *configEntries) DeleteCAS(kind string, name string, index uint64, w *capi.WriteOptions) (bool, *capi.WriteMeta, error) {
+	params, token := url.Values{}, ""
+	params.Set("cas", strconv.FormatUint(index, 10))
+	if w != nil {
+		setParams(params, w.Datacenter, w.Namespace)
+		token = w.Token
+	}
+
+	body, err := c.do(http.MethodDelete, kind, name, params, token)
+	if err != nil {
+		return false, nil, err
+	}
+
+	return strings.TrimSpace(string(body)) == "true", &capi.WriteMeta{}, nil
+}
+
+// do sends the request for the config entry with the settings of the Consul API client and returns the response body.
+// A StatusError is returned when the status code isn't 200.
+func (c *configEntries) do(method, kind, name string, params url.Values, token string) ([]byte, error) {
+	setParams(params, c.config.Datacenter, c.config.Namespace)
+	u := url.URL{
+		Scheme:   c.config.Scheme,
+		Host:     c.config.Address,
+		Path:     fmt.Sprintf("/v1/config/%s/%s", url.PathEscape(kind), url.PathEscape(name)),
+		RawQuery: params.Encode(),
+	}
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = c.client.Headers()
+	if len(token) == 0 {
+		token = c.config.Token
+	}
+
+	if len(token) > 0 {
+		req.Header.Set("X-Consul-Token", token)
+	}
+
+	resp, err := c.config.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Code: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+
+	return body, nil
+}
+
+// setParams sets the datacenter and the namespace query parameters unless they are empty or already set.
+func setParams(params url.Values, datacenter, namespace string) {
+	if len(datacenter) > 0 && len(params.Get("dc")) == 0 {
+		params.Set("dc", datacenter)
+	}
+
+	if len(namespace) > 0 && len(params.Get("ns")) == 0 {
+		params.Set("ns", namespace)
+	}
+}
+
+// NewConfigEntries creates a Consul API client for the address and returns its config entries endpoints.
+// The address, the TLS settings and the default ACL token are read from the CONSUL_HTTP_* environment variables
+// and the address is overridden when it isn't empty.
+func NewConfigEntries(address string) (ConfigEntries, error) {
+	config := capi.DefaultConfig()
+	if len(address) > 0 {
+		config.Address = address
+	}
+
+	// The client completes the config, e.g. its HTTP client and scheme, which is used for the direct requests.
+	client, err := capi.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &configEntries{
+		ConfigEntries: client.ConfigEntries(),
+		client:        client,
+		config:        config,
+	}
+
+	return c, nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consul
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TokenSource provides the ACL token for the requests to the Consul API.
+type TokenSource interface {
+	// GetToken returns the ACL token. An empty token means that the default token of the client is used.
+	GetToken(ctx context.Context) (string, error)
+}
+
+type secretTokenSource struct {
+	reader client.Reader
+	secret types.NamespacedName
+	key    string
+}
+
+func (s *secretTokenSource) GetToken(ctx context.Context) (string, error) {
+	secret := &corev1.Secret{}
+	err := s.reader.Get(ctx, s.secret, secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the Consul ACL token secret %s: %w", s.secret, err)
+	}
+
+	token, ok := secret.Data[s.key]
+	if !ok {
+		return "", fmt.Errorf("the Consul ACL token secret %s has no key %s", s.secret, s.key)
+	}
+
+	return string(token), nil
+}
+
+// NewSecretTokenSource creates new TokenSource which reads the token from the key of the Secret.
+// The Secret is read for each request, so a rotated token is used without a restart.
+func NewSecretTokenSource(reader client.Reader, secret types.NamespacedName, key string) TokenSource {
+	s := &secretTokenSource{
+		reader: reader,
+		secret: secret,
+		key:    key,
+	}
+
+	return s
+}
+
+type staticTokenSource struct {
+	token string
+}
+
+func (s *staticTokenSource) GetToken(ctx context.Context) (string, error) {
+	return s.token, nil
+}
+
+// NewStaticTokenSource creates new TokenSource which always returns the same token.
+func NewStaticTokenSource(token string) TokenSource {
+	s := &staticTokenSource{
+		token: token,
+	}
+
+	return s
+}
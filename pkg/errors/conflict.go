@@ -0,0 +1,62 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+// ConflictError is an error which is returned when items which are merged
+// into the same destination contradict each other.
+type ConflictError struct {
+	error
+
+	// Items are the names of the conflicting items.
+	Items []string
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.error
+}
+
+// Is reports whether the target is a ConflictError, so that all conflicts match ErrConflict.
+func (e *ConflictError) Is(target error) bool {
+	_, ok := target.(*ConflictError)
+
+	return ok
+}
+
+// Contains checks if the item with the given name is one of the conflicting items.
+func (e *ConflictError) Contains(name string) bool {
+	for _, item := range e.Items {
+		if item == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewConflictError creates new ConflictError.
+func NewConflictError(originalError error, items ...string) *ConflictError {
+	conflictErr := &ConflictError{
+		error: originalError,
+		Items: items,
+	}
+
+	return conflictErr
+}
+
+// ErrConflict is an instance of type ConflictError which can be used
+// in the errors.Is() method.
+var ErrConflict = new(ConflictError)
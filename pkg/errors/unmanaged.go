@@ -0,0 +1,36 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+// UnmanagedDestinationError is an error which is returned when a merge destination
+// which wasn't created by the controller can't be changed.
+type UnmanagedDestinationError struct {
+	error
+}
+
+func (e *UnmanagedDestinationError) Unwrap() error {
+	return e.error
+}
+
+// NewUnmanagedDestinationError creates new UnmanagedDestinationError.
+func NewUnmanagedDestinationError(originalError error) *UnmanagedDestinationError {
+	unmanagedErr := &UnmanagedDestinationError{
+		error: originalError,
+	}
+
+	return unmanagedErr
+}
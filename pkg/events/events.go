@@ -0,0 +1,37 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+const (
+	// ReasonMerged is the reason of the event on a resource which is merged into its destination.
+	ReasonMerged = "Merged"
+
+	// ReasonMergeFailed is the reason of the event on a resource whose merge has failed.
+	ReasonMergeFailed = "MergeFailed"
+
+	// ReasonConflict is the reason of the event on a resource which conflicts with another resource with the same destination.
+	ReasonConflict = "Conflict"
+
+	// ReasonCreated is the reason of the event on a destination which is created by the controller.
+	ReasonCreated = "Created"
+
+	// ReasonUpdated is the reason of the event on a destination which is updated by the controller.
+	ReasonUpdated = "Updated"
+
+	// ReasonDeleted is the reason of the event on a destination which is deleted by the controller.
+	ReasonDeleted = "Deleted"
+)
@@ -22,7 +22,26 @@ import (
 	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
 )
 
+// DefaultFinalizerName is the name of the finalizer when the prefix isn't set.
+var DefaultFinalizerName = fmt.Sprintf("finalizer.%s", servicev1alpha1.GroupVersion.Group)
+
 var (
-	// ConsulServiceRouteFinalizerName is the name of the finalizer used for consul service routes.
-	ConsulServiceRouteFinalizerName = fmt.Sprintf("finalizer.%s", servicev1alpha1.GroupVersion.Group)
+	// FinalizerName is the name of the finalizer of the merged resources. It is the same for all merge kinds.
+	FinalizerName = DefaultFinalizerName
 )
+
+// SetPrefix sets the domain of the finalizer name. It has to be called before the controllers are set up.
+func SetPrefix(prefix string) {
+	FinalizerName = fmt.Sprintf("finalizer.%s", prefix)
+}
+
+// GetReplacedFinalizerNames returns the names of the finalizers which are replaced by the finalizer.
+// The default finalizer is replaced by the finalizer with another prefix, so the resources
+// which were created before the prefix was set can still be deleted.
+func GetReplacedFinalizerNames(finalizer string) []string {
+	if finalizer == DefaultFinalizerName {
+		return nil
+	}
+
+	return []string{DefaultFinalizerName}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fragments
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+)
+
+// crdService selects the fragments by their target instead of by a label.
+type crdService struct {
+	services.CRDService
+	reader client.Reader
+}
+
+func (c *crdService) GetAllResourcesForService(ctx context.Context, label, targetKey, namespace string) ([]client.Object, error) {
+	resources, err := c.ListResources(ctx, label, targetKey, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	notMarkedForDeletion := []client.Object{}
+	for _, resource := range resources {
+		if !c.IsDeleted(resource) {
+			notMarkedForDeletion = append(notMarkedForDeletion, resource)
+		}
+	}
+
+	return notMarkedForDeletion, nil
+}
+
+// ListResources returns the fragments in the namespace whose target has the given key. The label is ignored.
+func (c *crdService) ListResources(ctx context.Context, label, targetKey, namespace string) ([]client.Object, error) {
+	fragments := &servicev1alpha1.ConsulConfigFragmentList{}
+	err := c.reader.List(ctx, fragments, client.InNamespace(namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []client.Object{}
+	for i := range fragments.Items {
+		if GetTargetKey(fragments.Items[i].Spec.Target) == targetKey {
+			resources = append(resources, &fragments.Items[i])
+		}
+	}
+
+	return resources, nil
+}
+
+// NewCRDService returns a CRDService for config fragments.
+func NewCRDService(
+	reader client.Reader,
+	writer client.Writer,
+	log logr.Logger,
+	finalizer string,
+) services.CRDService {
+	svc := new(crdService)
+	svc.CRDService = services.NewCRDService(
+		reader,
+		writer,
+		log,
+		finalizer,
+		reflect.TypeOf(servicev1alpha1.ConsulConfigFragment{}),
+		reflect.TypeOf(servicev1alpha1.ConsulConfigFragmentList{}),
+	)
+	svc.reader = reader
+
+	return svc
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fragments_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/finalizers"
+	"github.com/NativeChat/consul-merge-controller/pkg/fragments"
+	"github.com/NativeChat/consul-merge-controller/pkg/reconcile"
+)
+
+var _ = Describe("CRD service", func() {
+	It("reconciles only the fragments of the target", func() {
+		ctx := context.Background()
+		otherTarget := serviceDefaultsTarget
+		otherTarget.Name = "api"
+
+		k8sClient := newFakeClient(
+			newServiceDefaults(),
+			newFragment("a", serviceDefaultsTarget, exposePaths, fragments.StrategyAppend, "", `[{"path": "/a"}]`),
+			newFragment("b", otherTarget, exposePaths, fragments.StrategyAppend, "", `[{"path": "/b"}]`),
+		)
+		recorder := record.NewFakeRecorder(100)
+		crdService := fragments.NewCRDService(k8sClient, k8sClient, logr.Discard(), finalizers.FinalizerName)
+		reconciler := reconcile.NewReconciler(k8sClient, crdService, newFragmentMerger(k8sClient), logr.Discard(), recorder, "", nil)
+
+		requests := fragments.NewSourceMapFunc(crdService)(newFragment("a", serviceDefaultsTarget, exposePaths, fragments.StrategyAppend, "", "[]"))
+		Expect(requests).To(Equal([]ctrl.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: fragments.GetTargetKey(serviceDefaultsTarget)}}}))
+
+		_, err := reconciler.Reconcile(ctx, requests[0])
+		Expect(err).NotTo(HaveOccurred())
+
+		_, field := getField(k8sClient, serviceDefaultsTarget, "spec", "expose", "paths")
+		Expect(field).To(MatchJSON(`[{"path": "/health", "localPathPort": 8080, "listenerPort": 21500}, {"path": "/a"}]`))
+
+		merged := &v1alpha1.ConsulConfigFragment{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "a"}, merged)).To(Succeed())
+		Expect(merged.Finalizers).To(ContainElement(finalizers.FinalizerName))
+		Expect(meta.IsStatusConditionTrue(merged.Status.Conditions, v1alpha1.ConditionTypeMerged)).To(BeTrue())
+		Expect(merged.Status.Destination).To(Equal(&v1alpha1.DestinationReference{
+			APIVersion: serviceDefaultsTarget.APIVersion,
+			Kind:       serviceDefaultsTarget.Kind,
+			Name:       serviceDefaultsTarget.Name,
+			Namespace:  "default",
+		}))
+
+		notMerged := &v1alpha1.ConsulConfigFragment{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "b"}, notMerged)).To(Succeed())
+		Expect(notMerged.Status.Conditions).To(BeEmpty())
+		Expect(notMerged.Finalizers).To(BeEmpty())
+
+		moved := merged.DeepCopy()
+		moved.Spec.Target = otherTarget
+		requests = fragments.NewSourceMapFunc(crdService)(moved)
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[1].Name).To(Equal(fragments.GetTargetKey(serviceDefaultsTarget)))
+	})
+})
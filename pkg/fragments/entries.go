@@ -0,0 +1,272 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fragments
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	e "github.com/NativeChat/consul-merge-controller/pkg/errors"
+)
+
+const (
+	// StrategyAppend adds the entries of the fragments to the field. A key of a map field
+	// can be set by more than one fragment only if all of them set the same value.
+	StrategyAppend = "Append"
+
+	// StrategyUpsert replaces the entries of the field which have the same key as an entry of a fragment
+	// and adds the other entries. The fragments are applied in the order of their names.
+	StrategyUpsert = "Upsert"
+
+	// StrategyRejectDuplicates adds the entries of the fragments to the field and reports a conflict
+	// for an entry which is already in the field.
+	StrategyRejectDuplicates = "RejectDuplicates"
+)
+
+// pathMerge merges the entries of the fragments for the same path into the value of the field
+// before any fragments were merged into it.
+type pathMerge struct {
+	path     string
+	strategy string
+	mergeKey string
+
+	// definedBy is the name of the first fragment for the path, which defines its strategy and merge key.
+	definedBy string
+
+	// list and listOwners are set for a list field, entries and entryOwners for a map field.
+	// The owner of the entries which don't come from a fragment is empty.
+	list        []interface{}
+	listOwners  []string
+	entries     map[string]interface{}
+	entryOwners map[string]string
+}
+
+// add merges the entries of the fragment. The value is the decoded JSON of the fragment.
+func (p *pathMerge) add(fragment *servicev1alpha1.ConsulConfigFragment, value interface{}) error {
+	strategy, mergeKey := getStrategy(fragment), fragment.Spec.MergeKey
+	if strategy != p.strategy || mergeKey != p.mergeKey {
+		err := fmt.Errorf("%s merges into %s with the %s strategy and merge key %q, but %s uses the %s strategy and merge key %q",
+			p.definedBy, p.path, p.strategy, p.mergeKey, fragment.Name, strategy, mergeKey)
+
+		return e.NewConflictError(err, p.definedBy, fragment.Name)
+	}
+
+	switch value := value.(type) {
+	case []interface{}:
+		if p.entries != nil {
+			return newInvalidFragmentError(fmt.Errorf("%s is a list, but %s is a map", fragment.Name, p.path))
+		}
+
+		return p.addList(fragment.Name, value)
+	case map[string]interface{}:
+		if p.list != nil {
+			return newInvalidFragmentError(fmt.Errorf("%s is a map, but %s is a list", fragment.Name, p.path))
+		}
+
+		return p.addMap(fragment.Name, value)
+	default:
+		return newInvalidFragmentError(fmt.Errorf("%s is neither a list nor a map", fragment.Name))
+	}
+}
+
+func (p *pathMerge) addList(fragmentName string, entries []interface{}) error {
+	if p.list == nil {
+		p.list = []interface{}{}
+	}
+
+	if p.strategy == StrategyUpsert && len(p.mergeKey) == 0 {
+		return newInvalidFragmentError(fmt.Errorf("%s requires a merge key for the %s strategy of the list %s", fragmentName, p.strategy, p.path))
+	}
+
+	for _, entry := range entries {
+		index, err := p.findListEntry(fragmentName, entry)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case index < 0:
+			p.list = append(p.list, entry)
+			p.listOwners = append(p.listOwners, fragmentName)
+		case p.strategy == StrategyUpsert:
+			p.list[index] = entry
+			p.listOwners[index] = fragmentName
+		default:
+			return p.newDuplicateError(p.describeListEntry(entry), p.listOwners[index], fragmentName)
+		}
+	}
+
+	return nil
+}
+
+// findListEntry returns the index of the entry of the list with the same key as the entry or -1 if there is no such entry.
+// The entries are compared as a whole when there is no merge key. The Append strategy doesn't look for the entries.
+func (p *pathMerge) findListEntry(fragmentName string, entry interface{}) (int, error) {
+	if p.strategy == StrategyAppend {
+		return -1, nil
+	}
+
+	if len(p.mergeKey) == 0 {
+		for index, existing := range p.list {
+			if reflect.DeepEqual(existing, entry) {
+				return index, nil
+			}
+		}
+
+		return -1, nil
+	}
+
+	key, ok := getListEntryKey(entry, p.mergeKey)
+	if !ok {
+		return -1, newInvalidFragmentError(fmt.Errorf("an entry of %s has no %s field", fragmentName, p.mergeKey))
+	}
+
+	for index, existing := range p.list {
+		// The entries which weren't merged from a fragment may have no key.
+		if existingKey, ok := getListEntryKey(existing, p.mergeKey); ok && reflect.DeepEqual(existingKey, key) {
+			return index, nil
+		}
+	}
+
+	return -1, nil
+}
+
+func (p *pathMerge) addMap(fragmentName string, entries map[string]interface{}) error {
+	if p.entries == nil {
+		p.entries = map[string]interface{}{}
+		p.entryOwners = map[string]string{}
+	}
+
+	// The keys are merged in order, so the same conflict is reported for any order of the keys.
+	keys := []string{}
+	for key := range entries {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		existing, ok := p.entries[key]
+		isConflict := ok && (p.strategy == StrategyRejectDuplicates || (p.strategy == StrategyAppend && !reflect.DeepEqual(existing, entries[key])))
+		if isConflict {
+			return p.newDuplicateError(key, p.entryOwners[key], fragmentName)
+		}
+
+		if !ok || p.strategy == StrategyUpsert {
+			p.entryOwners[key] = fragmentName
+		}
+
+		p.entries[key] = entries[key]
+	}
+
+	return nil
+}
+
+// getValue returns the merged value of the field.
+func (p *pathMerge) getValue() interface{} {
+	if p.entries != nil {
+		return p.entries
+	}
+
+	return p.list
+}
+
+func (p *pathMerge) describeListEntry(entry interface{}) string {
+	if len(p.mergeKey) > 0 {
+		key, _ := getListEntryKey(entry, p.mergeKey)
+
+		return fmt.Sprintf("%s=%v", p.mergeKey, key)
+	}
+
+	description, _ := json.Marshal(entry)
+
+	return string(description)
+}
+
+// newDuplicateError returns a conflict between the fragment and the owner of the duplicated entry.
+// An entry which doesn't come from a fragment conflicts with the fragment alone.
+func (p *pathMerge) newDuplicateError(entry, owner, fragmentName string) error {
+	if len(owner) == 0 {
+		err := fmt.Errorf("%s %s of %s is already set on the target", p.path, entry, fragmentName)
+
+		return e.NewConflictError(err, fragmentName)
+	}
+
+	err := fmt.Errorf("%s %s is set by %s and %s", p.path, entry, owner, fragmentName)
+
+	return e.NewConflictError(err, owner, fragmentName)
+}
+
+// newPathMerge creates a pathMerge for the path with the strategy of the fragment.
+// The value of the field before any fragments were merged into it can be nil.
+func newPathMerge(path string, fragment *servicev1alpha1.ConsulConfigFragment, value interface{}) (*pathMerge, error) {
+	p := &pathMerge{
+		path:      path,
+		strategy:  getStrategy(fragment),
+		mergeKey:  fragment.Spec.MergeKey,
+		definedBy: fragment.Name,
+	}
+
+	if p.strategy != StrategyAppend && p.strategy != StrategyUpsert && p.strategy != StrategyRejectDuplicates {
+		return nil, newInvalidFragmentError(fmt.Errorf("invalid merge strategy %s of %s", p.strategy, fragment.Name))
+	}
+
+	switch value := value.(type) {
+	case nil:
+	case []interface{}:
+		p.list = append([]interface{}{}, value...)
+		p.listOwners = make([]string, len(value))
+	case map[string]interface{}:
+		p.entries = map[string]interface{}{}
+		p.entryOwners = map[string]string{}
+		for key, entry := range value {
+			p.entries[key] = entry
+			p.entryOwners[key] = ""
+		}
+	default:
+		return nil, newInvalidFragmentError(fmt.Errorf("%s of the target of %s is neither a list nor a map", path, fragment.Name))
+	}
+
+	return p, nil
+}
+
+func getStrategy(fragment *servicev1alpha1.ConsulConfigFragment) string {
+	if len(fragment.Spec.MergeStrategy) == 0 {
+		return StrategyAppend
+	}
+
+	return fragment.Spec.MergeStrategy
+}
+
+func getListEntryKey(entry interface{}, mergeKey string) (interface{}, bool) {
+	fields, ok := entry.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	key, ok := fields[mergeKey]
+
+	return key, ok
+}
+
+// newInvalidFragmentError returns an error for a fragment which can't be merged until it is changed.
+func newInvalidFragmentError(err error) error {
+	return e.NewReconcileError(err, false)
+}
@@ -0,0 +1,408 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fragments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/annotations"
+	e "github.com/NativeChat/consul-merge-controller/pkg/errors"
+	"github.com/NativeChat/consul-merge-controller/pkg/events"
+	"github.com/NativeChat/consul-merge-controller/pkg/metrics"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+)
+
+type merger struct {
+	reader   client.Reader
+	writer   client.Writer
+	log      logr.Logger
+	recorder record.EventRecorder
+	watcher  TargetWatcher
+}
+
+// Merge merges the fragments into the fields of an existing target. The target isn't created or deleted
+// by the controller and its fields are restored when no fragments are merged into them.
+func (m *merger) Merge(ctx context.Context, destinationResourceName, namespace string, items []client.Object) (*ctrl.Result, error) {
+	target, err := ParseTargetKey(destinationResourceName)
+	if err != nil {
+		m.log.Error(err, "failed to parse the target")
+
+		return &ctrl.Result{}, e.NewReconcileError(err, false)
+	}
+
+	// The merge is recorded as failed unless a result is set before returning.
+	result := metrics.MergeResultFailed
+	defer func() {
+		m.recordMerge(target, namespace, result, len(items))
+	}()
+
+	gvk := getGroupVersionKind(target)
+	if gvk.Group != TargetGroup {
+		err = fmt.Errorf("%s isn't in the %s group", gvk, TargetGroup)
+		m.log.Error(err, "invalid target")
+
+		return &ctrl.Result{}, newInvalidFragmentError(err)
+	}
+
+	if m.watcher != nil {
+		err = m.watcher.Watch(ctx, gvk)
+		if err != nil {
+			m.log.Error(err, fmt.Sprintf("failed to watch %s", gvk))
+
+			return &ctrl.Result{}, err
+		}
+	}
+
+	actual := &unstructured.Unstructured{}
+	actual.SetGroupVersionKind(gvk)
+	err = m.reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: target.Name}, actual)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			m.log.Error(err, fmt.Sprintf("failed to get %s", gvk))
+
+			return &ctrl.Result{Requeue: true}, nil
+		}
+
+		if len(items) == 0 {
+			m.log.Info(fmt.Sprintf("no fragments for %s, nothing to restore", gvk))
+
+			result = metrics.MergeResultUnchanged
+			return nil, nil
+		}
+
+		// The target is watched, so the fragments are merged when it is created.
+		m.log.Info(fmt.Sprintf("%s %s doesn't exist, the fragments will be merged when it is created", gvk.Kind, target.Name))
+
+		return &ctrl.Result{}, nil
+	}
+
+	expected := actual.DeepCopy()
+	err = m.mergeFragments(expected, items)
+	if err != nil {
+		m.log.Error(err, "failed to merge the fragments")
+
+		return &ctrl.Result{}, err
+	}
+
+	if reflect.DeepEqual(expected.Object, actual.Object) {
+		m.log.Info(fmt.Sprintf("%s is up to date", gvk))
+
+		result = metrics.MergeResultUnchanged
+		return nil, nil
+	}
+
+	m.log.Info(fmt.Sprintf("updating %s...", gvk))
+
+	err = m.writer.Update(ctx, expected)
+	if err != nil {
+		m.log.Error(err, fmt.Sprintf("failed to update %s", gvk))
+
+		return &ctrl.Result{}, err
+	}
+
+	m.log.Info(fmt.Sprintf("%s updated", gvk))
+	m.recorder.Event(expected, corev1.EventTypeNormal, events.ReasonUpdated, fmt.Sprintf("updated from %s", m.getItemNames(items)))
+
+	result = metrics.MergeResultUpdated
+	return nil, nil
+}
+
+// mergeFragments merges the fragments in the order of their names into the fields of the target.
+// The value of a field before any fragments were merged into it is stored in the UnmanagedFragmentFields annotation.
+func (m *merger) mergeFragments(target *unstructured.Unstructured, items []client.Object) error {
+	unmanagedFields := map[string]interface{}{}
+	if value, ok := target.GetAnnotations()[annotations.UnmanagedFragmentFields]; ok {
+		err := utiljson.Unmarshal([]byte(value), &unmanagedFields)
+		if err != nil {
+			return fmt.Errorf("invalid %s annotation: %w", annotations.UnmanagedFragmentFields, err)
+		}
+	}
+
+	fragments := []*servicev1alpha1.ConsulConfigFragment{}
+	for _, item := range items {
+		fragments = append(fragments, item.(*servicev1alpha1.ConsulConfigFragment))
+	}
+
+	sort.SliceStable(fragments, func(i, j int) bool {
+		return fragments[i].Name < fragments[j].Name
+	})
+
+	paths := map[string]*pathMerge{}
+	for _, fragment := range fragments {
+		path := fragment.Spec.Path
+		fields, err := splitPath(fragment)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := paths[path]; !ok {
+			value, ok := unmanagedFields[path]
+			if !ok {
+				value, _, err = unstructured.NestedFieldCopy(target.Object, fields...)
+				if err != nil {
+					return newInvalidFragmentError(fmt.Errorf("invalid path %s of %s: %w", path, fragment.Name, err))
+				}
+
+				unmanagedFields[path] = value
+			}
+
+			paths[path], err = newPathMerge(path, fragment, value)
+			if err != nil {
+				return err
+			}
+		}
+
+		var value interface{}
+		err = utiljson.Unmarshal(fragment.Spec.Fragment.Raw, &value)
+		if err != nil {
+			return newInvalidFragmentError(fmt.Errorf("invalid fragment %s: %w", fragment.Name, err))
+		}
+
+		err = paths[path].add(fragment, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := findOverlappingPaths(paths)
+	if err != nil {
+		return err
+	}
+
+	// The paths are written in sorted order, so a field is always written before the fields inside of it.
+	unmanagedPaths := []string{}
+	for path := range unmanagedFields {
+		unmanagedPaths = append(unmanagedPaths, path)
+	}
+
+	sort.Strings(unmanagedPaths)
+
+	for _, path := range unmanagedPaths {
+		value := unmanagedFields[path]
+		fields := strings.Split(path, ".")
+		if p, ok := paths[path]; ok {
+			value = p.getValue()
+		} else {
+			delete(unmanagedFields, path)
+		}
+
+		if value == nil {
+			unstructured.RemoveNestedField(target.Object, fields...)
+
+			continue
+		}
+
+		err := unstructured.SetNestedField(target.Object, value, fields...)
+		if err != nil {
+			return newInvalidFragmentError(fmt.Errorf("failed to set %s: %w", path, err))
+		}
+	}
+
+	return m.setUnmanagedFields(target, unmanagedFields)
+}
+
+func (m *merger) setUnmanagedFields(target *unstructured.Unstructured, unmanagedFields map[string]interface{}) error {
+	targetAnnotations := target.GetAnnotations()
+	if len(unmanagedFields) == 0 {
+		delete(targetAnnotations, annotations.UnmanagedFragmentFields)
+		if len(targetAnnotations) == 0 {
+			targetAnnotations = nil
+		}
+
+		target.SetAnnotations(targetAnnotations)
+
+		return nil
+	}
+
+	value, err := json.Marshal(unmanagedFields)
+	if err != nil {
+		return err
+	}
+
+	if targetAnnotations == nil {
+		targetAnnotations = map[string]string{}
+	}
+
+	targetAnnotations[annotations.UnmanagedFragmentFields] = string(value)
+	target.SetAnnotations(targetAnnotations)
+
+	return nil
+}
+
+// getItemNames returns the kind and the names of the fragments which are merged into the target.
+func (m *merger) getItemNames(items []client.Object) string {
+	if len(items) == 0 {
+		return "no resources"
+	}
+
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.GetName())
+	}
+
+	sort.Strings(names)
+
+	return fmt.Sprintf("%s %s", reflect.TypeOf(items[0]).Elem().Name(), strings.Join(names, ", "))
+}
+
+// recordMerge updates the merge metrics. The number of items of a target is the number of its fragments.
+func (m *merger) recordMerge(target servicev1alpha1.ConfigFragmentTarget, namespace, result string, fragments int) {
+	metrics.Merges.WithLabelValues(target.Kind, result).Inc()
+
+	if result == metrics.MergeResultFailed {
+		return
+	}
+
+	if fragments == 0 {
+		metrics.DeleteDestination(target.Kind, namespace, target.Name)
+
+		return
+	}
+
+	metrics.DestinationItems.WithLabelValues(target.Kind, namespace, target.Name).Set(float64(fragments))
+	metrics.LastSuccessfulMerge.WithLabelValues(target.Kind, namespace, target.Name).SetToCurrentTime()
+}
+
+func (m *merger) GetDestination(ctx context.Context, destinationResourceName, namespace string) (client.Object, error) {
+	target, err := ParseTargetKey(destinationResourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	destination := &unstructured.Unstructured{}
+	destination.SetGroupVersionKind(getGroupVersionKind(target))
+	err = m.reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: target.Name}, destination)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &syncedTarget{Unstructured: destination}, nil
+}
+
+func (m *merger) GetDestinationReference(destinationResourceName, namespace string) *servicev1alpha1.DestinationReference {
+	// The key is parsed again by Merge, which reports an invalid key.
+	target, _ := ParseTargetKey(destinationResourceName)
+	reference := &servicev1alpha1.DestinationReference{
+		APIVersion: target.APIVersion,
+		Kind:       target.Kind,
+		Name:       target.Name,
+		Namespace:  namespace,
+	}
+
+	return reference
+}
+
+// splitPath returns the fields of the path of the fragment.
+func splitPath(fragment *servicev1alpha1.ConsulConfigFragment) ([]string, error) {
+	fields := strings.Split(fragment.Spec.Path, ".")
+	for _, field := range fields {
+		if len(field) == 0 {
+			return nil, newInvalidFragmentError(fmt.Errorf("invalid path %q of %s", fragment.Spec.Path, fragment.Name))
+		}
+	}
+
+	return fields, nil
+}
+
+// findOverlappingPaths returns a conflict between the fragments of a path and the fragments of a path inside of it.
+// The value of such paths would depend on the order in which they are written.
+func findOverlappingPaths(paths map[string]*pathMerge) error {
+	sortedPaths := []string{}
+	for path := range paths {
+		sortedPaths = append(sortedPaths, path)
+	}
+
+	sort.Strings(sortedPaths)
+
+	for i, parent := range sortedPaths {
+		for _, child := range sortedPaths[i+1:] {
+			if !strings.HasPrefix(child, parent+".") {
+				continue
+			}
+
+			err := fmt.Errorf("%s of %s is inside of %s of %s", child, paths[child].definedBy, parent, paths[parent].definedBy)
+
+			return e.NewConflictError(err, paths[parent].definedBy, paths[child].definedBy)
+		}
+	}
+
+	return nil
+}
+
+// syncedTarget reads the Synced condition which consul-k8s sets on its resources from an unstructured target.
+type syncedTarget struct {
+	*unstructured.Unstructured
+}
+
+// SyncedCondition returns the status, the reason and the message of the Synced condition of the target.
+// The status is unknown when the target has no such condition.
+func (t *syncedTarget) SyncedCondition() (status corev1.ConditionStatus, reason, message string) {
+	conditions, _, _ := unstructured.NestedSlice(t.Object, "status", "conditions")
+	for _, condition := range conditions {
+		fields, ok := condition.(map[string]interface{})
+		if !ok || fields["type"] != servicev1alpha1.ConditionTypeSynced {
+			continue
+		}
+
+		status, _, _ := unstructured.NestedString(fields, "status")
+		reason, _, _ := unstructured.NestedString(fields, "reason")
+		message, _, _ := unstructured.NestedString(fields, "message")
+
+		return corev1.ConditionStatus(status), reason, message
+	}
+
+	return corev1.ConditionUnknown, "", ""
+}
+
+// NewMerger creates a merger which merges config fragments into their targets. The targets are watched
+// through the watcher the first time fragments are merged into them. The watcher can be nil.
+func NewMerger(
+	reader client.Reader,
+	writer client.Writer,
+	log logr.Logger,
+	recorder record.EventRecorder,
+	watcher TargetWatcher,
+) services.Merger {
+	m := new(merger)
+	m.reader = reader
+	m.writer = writer
+	m.log = log
+	m.recorder = recorder
+	m.watcher = watcher
+
+	return m
+}
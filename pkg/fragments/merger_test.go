@@ -0,0 +1,390 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fragments_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/annotations"
+	e "github.com/NativeChat/consul-merge-controller/pkg/errors"
+	"github.com/NativeChat/consul-merge-controller/pkg/fragments"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+)
+
+const (
+	exposePaths = "spec.expose.paths"
+	proxyConfig = "spec.config"
+)
+
+var (
+	serviceDefaultsTarget = v1alpha1.ConfigFragmentTarget{APIVersion: consulk8s.GroupVersion.String(), Kind: "ServiceDefaults", Name: "web"}
+	proxyDefaultsTarget   = v1alpha1.ConfigFragmentTarget{APIVersion: consulk8s.GroupVersion.String(), Kind: "ProxyDefaults", Name: "global"}
+)
+
+// mergeExpectation describes the outcome of a merge. The value is the JSON of the field after a successful merge.
+type mergeExpectation struct {
+	value     string
+	conflicts []string
+	invalid   bool
+}
+
+func newServiceDefaults() *consulk8s.ServiceDefaults {
+	serviceDefaults := &consulk8s.ServiceDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: serviceDefaultsTarget.Name, Namespace: "default"},
+		Spec: consulk8s.ServiceDefaultsSpec{
+			Protocol: "http",
+			Expose: consulk8s.Expose{
+				Paths: []consulk8s.ExposePath{{Path: "/health", LocalPathPort: 8080, ListenerPort: 21500}},
+			},
+		},
+	}
+
+	return serviceDefaults
+}
+
+func newProxyDefaults() *consulk8s.ProxyDefaults {
+	proxyDefaults := &consulk8s.ProxyDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: proxyDefaultsTarget.Name, Namespace: "default"},
+		Spec: consulk8s.ProxyDefaultsSpec{
+			Config: json.RawMessage(`{"protocol": "http"}`),
+		},
+	}
+
+	return proxyDefaults
+}
+
+func newFragment(name string, target v1alpha1.ConfigFragmentTarget, path, mergeStrategy, mergeKey, fragment string) *v1alpha1.ConsulConfigFragment {
+	configFragment := &v1alpha1.ConsulConfigFragment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1alpha1.ConsulConfigFragmentSpec{
+			Target:        target,
+			Path:          path,
+			Fragment:      apiextensionsv1.JSON{Raw: []byte(fragment)},
+			MergeStrategy: mergeStrategy,
+			MergeKey:      mergeKey,
+		},
+	}
+
+	return configFragment
+}
+
+// recordingWatcher records the kinds which are watched.
+type recordingWatcher struct {
+	watched []schema.GroupVersionKind
+}
+
+func (w *recordingWatcher) Watch(ctx context.Context, gvk schema.GroupVersionKind) error {
+	w.watched = append(w.watched, gvk)
+
+	return nil
+}
+
+func newFragmentMerger(k8sClient client.Client) services.Merger {
+	return fragments.NewMerger(k8sClient, k8sClient, logr.Discard(), record.NewFakeRecorder(100), nil)
+}
+
+// getField returns the target and the JSON of its field.
+func getField(k8sClient client.Client, target v1alpha1.ConfigFragmentTarget, path ...string) (*unstructured.Unstructured, string) {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(target.APIVersion)
+	obj.SetKind(target.Kind)
+	Expect(k8sClient.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: target.Name}, obj)).To(Succeed())
+
+	value, _, err := unstructured.NestedFieldCopy(obj.Object, path...)
+	Expect(err).NotTo(HaveOccurred())
+
+	field, err := json.Marshal(value)
+	Expect(err).NotTo(HaveOccurred())
+
+	return obj, string(field)
+}
+
+var _ = Describe("Merger", func() {
+	table.DescribeTable("Merge",
+		func(target client.Object, fragmentTarget v1alpha1.ConfigFragmentTarget, path []string, items []client.Object, expected mergeExpectation) {
+			k8sClient := newFakeClient(target)
+			merger := newFragmentMerger(k8sClient)
+
+			res, err := merger.Merge(context.Background(), fragments.GetTargetKey(fragmentTarget), "default", items)
+
+			conflictErr := new(e.ConflictError)
+			reconcileErr := new(e.ReconcileError)
+			switch {
+			case expected.conflicts != nil:
+				Expect(errors.As(err, &conflictErr)).To(BeTrue(), "expected a conflict, got %v", err)
+				Expect(conflictErr.Items).To(Equal(expected.conflicts))
+			case expected.invalid:
+				Expect(errors.As(err, &reconcileErr)).To(BeTrue(), "expected an invalid fragment, got %v", err)
+				Expect(reconcileErr.ShouldRequeue).To(BeFalse())
+			default:
+				Expect(err).NotTo(HaveOccurred())
+				Expect(res).To(BeNil())
+
+				_, field := getField(k8sClient, fragmentTarget, path...)
+				Expect(field).To(MatchJSON(expected.value))
+			}
+		},
+		table.Entry("appends the entries of the fragments in the order of their names",
+			newServiceDefaults(), serviceDefaultsTarget, []string{"spec", "expose", "paths"},
+			[]client.Object{
+				newFragment("b", serviceDefaultsTarget, exposePaths, fragments.StrategyAppend, "", `[{"path": "/b", "localPathPort": 2}]`),
+				newFragment("a", serviceDefaultsTarget, exposePaths, fragments.StrategyAppend, "", `[{"path": "/a", "localPathPort": 1}]`),
+			},
+			mergeExpectation{value: `[
+				{"path": "/health", "localPathPort": 8080, "listenerPort": 21500},
+				{"path": "/a", "localPathPort": 1},
+				{"path": "/b", "localPathPort": 2}
+			]`},
+		),
+		table.Entry("replaces the entries with the same key",
+			newServiceDefaults(), serviceDefaultsTarget, []string{"spec", "expose", "paths"},
+			[]client.Object{
+				newFragment("a", serviceDefaultsTarget, exposePaths, fragments.StrategyUpsert, "path", `[{"path": "/health", "localPathPort": 9090}, {"path": "/a"}]`),
+				newFragment("b", serviceDefaultsTarget, exposePaths, fragments.StrategyUpsert, "path", `[{"path": "/a", "localPathPort": 2}]`),
+			},
+			mergeExpectation{value: `[{"path": "/health", "localPathPort": 9090}, {"path": "/a", "localPathPort": 2}]`},
+		),
+		table.Entry("rejects an entry which is set by two fragments",
+			newServiceDefaults(), serviceDefaultsTarget, []string{"spec", "expose", "paths"},
+			[]client.Object{
+				newFragment("a", serviceDefaultsTarget, exposePaths, fragments.StrategyRejectDuplicates, "path", `[{"path": "/a", "localPathPort": 1}]`),
+				newFragment("b", serviceDefaultsTarget, exposePaths, fragments.StrategyRejectDuplicates, "path", `[{"path": "/a", "localPathPort": 2}]`),
+			},
+			mergeExpectation{conflicts: []string{"a", "b"}},
+		),
+		table.Entry("rejects an entry which is already set on the target",
+			newServiceDefaults(), serviceDefaultsTarget, []string{"spec", "expose", "paths"},
+			[]client.Object{
+				newFragment("a", serviceDefaultsTarget, exposePaths, fragments.StrategyRejectDuplicates, "", `[{"path": "/health", "localPathPort": 8080, "listenerPort": 21500}]`),
+			},
+			mergeExpectation{conflicts: []string{"a"}},
+		),
+		table.Entry("rejects fragments with different strategies for the same path",
+			newServiceDefaults(), serviceDefaultsTarget, []string{"spec", "expose", "paths"},
+			[]client.Object{
+				newFragment("a", serviceDefaultsTarget, exposePaths, fragments.StrategyAppend, "", `[{"path": "/a"}]`),
+				newFragment("b", serviceDefaultsTarget, exposePaths, fragments.StrategyUpsert, "path", `[{"path": "/b"}]`),
+			},
+			mergeExpectation{conflicts: []string{"a", "b"}},
+		),
+		table.Entry("requires a merge key to upsert the entries of a list",
+			newServiceDefaults(), serviceDefaultsTarget, []string{"spec", "expose", "paths"},
+			[]client.Object{
+				newFragment("a", serviceDefaultsTarget, exposePaths, fragments.StrategyUpsert, "", `[{"path": "/a"}]`),
+			},
+			mergeExpectation{invalid: true},
+		),
+		table.Entry("rejects a map fragment for a list field",
+			newServiceDefaults(), serviceDefaultsTarget, []string{"spec", "expose", "paths"},
+			[]client.Object{
+				newFragment("a", serviceDefaultsTarget, exposePaths, fragments.StrategyAppend, "", `{"path": "/a"}`),
+			},
+			mergeExpectation{invalid: true},
+		),
+		table.Entry("adds the keys of the fragments to a map field",
+			newProxyDefaults(), proxyDefaultsTarget, []string{"spec", "config"},
+			[]client.Object{
+				newFragment("a", proxyDefaultsTarget, proxyConfig, fragments.StrategyAppend, "", `{"envoy_prometheus_bind_addr": "0.0.0.0:9102"}`),
+				newFragment("b", proxyDefaultsTarget, proxyConfig, fragments.StrategyAppend, "", `{"protocol": "http", "local_connect_timeout_ms": 1000}`),
+			},
+			mergeExpectation{value: `{"protocol": "http", "envoy_prometheus_bind_addr": "0.0.0.0:9102", "local_connect_timeout_ms": 1000}`},
+		),
+		table.Entry("rejects a key which is set to different values by the appended fragments",
+			newProxyDefaults(), proxyDefaultsTarget, []string{"spec", "config"},
+			[]client.Object{
+				newFragment("a", proxyDefaultsTarget, proxyConfig, fragments.StrategyAppend, "", `{"protocol": "grpc"}`),
+			},
+			mergeExpectation{conflicts: []string{"a"}},
+		),
+		table.Entry("replaces the keys of a map field in the order of the fragment names",
+			newProxyDefaults(), proxyDefaultsTarget, []string{"spec", "config"},
+			[]client.Object{
+				newFragment("b", proxyDefaultsTarget, proxyConfig, fragments.StrategyUpsert, "", `{"protocol": "http2"}`),
+				newFragment("a", proxyDefaultsTarget, proxyConfig, fragments.StrategyUpsert, "", `{"protocol": "grpc"}`),
+			},
+			mergeExpectation{value: `{"protocol": "http2"}`},
+		),
+		table.Entry("creates a field which isn't set on the target",
+			newServiceDefaults(), serviceDefaultsTarget, []string{"spec", "transparentProxy"},
+			[]client.Object{
+				newFragment("a", serviceDefaultsTarget, "spec.transparentProxy", fragments.StrategyAppend, "", `{"outboundListenerPort": 15001}`),
+			},
+			mergeExpectation{value: `{"outboundListenerPort": 15001}`},
+		),
+		table.Entry("rejects fragments for a path inside of the path of another fragment",
+			newServiceDefaults(), serviceDefaultsTarget, []string{"spec", "expose"},
+			[]client.Object{
+				newFragment("a", serviceDefaultsTarget, exposePaths, fragments.StrategyAppend, "", `[{"path": "/a"}]`),
+				newFragment("b", serviceDefaultsTarget, "spec.expose", fragments.StrategyUpsert, "", `{"checks": true}`),
+			},
+			mergeExpectation{conflicts: []string{"b", "a"}},
+		),
+	)
+
+	It("merges overlapping paths with the same outcome every time", func() {
+		ctx := context.Background()
+		k8sClient := newFakeClient(newServiceDefaults())
+		merger := newFragmentMerger(k8sClient)
+		original, _ := getField(k8sClient, serviceDefaultsTarget)
+
+		items := []client.Object{
+			newFragment("a", serviceDefaultsTarget, "spec.expose", fragments.StrategyUpsert, "", `{"checks": true}`),
+			newFragment("b", serviceDefaultsTarget, exposePaths, fragments.StrategyAppend, "", `[{"path": "/b"}]`),
+			newFragment("c", serviceDefaultsTarget, "spec.expose.paths2", fragments.StrategyAppend, "", `[{"path": "/c"}]`),
+			newFragment("d", serviceDefaultsTarget, "spec.transparentProxy", fragments.StrategyAppend, "", `{"outboundListenerPort": 15001}`),
+		}
+
+		for i := 0; i < 100; i++ {
+			_, err := merger.Merge(ctx, fragments.GetTargetKey(serviceDefaultsTarget), "default", items)
+
+			conflictErr := new(e.ConflictError)
+			Expect(errors.As(err, &conflictErr)).To(BeTrue(), "expected a conflict, got %v", err)
+			Expect(conflictErr.Items).To(Equal([]string{"a", "b"}))
+
+			unchanged, _ := getField(k8sClient, serviceDefaultsTarget)
+			Expect(unchanged.GetResourceVersion()).To(Equal(original.GetResourceVersion()))
+		}
+	})
+
+	It("restores a field before merging into the fields inside of it", func() {
+		ctx := context.Background()
+
+		for i := 0; i < 20; i++ {
+			k8sClient := newFakeClient(newServiceDefaults())
+			merger := newFragmentMerger(k8sClient)
+
+			items := []client.Object{
+				newFragment("a", serviceDefaultsTarget, "spec.expose", fragments.StrategyUpsert, "", `{"checks": true}`),
+			}
+
+			_, err := merger.Merge(ctx, fragments.GetTargetKey(serviceDefaultsTarget), "default", items)
+			Expect(err).NotTo(HaveOccurred())
+
+			items = []client.Object{
+				newFragment("b", serviceDefaultsTarget, exposePaths, fragments.StrategyAppend, "", `[{"path": "/b"}]`),
+			}
+
+			_, err = merger.Merge(ctx, fragments.GetTargetKey(serviceDefaultsTarget), "default", items)
+			Expect(err).NotTo(HaveOccurred())
+
+			merged, expose := getField(k8sClient, serviceDefaultsTarget, "spec", "expose")
+			Expect(expose).To(MatchJSON(`{"paths": [{"path": "/health", "localPathPort": 8080, "listenerPort": 21500}, {"path": "/b"}]}`))
+			Expect(merged.GetAnnotations()[annotations.UnmanagedFragmentFields]).NotTo(ContainSubstring(`"spec.expose":`))
+		}
+	})
+
+	It("restores the fields when no fragments are merged into them", func() {
+		ctx := context.Background()
+		k8sClient := newFakeClient(newServiceDefaults())
+		merger := newFragmentMerger(k8sClient)
+		_, original := getField(k8sClient, serviceDefaultsTarget, "spec")
+
+		items := []client.Object{
+			newFragment("a", serviceDefaultsTarget, exposePaths, fragments.StrategyAppend, "", `[{"path": "/a"}]`),
+			newFragment("b", serviceDefaultsTarget, "spec.transparentProxy", fragments.StrategyAppend, "", `{"outboundListenerPort": 15001}`),
+		}
+
+		res, err := merger.Merge(ctx, fragments.GetTargetKey(serviceDefaultsTarget), "default", items)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeNil())
+
+		merged, _ := getField(k8sClient, serviceDefaultsTarget)
+		Expect(merged.GetAnnotations()).To(HaveKey(annotations.UnmanagedFragmentFields))
+
+		res, err = merger.Merge(ctx, fragments.GetTargetKey(serviceDefaultsTarget), "default", []client.Object{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeNil())
+
+		restored, spec := getField(k8sClient, serviceDefaultsTarget, "spec")
+		Expect(spec).To(MatchJSON(original))
+		Expect(restored.GetAnnotations()).NotTo(HaveKey(annotations.UnmanagedFragmentFields))
+	})
+
+	It("doesn't update a target which is up to date", func() {
+		ctx := context.Background()
+		k8sClient := newFakeClient(newServiceDefaults())
+		merger := newFragmentMerger(k8sClient)
+		items := []client.Object{
+			newFragment("a", serviceDefaultsTarget, exposePaths, fragments.StrategyUpsert, "path", `[{"path": "/a", "localPathPort": 1}]`),
+		}
+
+		_, err := merger.Merge(ctx, fragments.GetTargetKey(serviceDefaultsTarget), "default", items)
+		Expect(err).NotTo(HaveOccurred())
+		merged, _ := getField(k8sClient, serviceDefaultsTarget)
+
+		_, err = merger.Merge(ctx, fragments.GetTargetKey(serviceDefaultsTarget), "default", items)
+		Expect(err).NotTo(HaveOccurred())
+		unchanged, _ := getField(k8sClient, serviceDefaultsTarget)
+		Expect(unchanged.GetResourceVersion()).To(Equal(merged.GetResourceVersion()))
+	})
+
+	It("waits for the target to be created", func() {
+		k8sClient := newFakeClient()
+		merger := newFragmentMerger(k8sClient)
+		items := []client.Object{
+			newFragment("a", serviceDefaultsTarget, exposePaths, fragments.StrategyAppend, "", `[{"path": "/a"}]`),
+		}
+
+		res, err := merger.Merge(context.Background(), fragments.GetTargetKey(serviceDefaultsTarget), "default", items)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).NotTo(BeNil())
+		Expect(res.Requeue).To(BeFalse())
+	})
+
+	It("rejects a target outside of the consul.hashicorp.com group without watching it", func() {
+		k8sClient := newFakeClient()
+		watcher := &recordingWatcher{}
+		merger := fragments.NewMerger(k8sClient, k8sClient, logr.Discard(), record.NewFakeRecorder(100), watcher)
+		target := v1alpha1.ConfigFragmentTarget{APIVersion: "v1", Kind: "ConfigMap", Name: "config"}
+		items := []client.Object{
+			newFragment("a", target, "data", fragments.StrategyUpsert, "", `{"key": "value"}`),
+		}
+
+		_, err := merger.Merge(context.Background(), fragments.GetTargetKey(target), "default", items)
+
+		reconcileErr := new(e.ReconcileError)
+		Expect(errors.As(err, &reconcileErr)).To(BeTrue(), "expected an invalid fragment, got %v", err)
+		Expect(reconcileErr.ShouldRequeue).To(BeFalse())
+		Expect(watcher.watched).To(BeEmpty())
+	})
+
+	It("watches a target of the consul.hashicorp.com group", func() {
+		k8sClient := newFakeClient()
+		watcher := &recordingWatcher{}
+		merger := fragments.NewMerger(k8sClient, k8sClient, logr.Discard(), record.NewFakeRecorder(100), watcher)
+
+		_, err := merger.Merge(context.Background(), fragments.GetTargetKey(serviceDefaultsTarget), "default", []client.Object{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(watcher.watched).To(ConsistOf(schema.FromAPIVersionAndKind(serviceDefaultsTarget.APIVersion, serviceDefaultsTarget.Kind)))
+	})
+})
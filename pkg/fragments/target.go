@@ -0,0 +1,117 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fragments
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+)
+
+// TargetGroup is the API group of the resources into which the fragments can be merged.
+// The controller is allowed to watch and update only the resources of this group.
+const TargetGroup = "consul.hashicorp.com"
+
+// GetTargetKey returns the name of the reconcile requests for the target. The requests are for the targets
+// and not for the fragments, so the key contains the API version and the kind of the target as well as its name.
+func GetTargetKey(target servicev1alpha1.ConfigFragmentTarget) string {
+	return fmt.Sprintf("%s/%s/%s", target.APIVersion, target.Kind, target.Name)
+}
+
+// ParseTargetKey returns the target whose key is returned by GetTargetKey.
+func ParseTargetKey(key string) (servicev1alpha1.ConfigFragmentTarget, error) {
+	nameIndex := strings.LastIndex(key, "/")
+	kindIndex := -1
+	if nameIndex > 0 {
+		kindIndex = strings.LastIndex(key[:nameIndex], "/")
+	}
+
+	if kindIndex <= 0 || kindIndex+1 == nameIndex || nameIndex+1 == len(key) {
+		return servicev1alpha1.ConfigFragmentTarget{}, fmt.Errorf("invalid target %s, expected apiVersion/kind/name", key)
+	}
+
+	target := servicev1alpha1.ConfigFragmentTarget{
+		APIVersion: key[:kindIndex],
+		Kind:       key[kindIndex+1 : nameIndex],
+		Name:       key[nameIndex+1:],
+	}
+
+	return target, nil
+}
+
+// getGroupVersionKind returns the GroupVersionKind of the target.
+func getGroupVersionKind(target servicev1alpha1.ConfigFragmentTarget) schema.GroupVersionKind {
+	return schema.FromAPIVersionAndKind(target.APIVersion, target.Kind)
+}
+
+// NewSourceMapFunc returns a MapFunc which maps an event of a fragment to requests for its target
+// and for the target into which it was last merged, if they differ.
+func NewSourceMapFunc(crdService services.CRDService) handler.MapFunc {
+	mapFunc := func(obj client.Object) []ctrl.Request {
+		fragment := obj.(*servicev1alpha1.ConsulConfigFragment)
+		targetKey := GetTargetKey(fragment.Spec.Target)
+		requests := []ctrl.Request{newRequest(obj.GetNamespace(), targetKey)}
+
+		lastDestination := crdService.GetLastDestination(obj)
+		if lastDestination != nil {
+			lastTargetKey := GetTargetKey(servicev1alpha1.ConfigFragmentTarget{
+				APIVersion: lastDestination.APIVersion,
+				Kind:       lastDestination.Kind,
+				Name:       lastDestination.Name,
+			})
+
+			if lastTargetKey != targetKey {
+				requests = append(requests, newRequest(obj.GetNamespace(), lastTargetKey))
+			}
+		}
+
+		return requests
+	}
+
+	return mapFunc
+}
+
+// NewTargetMapFunc returns a MapFunc which maps an event of a target to a request for it,
+// so that the changes of the target made outside of the controller are reverted.
+func NewTargetMapFunc() handler.MapFunc {
+	mapFunc := func(obj client.Object) []ctrl.Request {
+		apiVersion, kind := obj.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+		targetKey := GetTargetKey(servicev1alpha1.ConfigFragmentTarget{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Name:       obj.GetName(),
+		})
+
+		return []ctrl.Request{newRequest(obj.GetNamespace(), targetKey)}
+	}
+
+	return mapFunc
+}
+
+func newRequest(namespace, name string) ctrl.Request {
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+
+	return request
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fragments_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/fragments"
+)
+
+var _ = Describe("Target key", func() {
+	table.DescribeTable("ParseTargetKey",
+		func(key string, expected *v1alpha1.ConfigFragmentTarget) {
+			target, err := fragments.ParseTargetKey(key)
+			if expected == nil {
+				Expect(err).To(HaveOccurred())
+
+				return
+			}
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target).To(Equal(*expected))
+			Expect(fragments.GetTargetKey(target)).To(Equal(key))
+		},
+		table.Entry("parses a target with an API group",
+			"consul.hashicorp.com/v1alpha1/ServiceDefaults/web",
+			&v1alpha1.ConfigFragmentTarget{APIVersion: "consul.hashicorp.com/v1alpha1", Kind: "ServiceDefaults", Name: "web"},
+		),
+		table.Entry("parses a target from the core API group",
+			"v1/ConfigMap/web",
+			&v1alpha1.ConfigFragmentTarget{APIVersion: "v1", Kind: "ConfigMap", Name: "web"},
+		),
+		table.Entry("rejects a key without a kind", "ServiceDefaults/web", nil),
+		table.Entry("rejects a key without a name", "consul.hashicorp.com/v1alpha1/ServiceDefaults/", nil),
+		table.Entry("rejects an empty kind", "consul.hashicorp.com/v1alpha1//web", nil),
+	)
+})
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fragments
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// watchSyncTimeout is the time to wait for the cache of a newly watched kind to be synced,
+// e.g. when the controller isn't allowed to list the kind.
+const watchSyncTimeout = 30 * time.Second
+
+// TargetWatcher watches the kinds of the targets of the fragments, which aren't known when the controller is set up.
+type TargetWatcher interface {
+	// Watch starts watching the resources of the kind unless they are already watched.
+	Watch(ctx context.Context, gvk schema.GroupVersionKind) error
+}
+
+type targetWatcher struct {
+	controller controller.Controller
+	lock       sync.Mutex
+	watches    map[schema.GroupVersionKind]*targetWatch
+}
+
+// targetWatch is the watch of a kind, which is registered in the controller only once.
+type targetWatch struct {
+	synced chan struct{}
+	err    error
+}
+
+func (w *targetWatcher) Watch(ctx context.Context, gvk schema.GroupVersionKind) error {
+	watch, err := w.getOrAddWatch(gvk)
+	if err != nil {
+		return err
+	}
+
+	// The sync is waited without holding the lock, so the merges of the other kinds aren't blocked by it.
+	// The kind stays registered when its cache isn't synced in time, so the next merge waits for the same watch.
+	ctx, cancel := context.WithTimeout(ctx, watchSyncTimeout)
+	defer cancel()
+
+	select {
+	case <-watch.synced:
+		return watch.err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for the cache of %s to be synced", gvk)
+	}
+}
+
+func (w *targetWatcher) getOrAddWatch(gvk schema.GroupVersionKind) (*targetWatch, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if watch, ok := w.watches[gvk]; ok {
+		return watch, nil
+	}
+
+	target := &unstructured.Unstructured{}
+	target.SetGroupVersionKind(gvk)
+	kind := &source.Kind{Type: target}
+	err := w.controller.Watch(kind, handler.EnqueueRequestsFromMapFunc(NewTargetMapFunc()))
+	if err != nil {
+		return nil, err
+	}
+
+	watch := &targetWatch{synced: make(chan struct{})}
+	w.watches[gvk] = watch
+
+	go w.waitForSync(gvk, kind, watch)
+
+	return watch, nil
+}
+
+func (w *targetWatcher) waitForSync(gvk schema.GroupVersionKind, kind source.SyncingSource, watch *targetWatch) {
+	// The source reports the result of its start only once, so it is shared by all merges of the kind.
+	watch.err = kind.WaitForSync(context.Background())
+	if watch.err != nil {
+		// The source fails before its handler is added, e.g. when the CRD of the kind isn't installed yet,
+		// so the kind is registered again by the next merge.
+		w.lock.Lock()
+		delete(w.watches, gvk)
+		w.lock.Unlock()
+	}
+
+	close(watch.synced)
+}
+
+// NewTargetWatcher returns a TargetWatcher which adds the watches to the controller which merges the fragments.
+// The controller must be started before the first call of Watch.
+func NewTargetWatcher(c controller.Controller) TargetWatcher {
+	w := new(targetWatcher)
+	w.controller = c
+	w.watches = map[schema.GroupVersionKind]*targetWatch{}
+
+	return w
+}
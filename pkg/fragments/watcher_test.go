@@ -0,0 +1,139 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fragments_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/NativeChat/consul-merge-controller/pkg/fragments"
+)
+
+// fakeController counts the watches and starts their sources with cache.
+// The sources aren't started and never sync when cache is nil.
+type fakeController struct {
+	cache cache.Cache
+	lock  sync.Mutex
+	calls int
+}
+
+func (c *fakeController) Reconcile(context.Context, reconcile.Request) (reconcile.Result, error) {
+	return reconcile.Result{}, nil
+}
+
+func (c *fakeController) Watch(src source.Source, eventhandler handler.EventHandler, predicates ...predicate.Predicate) error {
+	c.lock.Lock()
+	c.calls++
+	c.lock.Unlock()
+
+	if c.cache == nil {
+		return nil
+	}
+
+	kind := src.(*source.Kind)
+	Expect(kind.InjectCache(c.cache)).To(Succeed())
+
+	return kind.Start(context.Background(), eventhandler, workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()), predicates...)
+}
+
+func (c *fakeController) Start(context.Context) error {
+	return nil
+}
+
+func (c *fakeController) GetLogger() logr.Logger {
+	return logr.Discard()
+}
+
+func (c *fakeController) watchCalls() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.calls
+}
+
+var _ = Describe("TargetWatcher", func() {
+	serviceRouter := schema.GroupVersionKind{Group: "consul.hashicorp.com", Version: "v1alpha1", Kind: "ServiceRouter"}
+	serviceSplitter := schema.GroupVersionKind{Group: "consul.hashicorp.com", Version: "v1alpha1", Kind: "ServiceSplitter"}
+
+	It("watches a synced kind only once", func() {
+		c := &fakeController{cache: &informertest.FakeInformers{}}
+		watcher := fragments.NewTargetWatcher(c)
+
+		Expect(watcher.Watch(context.Background(), serviceRouter)).To(Succeed())
+		Expect(watcher.Watch(context.Background(), serviceRouter)).To(Succeed())
+		Expect(c.watchCalls()).To(Equal(1))
+	})
+
+	It("doesn't watch a kind again while its cache is syncing", func() {
+		c := &fakeController{}
+		watcher := fragments.NewTargetWatcher(c)
+
+		for i := 0; i < 2; i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			Expect(watcher.Watch(ctx, serviceRouter)).NotTo(Succeed())
+			cancel()
+		}
+
+		Expect(c.watchCalls()).To(Equal(1))
+	})
+
+	It("doesn't block the watches of the other kinds while a cache is syncing", func() {
+		c := &fakeController{}
+		watcher := fragments.NewTargetWatcher(c)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		done := make(chan error)
+		go func() {
+			done <- watcher.Watch(ctx, serviceRouter)
+		}()
+
+		Eventually(c.watchCalls).Should(Equal(1))
+
+		otherCtx, otherCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer otherCancel()
+		Expect(watcher.Watch(otherCtx, serviceSplitter)).NotTo(Succeed())
+		Expect(c.watchCalls()).To(Equal(2))
+
+		cancel()
+		Eventually(done).Should(Receive(HaveOccurred()))
+	})
+
+	It("watches a kind again when its source fails to start", func() {
+		c := &fakeController{cache: &informertest.FakeInformers{Error: errors.New("no matches for kind")}}
+		watcher := fragments.NewTargetWatcher(c)
+
+		Expect(watcher.Watch(context.Background(), serviceRouter)).To(MatchError("no matches for kind"))
+		Expect(watcher.Watch(context.Background(), serviceRouter)).To(MatchError("no matches for kind"))
+		Expect(c.watchCalls()).To(Equal(2))
+	})
+})
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package intentions
+
+import (
+	"fmt"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	e "github.com/NativeChat/consul-merge-controller/pkg/errors"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewMergeItemFunc returns a MergeItemFunc which groups the intention sources
+// by name and namespace and concatenates the permissions of the sources in each group.
+// A source which sets action can't be merged with a source which sets permissions.
+func NewMergeItemFunc() services.MergeItemFunc {
+	sourceOwners := map[string]string{}
+
+	mergeItem := func(expected client.Object, item client.Object) error {
+		serviceIntentions := expected.(*consulk8s.ServiceIntentions)
+		source := item.(*servicev1alpha1.ConsulServiceIntentionsSource).Spec.Source
+		if source == nil {
+			return nil
+		}
+
+		key := fmt.Sprintf("%s/%s", source.Namespace, source.Name)
+
+		for _, existing := range serviceIntentions.Spec.Sources {
+			if fmt.Sprintf("%s/%s", existing.Namespace, existing.Name) != key {
+				continue
+			}
+
+			owner := sourceOwners[key]
+			if len(existing.Action) > 0 && len(source.Permissions) > 0 || len(existing.Permissions) > 0 && len(source.Action) > 0 {
+				err := fmt.Errorf("source %s has action in one of %s and %s and permissions in the other", key, owner, item.GetName())
+
+				return e.NewConflictError(err, owner, item.GetName())
+			}
+
+			if existing.Action != source.Action {
+				err := fmt.Errorf("source %s has action %s in %s and %s in %s", key, existing.Action, owner, source.Action, item.GetName())
+
+				return e.NewConflictError(err, owner, item.GetName())
+			}
+
+			existing.Permissions = append(existing.Permissions, source.Permissions.DeepCopy()...)
+
+			return nil
+		}
+
+		sourceOwners[key] = item.GetName()
+		serviceIntentions.Spec.Sources = append(serviceIntentions.Spec.Sources, source.DeepCopy())
+
+		return nil
+	}
+
+	return mergeItem
+}
+
+// SetDestinationName sets the destination of the service intentions to the service with the same name.
+func SetDestinationName(obj client.Object, items []client.Object) (client.Object, error) {
+	serviceIntentions := obj.(*consulk8s.ServiceIntentions)
+
+	serviceIntentions.Spec.Destination.Name = obj.GetName()
+
+	return serviceIntentions, nil
+}
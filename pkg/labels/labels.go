@@ -24,8 +24,51 @@ import (
 
 var (
 	// ServiceRouter is the name of the label which stores the service router name.
-	ServiceRouter = fmt.Sprintf("%s/service-router", servicev1alpha1.GroupVersion.Group)
+	ServiceRouter string
 
 	// ServiceIntentions is the name of the label which stores the service intentions name.
-	ServiceIntentions = fmt.Sprintf("%s/service-intentions", servicev1alpha1.GroupVersion.Group)
+	ServiceIntentions string
+
+	// ServiceSplitter is the name of the label which stores the service splitter name.
+	ServiceSplitter string
+
+	// ServiceResolver is the name of the label which stores the service resolver name.
+	ServiceResolver string
+
+	// IngressGateway is the name of the label which stores the ingress gateway name.
+	IngressGateway string
+
+	// TerminatingGateway is the name of the label which stores the terminating gateway name.
+	TerminatingGateway string
+
+	prefix string
+)
+
+func init() {
+	SetPrefix(servicev1alpha1.GroupVersion.Group)
+}
+
+// SetPrefix sets the prefix of the labels which select the merge destinations.
+// It has to be called before the controllers are set up.
+func SetPrefix(labelPrefix string) {
+	prefix = labelPrefix
+	ServiceRouter = fmt.Sprintf("%s/service-router", prefix)
+	ServiceIntentions = fmt.Sprintf("%s/service-intentions", prefix)
+	ServiceSplitter = fmt.Sprintf("%s/service-splitter", prefix)
+	ServiceResolver = fmt.Sprintf("%s/service-resolver", prefix)
+	IngressGateway = fmt.Sprintf("%s/ingress-gateway", prefix)
+	TerminatingGateway = fmt.Sprintf("%s/terminating-gateway", prefix)
+}
+
+// GetPrefix returns the prefix of the labels which select the merge destinations.
+func GetPrefix() string {
+	return prefix
+}
+
+const (
+	// ManagedBy is the name of the label which marks the merge destinations created by the controller.
+	ManagedBy = "app.kubernetes.io/managed-by"
+
+	// ManagedByValue is the value of the ManagedBy label of the merge destinations created by the controller.
+	ManagedByValue = "consul-merge-controller"
 )
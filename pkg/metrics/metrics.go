@@ -0,0 +1,103 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// MergeResultCreated is the result of a merge which created the destination.
+	MergeResultCreated = "created"
+
+	// MergeResultUpdated is the result of a merge which updated the destination.
+	MergeResultUpdated = "updated"
+
+	// MergeResultDeleted is the result of a merge which deleted the destination.
+	MergeResultDeleted = "deleted"
+
+	// MergeResultUnchanged is the result of a merge which didn't change the destination.
+	MergeResultUnchanged = "unchanged"
+
+	// MergeResultFailed is the result of a merge which failed.
+	MergeResultFailed = "failed"
+)
+
+const namespace = "consul_merge_controller"
+
+var (
+	// Merges counts the merges by destination kind and result.
+	Merges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "merges_total",
+		Help:      "Number of merges by destination kind and result.",
+	}, []string{"kind", "result"})
+
+	// DestinationItems is the number of items in each merged destination.
+	DestinationItems = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "destination_items",
+		Help:      "Number of items in the merged destination.",
+	}, []string{"kind", "namespace", "name"})
+
+	// MergeDuration is the latency of the merges by destination kind.
+	MergeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "merge_duration_seconds",
+		Help:      "Latency of the merges by destination kind.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"kind"})
+
+	// Conflicts counts the merges which failed because of conflicting resources by destination kind.
+	Conflicts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "conflicts_total",
+		Help:      "Number of merges which failed because of conflicting resources by destination kind.",
+	}, []string{"kind"})
+
+	// DriftRepairs counts the reverted changes of the destinations made outside of the controller by destination kind.
+	DriftRepairs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "drift_repairs_total",
+		Help:      "Number of reverted changes of the destinations made outside of the controller by destination kind.",
+	}, []string{"kind"})
+
+	// LastSuccessfulMerge is the time of the last successful merge of each destination.
+	LastSuccessfulMerge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_successful_merge_timestamp_seconds",
+		Help:      "Unix time of the last successful merge of the destination.",
+	}, []string{"kind", "namespace", "name"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		Merges,
+		DestinationItems,
+		MergeDuration,
+		Conflicts,
+		DriftRepairs,
+		LastSuccessfulMerge,
+	)
+}
+
+// DeleteDestination removes the metrics of a destination which no longer exists.
+func DeleteDestination(kind, namespace, name string) {
+	DestinationItems.DeleteLabelValues(kind, namespace, name)
+	LastSuccessfulMerge.DeleteLabelValues(kind, namespace, name)
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/render"
+	"github.com/NativeChat/consul-merge-controller/pkg/routes"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	"github.com/NativeChat/consul-merge-controller/pkg/strategies"
+)
+
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// Split splits the ServiceRouter, ServiceSplitter and ServiceIntentions resources into
+// ConsulServiceRoute, ConsulServiceSplit and ConsulServiceIntentionsSource resources.
+// The resources of other kinds are skipped.
+func Split(objs []client.Object) ([]client.Object, error) {
+	split := []client.Object{}
+	for _, obj := range objs {
+		switch obj := obj.(type) {
+		case *consulk8s.ServiceRouter:
+			for _, route := range SplitServiceRouter(obj) {
+				split = append(split, route)
+			}
+		case *consulk8s.ServiceSplitter:
+			for _, serviceSplit := range SplitServiceSplitter(obj) {
+				split = append(split, serviceSplit)
+			}
+		case *consulk8s.ServiceIntentions:
+			sources, err := SplitServiceIntentions(obj)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, source := range sources {
+				split = append(split, source)
+			}
+		}
+	}
+
+	return split, nil
+}
+
+// SplitServiceRouter creates a ConsulServiceRoute for each route of the service router.
+// The routes are ordered by priority, so the first route has the highest priority.
+func SplitServiceRouter(serviceRouter *consulk8s.ServiceRouter) []*servicev1alpha1.ConsulServiceRoute {
+	serviceRoutes := serviceRouter.Spec.Routes
+	routeList := []*servicev1alpha1.ConsulServiceRoute{}
+
+	for i, serviceRoute := range serviceRoutes {
+		route := &servicev1alpha1.ConsulServiceRoute{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: servicev1alpha1.GroupVersion.String(),
+				Kind:       "ConsulServiceRoute",
+			},
+			ObjectMeta: newObjectMeta(serviceRouter, controllerlabels.ServiceRouter, getItemName(serviceRouter.Name, i, len(serviceRoutes), "")),
+			Spec: servicev1alpha1.ConsulServiceRouteSpec{
+				Route:    *serviceRoute.DeepCopy(),
+				Priority: int32(len(serviceRoutes) - i),
+			},
+		}
+
+		// The ordering is set explicitly, so the routes keep their order when the controller uses another default ordering.
+		route.Annotations = map[string]string{routes.GetOrderingAnnotation(): routes.OrderingPriority}
+		routeList = append(routeList, route)
+	}
+
+	return routeList
+}
+
+// SplitServiceSplitter creates a ConsulServiceSplit for each split of the service splitter.
+// The names of the created resources keep the order of the splits and none of them is the default split,
+// so the weights are written as they are.
+func SplitServiceSplitter(serviceSplitter *consulk8s.ServiceSplitter) []*servicev1alpha1.ConsulServiceSplit {
+	serviceSplits := serviceSplitter.Spec.Splits
+	splitList := []*servicev1alpha1.ConsulServiceSplit{}
+
+	for i, serviceSplit := range serviceSplits {
+		suffix := strings.Join([]string{serviceSplit.Service, serviceSplit.ServiceSubset}, "-")
+		split := &servicev1alpha1.ConsulServiceSplit{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: servicev1alpha1.GroupVersion.String(),
+				Kind:       "ConsulServiceSplit",
+			},
+			ObjectMeta: newObjectMeta(serviceSplitter, controllerlabels.ServiceSplitter, getItemName(serviceSplitter.Name, i, len(serviceSplits), suffix)),
+			Spec: servicev1alpha1.ConsulServiceSplitSpec{
+				Split: *serviceSplit.DeepCopy(),
+			},
+		}
+
+		splitList = append(splitList, split)
+	}
+
+	return splitList
+}
+
+// SplitServiceIntentions creates a ConsulServiceIntentionsSource for each source of the service intentions.
+// The names of the created resources keep the order of the sources.
+// The destination of the service intentions must be the service with the same name
+// because the controller always sets it to the name of the service intentions.
+func SplitServiceIntentions(serviceIntentions *consulk8s.ServiceIntentions) ([]*servicev1alpha1.ConsulServiceIntentionsSource, error) {
+	destination := serviceIntentions.Spec.Destination
+	if destination.Name != serviceIntentions.Name || len(destination.Namespace) > 0 {
+		return nil, fmt.Errorf("ServiceIntentions %s has destination %s/%s, only the service with the same name is supported",
+			client.ObjectKeyFromObject(serviceIntentions), destination.Namespace, destination.Name)
+	}
+
+	sources := serviceIntentions.Spec.Sources
+	sourceList := []*servicev1alpha1.ConsulServiceIntentionsSource{}
+
+	for i, source := range sources {
+		intentionsSource := &servicev1alpha1.ConsulServiceIntentionsSource{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: servicev1alpha1.GroupVersion.String(),
+				Kind:       "ConsulServiceIntentionsSource",
+			},
+			ObjectMeta: newObjectMeta(serviceIntentions, controllerlabels.ServiceIntentions, getItemName(serviceIntentions.Name, i, len(sources), source.Name)),
+			Spec: servicev1alpha1.ConsulServiceIntentionsSourceSpec{
+				Source: source.DeepCopy(),
+			},
+		}
+
+		sourceList = append(sourceList, intentionsSource)
+	}
+
+	return sourceList, nil
+}
+
+// Verify renders the split resources and checks that the specs of the destinations are reproduced exactly.
+// The destinations without entries, e.g. routes or sources, aren't rendered, so they are skipped.
+func Verify(ctx context.Context, destinations []client.Object, split []client.Object) error {
+	result, err := render.NewRenderer(logr.Discard(), metav1.NamespaceDefault, routes.OrderingPriority, false).Render(ctx, split)
+	if err != nil {
+		return err
+	}
+
+	rendered := map[string]client.Object{}
+	for _, obj := range result.Destinations {
+		rendered[getKey(obj)] = obj
+	}
+
+	for _, destination := range destinations {
+		strategy := getStrategy(destination)
+		if strategy == nil || strategy.CountEntries(destination) == 0 {
+			continue
+		}
+
+		obj, ok := rendered[getKey(destination)]
+		if !ok {
+			return fmt.Errorf("%s isn't rendered from the split resources", getKey(destination))
+		}
+
+		if !reflect.DeepEqual(strategy.GetSpec(obj), strategy.GetSpec(destination)) {
+			return fmt.Errorf("the rendered spec of %s is different from the original", getKey(destination))
+		}
+	}
+
+	return nil
+}
+
+// getStrategy returns the merge strategy of the destination or nil if the controller doesn't merge destinations of its kind.
+func getStrategy(destination client.Object) services.MergeStrategy {
+	for _, kind := range strategies.GetMergeKinds() {
+		strategy := kind.NewStrategy()
+		if reflect.TypeOf(strategy.NewDestination()) == reflect.TypeOf(destination) {
+			return strategy
+		}
+	}
+
+	return nil
+}
+
+func newObjectMeta(destination client.Object, queryLabel, name string) metav1.ObjectMeta {
+	objectMeta := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: destination.GetNamespace(),
+		Labels:    map[string]string{queryLabel: destination.GetName()},
+	}
+
+	return objectMeta
+}
+
+// getItemName returns the name of the i-th item of the destination.
+// The index is padded with zeros so the names are sorted in the order of the items.
+func getItemName(destinationName string, i, count int, suffix string) string {
+	width := len(fmt.Sprint(count - 1))
+	name := fmt.Sprintf("%s-%0*d", destinationName, width, i)
+
+	suffix = strings.Trim(invalidNameCharacters.ReplaceAllString(strings.ToLower(strings.ReplaceAll(suffix, "*", "all")), "-"), "-")
+	if len(suffix) > 0 {
+		name = fmt.Sprintf("%s-%s", name, suffix)
+	}
+
+	return name
+}
+
+// getKey returns the kind and the namespaced name of the object. The objects without a namespace are in the default namespace.
+func getKey(obj client.Object) string {
+	namespace := obj.GetNamespace()
+	if len(namespace) == 0 {
+		namespace = metav1.NamespaceDefault
+	}
+
+	return fmt.Sprintf("%s %s/%s", reflect.TypeOf(obj).Elem().Name(), namespace, obj.GetName())
+}
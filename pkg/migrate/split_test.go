@@ -0,0 +1,183 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/migrate"
+	"github.com/NativeChat/consul-merge-controller/pkg/render"
+	"github.com/NativeChat/consul-merge-controller/pkg/routes"
+)
+
+func newServiceRouter(name string, pathPrefixes ...string) *consulk8s.ServiceRouter {
+	serviceRouter := &consulk8s.ServiceRouter{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault},
+	}
+
+	for i, pathPrefix := range pathPrefixes {
+		serviceRouter.Spec.Routes = append(serviceRouter.Spec.Routes, consulk8s.ServiceRoute{
+			Match:       &consulk8s.ServiceRouteMatch{HTTP: &consulk8s.ServiceRouteHTTPMatch{PathPrefix: pathPrefix}},
+			Destination: &consulk8s.ServiceRouteDestination{Service: fmt.Sprintf("%s-%d", name, i)},
+		})
+	}
+
+	return serviceRouter
+}
+
+func newServiceSplitter(name string, weights ...float32) *consulk8s.ServiceSplitter {
+	serviceSplitter := &consulk8s.ServiceSplitter{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault},
+	}
+
+	for i, weight := range weights {
+		serviceSplitter.Spec.Splits = append(serviceSplitter.Spec.Splits, consulk8s.ServiceSplit{
+			Weight:        weight,
+			Service:       name,
+			ServiceSubset: fmt.Sprintf("v%d", i),
+		})
+	}
+
+	return serviceSplitter
+}
+
+func newServiceIntentions(name string, sources ...string) *consulk8s.ServiceIntentions {
+	serviceIntentions := &consulk8s.ServiceIntentions{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault},
+		Spec: consulk8s.ServiceIntentionsSpec{
+			Destination: consulk8s.Destination{Name: name},
+		},
+	}
+
+	for _, source := range sources {
+		serviceIntentions.Spec.Sources = append(serviceIntentions.Spec.Sources, &consulk8s.SourceIntention{
+			Name:   source,
+			Action: "allow",
+		})
+	}
+
+	return serviceIntentions
+}
+
+// renderSplit splits the destinations and renders the split resources with the route ordering.
+func renderSplit(routeOrdering string, destinations ...client.Object) []client.Object {
+	split, err := migrate.Split(destinations)
+	Expect(err).NotTo(HaveOccurred())
+
+	result, err := render.NewRenderer(logr.Discard(), metav1.NamespaceDefault, routeOrdering, false).Render(context.Background(), split)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(result.Unlabeled).To(BeEmpty())
+
+	return result.Destinations
+}
+
+var _ = Describe("Split", func() {
+	It("reproduces the spec of a service router", func() {
+		serviceRouter := newServiceRouter("service-a", "/v2", "/v1/api", "/v1")
+
+		rendered := renderSplit(routes.OrderingPriority, serviceRouter)
+		Expect(rendered).To(HaveLen(1))
+		Expect(rendered[0].(*consulk8s.ServiceRouter).Spec).To(Equal(serviceRouter.Spec))
+	})
+
+	It("keeps the order of the routes when the specificity ordering is the default", func() {
+		serviceRouter := newServiceRouter("service-a", "/v2", "/v1/api", "/v1")
+
+		rendered := renderSplit(routes.OrderingSpecificity, serviceRouter)
+		Expect(rendered).To(HaveLen(1))
+		Expect(rendered[0].(*consulk8s.ServiceRouter).Spec).To(Equal(serviceRouter.Spec))
+	})
+
+	It("sets descending priorities and the priority ordering on the routes", func() {
+		routeList := migrate.SplitServiceRouter(newServiceRouter("service-a", "/v2", "/v1/api", "/v1"))
+		Expect(routeList).To(HaveLen(3))
+
+		for i, route := range routeList {
+			Expect(route.Spec.Priority).To(BeNumerically("==", 3-i))
+			Expect(route.Annotations).To(HaveKeyWithValue(routes.GetOrderingAnnotation(), routes.OrderingPriority))
+		}
+	})
+
+	It("keeps the order of more than ten routes", func() {
+		pathPrefixes := []string{}
+		for i := 0; i < 12; i++ {
+			pathPrefixes = append(pathPrefixes, fmt.Sprintf("/v%d", i))
+		}
+
+		serviceRouter := newServiceRouter("service-a", pathPrefixes...)
+
+		rendered := renderSplit(routes.OrderingSpecificity, serviceRouter)
+		Expect(rendered).To(HaveLen(1))
+		Expect(rendered[0].(*consulk8s.ServiceRouter).Spec).To(Equal(serviceRouter.Spec))
+	})
+
+	It("reproduces the spec of a service splitter", func() {
+		serviceSplitter := newServiceSplitter("service-a", 33.33, 33.33, 33.34)
+
+		rendered := renderSplit(routes.OrderingPriority, serviceSplitter)
+		Expect(rendered).To(HaveLen(1))
+		Expect(rendered[0].(*consulk8s.ServiceSplitter).Spec).To(Equal(serviceSplitter.Spec))
+	})
+
+	It("reproduces the spec of service intentions", func() {
+		sources := []string{"*"}
+		for i := 0; i < 11; i++ {
+			sources = append(sources, fmt.Sprintf("service-%c", 'k'-i))
+		}
+
+		serviceIntentions := newServiceIntentions("service-a", sources...)
+
+		rendered := renderSplit(routes.OrderingPriority, serviceIntentions)
+		Expect(rendered).To(HaveLen(1))
+		Expect(rendered[0].(*consulk8s.ServiceIntentions).Spec).To(Equal(serviceIntentions.Spec))
+	})
+
+	It("verifies the split resources of all kinds", func() {
+		destinations := []client.Object{
+			newServiceRouter("service-a", "/v2", "/v1/api", "/v1"),
+			newServiceSplitter("service-a", 90, 10),
+			newServiceIntentions("service-a", "service-b", "*"),
+			newServiceRouter("service-b"),
+		}
+
+		split, err := migrate.Split(destinations)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(split).To(HaveLen(7))
+		Expect(migrate.Verify(context.Background(), destinations, split)).To(Succeed())
+
+		By("changing the priority of a route")
+		split[0].(*v1alpha1.ConsulServiceRoute).Spec.Priority = 0
+		Expect(migrate.Verify(context.Background(), destinations, split)).To(MatchError(ContainSubstring("ServiceRouter default/service-a")))
+	})
+
+	It("fails for service intentions with another destination", func() {
+		serviceIntentions := newServiceIntentions("service-a", "service-b")
+		serviceIntentions.Spec.Destination.Name = "service-c"
+
+		_, err := migrate.Split([]client.Object{serviceIntentions})
+		Expect(err).To(HaveOccurred())
+	})
+})
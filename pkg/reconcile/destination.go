@@ -0,0 +1,101 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// The requests for resources without a destination label use the name of the resource with this prefix.
+// The prefix isn't valid in a resource name, so these requests can't be mistaken for requests for a destination.
+const unlabeledResourcePrefix = "unlabeled:"
+
+// NewSourceMapFunc returns a MapFunc which maps an event of a resource to requests for the destination
+// selected by its label and for the destination into which it was last merged, if they differ.
+// A resource without the label is mapped to a request for the resource itself, so that it is reported as invalid.
+func NewSourceMapFunc(crdService services.CRDService, queryLabel string) handler.MapFunc {
+	mapFunc := func(obj client.Object) []ctrl.Request {
+		requests := []ctrl.Request{}
+
+		queryValue := obj.GetLabels()[queryLabel]
+		if len(queryValue) > 0 {
+			requests = append(requests, newRequest(obj.GetNamespace(), queryValue))
+		} else {
+			requests = append(requests, newRequest(obj.GetNamespace(), unlabeledResourcePrefix+obj.GetName()))
+		}
+
+		lastDestination := crdService.GetLastDestination(obj)
+		if lastDestination != nil && lastDestination.Name != queryValue {
+			requests = append(requests, newRequest(obj.GetNamespace(), lastDestination.Name))
+		}
+
+		return requests
+	}
+
+	return mapFunc
+}
+
+// SetupWithManager sets up a controller which reconciles the destinations of the src resources.
+// The controller is named after the kind of the destination. The destinations are watched too
+// unless watchDestinations is false, e.g. when they aren't stored in the cluster.
+func SetupWithManager(
+	mgr ctrl.Manager,
+	options controller.Options,
+	destination client.Object,
+	src client.Object,
+	mapSource handler.MapFunc,
+	watchDestinations bool,
+) error {
+	name := strings.ToLower(reflect.TypeOf(destination).Elem().Name())
+	c, err := controller.New(name, mgr, options)
+	if err != nil {
+		return err
+	}
+
+	if watchDestinations {
+		err = c.Watch(&source.Kind{Type: destination}, &handler.EnqueueRequestForObject{})
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.Watch(&source.Kind{Type: src}, handler.EnqueueRequestsFromMapFunc(mapSource))
+}
+
+// IsDestinationRequest checks if the request is for a destination and not for a resource without a destination label.
+func IsDestinationRequest(req ctrl.Request) bool {
+	return !strings.HasPrefix(req.Name, unlabeledResourcePrefix)
+}
+
+func getUnlabeledResourceRequest(req ctrl.Request) ctrl.Request {
+	return newRequest(req.Namespace, strings.TrimPrefix(req.Name, unlabeledResourcePrefix))
+}
+
+func newRequest(namespace, name string) ctrl.Request {
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+
+	return request
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile_test
+
+import (
+	"reflect"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/finalizers"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/reconcile"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+)
+
+func newRequest(name string) ctrl.Request {
+	return ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}}
+}
+
+var _ = Describe("NewSourceMapFunc", func() {
+	table.DescribeTable("maps the resource to the requests for its destinations",
+		func(serviceRouter, lastServiceRouter string, expected []ctrl.Request) {
+			route := newRoute("service-a-v1", serviceRouter, "/v1")
+			if len(lastServiceRouter) > 0 {
+				route.Status.Destination = &v1alpha1.DestinationReference{Kind: "ServiceRouter", Name: lastServiceRouter, Namespace: "default"}
+			}
+
+			crdService := services.NewCRDService(
+				nil,
+				nil,
+				logr.Discard(),
+				finalizers.FinalizerName,
+				reflect.TypeOf(v1alpha1.ConsulServiceRoute{}),
+				reflect.TypeOf(v1alpha1.ConsulServiceRouteList{}),
+			)
+
+			requests := reconcile.NewSourceMapFunc(crdService, controllerlabels.ServiceRouter)(route)
+			Expect(requests).To(Equal(expected))
+		},
+		table.Entry("a new labeled resource",
+			"service-a", "", []ctrl.Request{newRequest("service-a")},
+		),
+		table.Entry("a labeled resource merged into the same destination",
+			"service-a", "service-a", []ctrl.Request{newRequest("service-a")},
+		),
+		table.Entry("a resource whose label was changed",
+			"service-b", "service-a", []ctrl.Request{newRequest("service-b"), newRequest("service-a")},
+		),
+		table.Entry("a new unlabeled resource",
+			"", "", []ctrl.Request{newRequest("unlabeled:service-a-v1")},
+		),
+		table.Entry("a resource whose label was removed",
+			"", "service-a", []ctrl.Request{newRequest("unlabeled:service-a-v1"), newRequest("service-a")},
+		),
+	)
+})
+
+var _ = Describe("IsDestinationRequest", func() {
+	It("distinguishes the requests for the unlabeled resources", func() {
+		Expect(reconcile.IsDestinationRequest(newRequest("service-a"))).To(BeTrue())
+		Expect(reconcile.IsDestinationRequest(newRequest("unlabeled:service-a-v1"))).To(BeFalse())
+	})
+})
@@ -20,107 +20,357 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"time"
 
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
 	e "github.com/NativeChat/consul-merge-controller/pkg/errors"
+	"github.com/NativeChat/consul-merge-controller/pkg/events"
+	"github.com/NativeChat/consul-merge-controller/pkg/metrics"
 	"github.com/NativeChat/consul-merge-controller/pkg/services"
 	"github.com/go-logr/logr"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type reconciler struct {
-	statusClient client.StatusClient
-	crdService   services.CRDService
-	merger       services.Merger
-	log          logr.Logger
-	queryLabel   string
+	statusClient  client.StatusClient
+	crdService    services.CRDService
+	merger        services.Merger
+	log           logr.Logger
+	recorder      record.EventRecorder
+	queryLabel    string
+	findConflicts services.FindConflictsFunc
 }
 
 func (r *reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	r.log.Info("starting reconcile")
 
-	obj, res, err := r.crdService.GetResourceFromRequest(ctx, req)
-	if err != nil || res != nil {
-		return *res, err
+	if !IsDestinationRequest(req) {
+		return r.reconcileUnlabeledResource(ctx, req)
 	}
 
-	r.log = r.log.WithValues("resourceName", obj.GetName())
+	destinationResourceName := req.Name
+	namespace := req.Namespace
+
+	resources, err := r.crdService.ListResources(ctx, r.queryLabel, destinationResourceName, namespace)
+	if err != nil {
+		r.log.Error(err, "failed to get all resources for service")
+		reconcileErr := new(e.ReconcileError)
+		if errors.As(err, &reconcileErr) {
+			return ctrl.Result{Requeue: reconcileErr.ShouldRequeue}, err
+		}
+
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	notMarkedForDeletion := []client.Object{}
+	for _, resource := range resources {
+		if !r.crdService.IsDeleted(resource) {
+			notMarkedForDeletion = append(notMarkedForDeletion, resource)
+		}
+	}
+
+	r.log.Info(fmt.Sprintf("merging %d resources, %d resources are marked for deletion", len(notMarkedForDeletion), len(resources)-len(notMarkedForDeletion)))
 
-	reconcileAction := "triggered by dependency change"
-	isChanged := r.crdService.IsChanged(obj)
-	isDeleted := r.crdService.IsDeleted(obj)
-	if r.crdService.IsNew(obj) {
-		reconcileAction = "create"
-	} else if isChanged {
-		reconcileAction = "change"
-	} else if isDeleted {
-		reconcileAction = "delete"
+	kind := r.merger.GetDestinationReference(destinationResourceName, namespace).Kind
+	mergeStartedAt := time.Now()
+	res, err := r.merger.Merge(ctx, destinationResourceName, namespace, notMarkedForDeletion)
+	metrics.MergeDuration.WithLabelValues(kind).Observe(time.Since(mergeStartedAt).Seconds())
+
+	if errors.Is(err, e.ErrConflict) {
+		metrics.Conflicts.WithLabelValues(kind).Inc()
 	}
 
-	r.log.Info(fmt.Sprintf("reconcile action is: %s", reconcileAction))
+	// A change of one resource can resolve or cause conflicts of the other resources with the same destination,
+	// so the conflicts are set on each of them with the rest of their status.
+	conflicts := map[string]services.Conflict{}
+	if r.findConflicts != nil {
+		conflicts = r.findConflicts(notMarkedForDeletion)
+	}
+
+	if err != nil || res != nil {
+		for _, obj := range notMarkedForDeletion {
+			original := obj.DeepCopyObject().(client.Object)
+			r.setMergeConditions(obj, destinationResourceName, namespace, res, err)
+			r.setDetectedConflict(obj, conflicts)
+			r.recordMergeEvent(obj, original, destinationResourceName, res, err)
+			r.updateStatusIfChanged(ctx, obj, original)
+		}
 
-	queryValue, ok := obj.GetLabels()[r.queryLabel]
-	if !ok || len(queryValue) == 0 {
-		return ctrl.Result{}, apierrors.NewBadRequest(fmt.Sprintf("%s label is required", r.queryLabel))
+		return *res, err
 	}
 
-	namespace := req.Namespace
-	resources, err := r.crdService.GetAllResourcesForService(ctx, r.queryLabel, queryValue, namespace)
+	destination, err := r.merger.GetDestination(ctx, destinationResourceName, namespace)
 	if err != nil {
-		r.log.Error(err, "failed to get all resources for service")
-		if errors.Is(err, e.ErrReconcile) {
-			return ctrl.Result{Requeue: err.(*e.ReconcileError).ShouldRequeue}, err
+		r.log.Error(err, "failed to get the destination")
+	}
+
+	for _, obj := range resources {
+		err = r.crdService.UpdateFinalizer(ctx, obj)
+		if err != nil {
+			r.log.Error(err, "failed to update the finalizer", "resourceName", obj.GetName())
+
+			return ctrl.Result{Requeue: true}, err
 		}
 
-		return ctrl.Result{Requeue: true}, nil
+		if r.crdService.IsDeleted(obj) {
+			continue
+		}
+
+		original := obj.DeepCopyObject().(client.Object)
+		r.setMergeConditions(obj, destinationResourceName, namespace, nil, nil)
+		r.setDetectedConflict(obj, conflicts)
+		r.setSyncedCondition(obj, destination)
+		r.recordMergeEvent(obj, original, destinationResourceName, nil, nil)
+
+		if r.crdService.IsChanged(obj) {
+			r.crdService.SetContentSHA(obj, r.crdService.GetContentSHA(obj))
+			r.crdService.SetUpdatedAt(obj, time.Now().String())
+		}
+
+		err = r.updateStatusIfChanged(ctx, obj, original)
+		if err != nil {
+			return ctrl.Result{Requeue: true}, err
+		}
 	}
 
-	res, err = r.merger.Merge(ctx, queryValue, namespace, resources)
+	return ctrl.Result{}, nil
+}
+
+// reconcileUnlabeledResource reports a resource without the label which selects its destination as invalid.
+// The finalizer of such a resource is removed when it is deleted.
+func (r *reconciler) reconcileUnlabeledResource(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	obj, res, err := r.crdService.GetResourceFromRequest(ctx, getUnlabeledResourceRequest(req))
 	if err != nil || res != nil {
 		return *res, err
 	}
 
-	err = r.crdService.UpdateFinalizer(ctx, obj)
-	if err != nil {
-		r.log.Error(err, "failed to update the finalizer")
+	r.log = r.log.WithValues("resourceName", obj.GetName())
 
-		return ctrl.Result{Requeue: true}, err
-	}
+	if queryValue, ok := obj.GetLabels()[r.queryLabel]; ok && len(queryValue) > 0 {
+		r.log.Info("the resource has a destination label, it is reconciled with its destination")
 
-	if !isDeleted && isChanged {
-		r.crdService.SetContentSHA(obj, r.crdService.GetContentSHA(obj))
-		r.crdService.SetUpdatedAt(obj, time.Now().String())
+		return ctrl.Result{}, nil
+	}
 
-		r.log.Info("updating the status of the consul service route")
-		err = r.statusClient.Status().Update(ctx, obj)
+	if r.crdService.IsDeleted(obj) {
+		err = r.crdService.UpdateFinalizer(ctx, obj)
 		if err != nil {
-			r.log.Error(err, "failed to update the status of the consul service route")
+			r.log.Error(err, "failed to update the finalizer")
 
 			return ctrl.Result{Requeue: true}, err
 		}
 
-		r.log.Info("successfully updated the status of the consul service route")
+		return ctrl.Result{}, nil
+	}
+
+	message := fmt.Sprintf("%s label is required", r.queryLabel)
+	r.log.Info(message)
+
+	original := obj.DeepCopyObject()
+	r.setCondition(obj, servicev1alpha1.ConditionTypeInvalid, metav1.ConditionTrue, servicev1alpha1.ConditionReasonMissingLabel, message)
+	r.setCondition(obj, servicev1alpha1.ConditionTypeMerged, metav1.ConditionFalse, servicev1alpha1.ConditionReasonMissingLabel, message)
+	r.crdService.SetObservedGeneration(obj, obj.GetGeneration())
+
+	err = r.updateStatusIfChanged(ctx, obj, original)
+	if err != nil {
+		return ctrl.Result{Requeue: true}, err
 	}
 
 	return ctrl.Result{}, nil
 }
 
-// NewReconciler ...
+// setMergeConditions sets the conditions and the destination of the resource from the outcome of the merge.
+// A res or err which is not nil means that the merge has failed.
+func (r *reconciler) setMergeConditions(obj client.Object, destinationResourceName, namespace string, res *ctrl.Result, err error) {
+	r.crdService.SetObservedGeneration(obj, obj.GetGeneration())
+	r.crdService.SetDestination(obj, r.merger.GetDestinationReference(destinationResourceName, namespace))
+
+	conflictErr := new(e.ConflictError)
+	reconcileErr := new(e.ReconcileError)
+	unmanagedErr := new(e.UnmanagedDestinationError)
+	switch {
+	case err == nil && res == nil:
+		message := fmt.Sprintf("merged into %s", destinationResourceName)
+		r.setCondition(obj, servicev1alpha1.ConditionTypeMerged, metav1.ConditionTrue, servicev1alpha1.ConditionReasonMerged, message)
+		r.setCondition(obj, servicev1alpha1.ConditionTypeInvalid, metav1.ConditionFalse, servicev1alpha1.ConditionReasonValid, "")
+		r.clearMergeConflictCondition(obj)
+	case err == nil:
+		message := "the merge will be retried"
+		r.setCondition(obj, servicev1alpha1.ConditionTypeMerged, metav1.ConditionFalse, servicev1alpha1.ConditionReasonMergeFailed, message)
+	case errors.As(err, &conflictErr):
+		r.setCondition(obj, servicev1alpha1.ConditionTypeMerged, metav1.ConditionFalse, servicev1alpha1.ConditionReasonMergeConflict, err.Error())
+		r.setCondition(obj, servicev1alpha1.ConditionTypeInvalid, metav1.ConditionFalse, servicev1alpha1.ConditionReasonValid, "")
+		if conflictErr.Contains(obj.GetName()) {
+			r.setCondition(obj, servicev1alpha1.ConditionTypeConflict, metav1.ConditionTrue, servicev1alpha1.ConditionReasonMergeConflict, err.Error())
+		} else {
+			r.clearMergeConflictCondition(obj)
+		}
+	case errors.As(err, &unmanagedErr):
+		r.setCondition(obj, servicev1alpha1.ConditionTypeMerged, metav1.ConditionFalse, servicev1alpha1.ConditionReasonUnmanagedDestination, err.Error())
+	case errors.As(err, &reconcileErr) && !reconcileErr.ShouldRequeue:
+		r.setCondition(obj, servicev1alpha1.ConditionTypeMerged, metav1.ConditionFalse, servicev1alpha1.ConditionReasonInvalidDefinition, err.Error())
+		r.setCondition(obj, servicev1alpha1.ConditionTypeInvalid, metav1.ConditionTrue, servicev1alpha1.ConditionReasonInvalidDefinition, err.Error())
+		r.clearMergeConflictCondition(obj)
+	default:
+		r.setCondition(obj, servicev1alpha1.ConditionTypeMerged, metav1.ConditionFalse, servicev1alpha1.ConditionReasonMergeFailed, err.Error())
+	}
+}
+
+// recordMergeEvent emits an event on the resource with the outcome of the merge when the outcome has changed.
+func (r *reconciler) recordMergeEvent(obj client.Object, original client.Object, destinationResourceName string, res *ctrl.Result, err error) {
+	if !r.isMergeOutcomeChanged(obj, original) {
+		return
+	}
+
+	conflictErr := new(e.ConflictError)
+	switch {
+	case err == nil && res == nil:
+		r.recorder.Event(obj, corev1.EventTypeNormal, events.ReasonMerged, fmt.Sprintf("merged into %s", destinationResourceName))
+	case err == nil:
+		r.recorder.Event(obj, corev1.EventTypeWarning, events.ReasonMergeFailed, "the merge will be retried")
+	case errors.As(err, &conflictErr) && conflictErr.Contains(obj.GetName()):
+		r.recorder.Event(obj, corev1.EventTypeWarning, events.ReasonConflict, err.Error())
+	default:
+		r.recorder.Event(obj, corev1.EventTypeWarning, events.ReasonMergeFailed, err.Error())
+	}
+}
+
+// isMergeOutcomeChanged checks if the Merged condition of the resource was changed by the reconcile.
+// Resources without conditions are checked for changes of their content since their last merge instead.
+func (r *reconciler) isMergeOutcomeChanged(obj client.Object, original client.Object) bool {
+	conditions := r.crdService.GetConditions(obj)
+	if conditions == nil {
+		return r.crdService.IsChanged(original)
+	}
+
+	merged := meta.FindStatusCondition(*conditions, servicev1alpha1.ConditionTypeMerged)
+	previous := meta.FindStatusCondition(*r.crdService.GetConditions(original), servicev1alpha1.ConditionTypeMerged)
+	if merged == nil || previous == nil {
+		return merged != previous
+	}
+
+	return merged.Status != previous.Status || merged.Reason != previous.Reason ||
+		merged.Message != previous.Message || merged.ObservedGeneration != previous.ObservedGeneration
+}
+
+// clearMergeConflictCondition sets the conflict condition to false unless it is set to true
+// with a reason other than a merge conflict, in which case it is managed by setDetectedConflict.
+func (r *reconciler) clearMergeConflictCondition(obj client.Object) {
+	conditions := r.crdService.GetConditions(obj)
+	if conditions == nil {
+		return
+	}
+
+	conflict := meta.FindStatusCondition(*conditions, servicev1alpha1.ConditionTypeConflict)
+	if conflict != nil && conflict.Status == metav1.ConditionTrue && conflict.Reason != servicev1alpha1.ConditionReasonMergeConflict {
+		return
+	}
+
+	r.setCondition(obj, servicev1alpha1.ConditionTypeConflict, metav1.ConditionFalse, servicev1alpha1.ConditionReasonNoConflict, "")
+}
+
+// setDetectedConflict sets the conflict condition of the resource from the conflicts found by findConflicts.
+// A conflict reported by the merge takes precedence. A warning event is emitted when the conflict is new or changed.
+func (r *reconciler) setDetectedConflict(obj client.Object, conflicts map[string]services.Conflict) {
+	conditions := r.crdService.GetConditions(obj)
+	if r.findConflicts == nil || conditions == nil {
+		return
+	}
+
+	existing := meta.FindStatusCondition(*conditions, servicev1alpha1.ConditionTypeConflict)
+	if existing != nil && existing.Status == metav1.ConditionTrue && existing.Reason == servicev1alpha1.ConditionReasonMergeConflict {
+		return
+	}
+
+	conflict, ok := conflicts[obj.GetName()]
+	if !ok {
+		if existing != nil && existing.Status == metav1.ConditionTrue {
+			r.setCondition(obj, servicev1alpha1.ConditionTypeConflict, metav1.ConditionFalse, servicev1alpha1.ConditionReasonNoConflict, "")
+		}
+
+		return
+	}
+
+	if existing == nil || existing.Status != metav1.ConditionTrue || existing.Reason != conflict.Reason || existing.Message != conflict.Message {
+		r.recorder.Event(obj, corev1.EventTypeWarning, conflict.Reason, conflict.Message)
+	}
+
+	r.setCondition(obj, servicev1alpha1.ConditionTypeConflict, metav1.ConditionTrue, conflict.Reason, conflict.Message)
+}
+
+// setSyncedCondition copies the Synced condition of the destination which is set by consul-k8s.
+func (r *reconciler) setSyncedCondition(obj client.Object, destination client.Object) {
+	status, reason, message := corev1.ConditionUnknown, "", ""
+	if destination, ok := destination.(syncedConditionGetter); ok {
+		status, reason, message = destination.SyncedCondition()
+	}
+
+	if len(reason) == 0 {
+		reason = servicev1alpha1.ConditionReasonSyncPending
+	}
+
+	r.setCondition(obj, servicev1alpha1.ConditionTypeSynced, metav1.ConditionStatus(status), reason, message)
+}
+
+func (r *reconciler) setCondition(obj client.Object, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	conditions := r.crdService.GetConditions(obj)
+	if conditions == nil {
+		return
+	}
+
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: obj.GetGeneration(),
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func (r *reconciler) updateStatusIfChanged(ctx context.Context, obj client.Object, original runtime.Object) error {
+	if reflect.DeepEqual(original, obj) {
+		return nil
+	}
+
+	r.log.Info("updating the status of the resource", "resourceName", obj.GetName())
+	err := r.statusClient.Status().Update(ctx, obj)
+	if err != nil {
+		r.log.Error(err, "failed to update the status of the resource", "resourceName", obj.GetName())
+
+		return err
+	}
+
+	r.log.Info("successfully updated the status of the resource", "resourceName", obj.GetName())
+
+	return nil
+}
+
+// NewReconciler creates a reconciler which merges the resources selected by queryLabel into their destination.
+// When findConflicts is not nil the conflicts which it finds are set in the Conflict condition of the resources.
 func NewReconciler(
 	statusClient client.StatusClient,
 	crdService services.CRDService,
 	merger services.Merger,
 	log logr.Logger,
+	recorder record.EventRecorder,
 	queryLabel string,
+	findConflicts services.FindConflictsFunc,
 ) Reconciler {
 	r := new(reconciler)
 	r.statusClient = statusClient
 	r.crdService = crdService
 	r.merger = merger
 	r.log = log
+	r.recorder = recorder
 	r.queryLabel = queryLabel
+	r.findConflicts = findConflicts
 
 	return r
 }
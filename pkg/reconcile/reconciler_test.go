@@ -0,0 +1,385 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile_test
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/adoption"
+	"github.com/NativeChat/consul-merge-controller/pkg/finalizers"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/reconcile"
+	"github.com/NativeChat/consul-merge-controller/pkg/routes"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+)
+
+const serviceRouterName = "service-a"
+
+// routeExpectation describes the expected state of a route after the reconcile.
+type routeExpectation struct {
+	conditionType string
+	status        metav1.ConditionStatus
+	reason        string
+	finalizer     bool
+}
+
+func newRoute(name, serviceRouter, pathPrefix string) *v1alpha1.ConsulServiceRoute {
+	route := &v1alpha1.ConsulServiceRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{},
+		},
+		Spec: v1alpha1.ConsulServiceRouteSpec{
+			Route: consulk8s.ServiceRoute{
+				Match:       &consulk8s.ServiceRouteMatch{HTTP: &consulk8s.ServiceRouteHTTPMatch{PathPrefix: pathPrefix}},
+				Destination: &consulk8s.ServiceRouteDestination{Service: name},
+			},
+		},
+	}
+
+	if len(serviceRouter) > 0 {
+		route.Labels[controllerlabels.ServiceRouter] = serviceRouter
+	}
+
+	return route
+}
+
+func newDeletedRoute(name, serviceRouter, pathPrefix string) *v1alpha1.ConsulServiceRoute {
+	route := newRoute(name, serviceRouter, pathPrefix)
+	deletionTimestamp := metav1.Now()
+	route.SetDeletionTimestamp(&deletionTimestamp)
+	route.SetFinalizers([]string{finalizers.FinalizerName})
+
+	return route
+}
+
+func drainEvents(recorder *record.FakeRecorder) []string {
+	events := []string{}
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func newFakeClient(objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	Expect(consulk8s.AddToScheme(scheme)).To(Succeed())
+	Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func getPathPrefixes(serviceRouter *consulk8s.ServiceRouter) []string {
+	pathPrefixes := []string{}
+	for _, route := range serviceRouter.Spec.Routes {
+		pathPrefixes = append(pathPrefixes, route.Match.HTTP.PathPrefix)
+	}
+
+	return pathPrefixes
+}
+
+var _ = Describe("Reconciler", func() {
+	table.DescribeTable("Reconcile",
+		func(routeList []*v1alpha1.ConsulServiceRoute, changedRoute string, expected map[string]routeExpectation, expectedPathPrefixes []string) {
+			ctx := context.Background()
+
+			objs := []client.Object{}
+			for _, route := range routeList {
+				objs = append(objs, route)
+			}
+
+			k8sClient := newFakeClient(objs...)
+			recorder := record.NewFakeRecorder(100)
+
+			crdService := services.NewCRDService(
+				k8sClient,
+				k8sClient,
+				logr.Discard(),
+				finalizers.FinalizerName,
+				reflect.TypeOf(v1alpha1.ConsulServiceRoute{}),
+				reflect.TypeOf(v1alpha1.ConsulServiceRouteList{}),
+			)
+
+			merger := services.NewMerger(
+				k8sClient,
+				k8sClient,
+				logr.Discard(),
+				recorder,
+				nil,
+				nil,
+				routes.NewSortItemsFunc(routes.OrderingPriority),
+				adoption.ModeAdopt,
+				"Routes",
+				"Route",
+				reflect.TypeOf(consulk8s.ServiceRouter{}),
+			)
+
+			reconciler := reconcile.NewReconciler(k8sClient, crdService, merger, logr.Discard(), recorder, controllerlabels.ServiceRouter, routes.NewFindConflictsFunc(routes.OrderingPriority))
+
+			changed := &v1alpha1.ConsulServiceRoute{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: changedRoute}, changed)).To(Succeed())
+
+			for _, req := range reconcile.NewSourceMapFunc(crdService, controllerlabels.ServiceRouter)(changed) {
+				_, err := reconciler.Reconcile(ctx, req)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			for name, expectation := range expected {
+				route := &v1alpha1.ConsulServiceRoute{}
+				err := k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, route)
+				if errors.IsNotFound(err) {
+					Expect(expectation.finalizer).To(BeFalse(), "route %s was deleted", name)
+
+					continue
+				}
+
+				Expect(err).NotTo(HaveOccurred())
+				if expectation.finalizer {
+					Expect(route.Finalizers).To(ConsistOf(finalizers.FinalizerName), "finalizers of route %s", name)
+				} else {
+					Expect(route.Finalizers).To(BeEmpty(), "finalizers of route %s", name)
+				}
+
+				if len(expectation.conditionType) == 0 {
+					continue
+				}
+
+				condition := meta.FindStatusCondition(route.Status.Conditions, expectation.conditionType)
+				Expect(condition).NotTo(BeNil(), "%s condition of route %s", expectation.conditionType, name)
+				Expect(condition.Status).To(Equal(expectation.status), "%s condition of route %s", expectation.conditionType, name)
+				Expect(condition.Reason).To(Equal(expectation.reason), "%s condition of route %s", expectation.conditionType, name)
+			}
+
+			serviceRouter := &consulk8s.ServiceRouter{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: serviceRouterName}, serviceRouter)
+			if expectedPathPrefixes == nil {
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+
+				return
+			}
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(getPathPrefixes(serviceRouter)).To(Equal(expectedPathPrefixes))
+		},
+		table.Entry("merges the labeled routes into their destination",
+			[]*v1alpha1.ConsulServiceRoute{
+				newRoute("service-a-v1", serviceRouterName, "/v1"),
+				newRoute("service-a-v2", serviceRouterName, "/v2"),
+			},
+			"service-a-v1",
+			map[string]routeExpectation{
+				"service-a-v1": {v1alpha1.ConditionTypeMerged, metav1.ConditionTrue, v1alpha1.ConditionReasonMerged, true},
+				"service-a-v2": {v1alpha1.ConditionTypeSynced, metav1.ConditionUnknown, v1alpha1.ConditionReasonSyncPending, true},
+			},
+			[]string{"/v1", "/v2"},
+		),
+		table.Entry("reports a route without the destination label as invalid",
+			[]*v1alpha1.ConsulServiceRoute{
+				newRoute("service-a-v1", "", "/v1"),
+			},
+			"service-a-v1",
+			map[string]routeExpectation{
+				"service-a-v1": {v1alpha1.ConditionTypeInvalid, metav1.ConditionTrue, v1alpha1.ConditionReasonMissingLabel, false},
+			},
+			nil,
+		),
+		table.Entry("removes the finalizer of a deleted route and excludes it from the destination",
+			[]*v1alpha1.ConsulServiceRoute{
+				newRoute("service-a-v1", serviceRouterName, "/v1"),
+				newDeletedRoute("service-a-v2", serviceRouterName, "/v2"),
+			},
+			"service-a-v2",
+			map[string]routeExpectation{
+				"service-a-v1": {v1alpha1.ConditionTypeMerged, metav1.ConditionTrue, v1alpha1.ConditionReasonMerged, true},
+				"service-a-v2": {"", "", "", false},
+			},
+			[]string{"/v1"},
+		),
+		table.Entry("reports a duplicated route as conflicting",
+			[]*v1alpha1.ConsulServiceRoute{
+				newRoute("service-a-v1", serviceRouterName, "/v1"),
+				newRoute("service-a-v2", serviceRouterName, "/v1"),
+			},
+			"service-a-v1",
+			map[string]routeExpectation{
+				"service-a-v1": {v1alpha1.ConditionTypeConflict, metav1.ConditionFalse, v1alpha1.ConditionReasonNoConflict, true},
+				"service-a-v2": {v1alpha1.ConditionTypeConflict, metav1.ConditionTrue, routes.ConflictReasonDuplicateMatch, true},
+			},
+			[]string{"/v1", "/v1"},
+		),
+		table.Entry("doesn't create a destination when its only route is deleted",
+			[]*v1alpha1.ConsulServiceRoute{
+				newDeletedRoute("service-a-v1", serviceRouterName, "/v1"),
+			},
+			"service-a-v1",
+			map[string]routeExpectation{
+				"service-a-v1": {"", "", "", false},
+			},
+			nil,
+		),
+	)
+
+	It("sets the conflicts of the routes in the same status update as their merge conditions", func() {
+		ctx := context.Background()
+
+		resolved := newRoute("service-a-v1", serviceRouterName, "/v1")
+		resolved.Status.Conditions = []metav1.Condition{{
+			Type:   v1alpha1.ConditionTypeConflict,
+			Status: metav1.ConditionTrue,
+			Reason: routes.ConflictReasonShadowedMatch,
+		}}
+
+		k8sClient := newFakeClient(resolved, newRoute("service-a-v2", serviceRouterName, "/v2"), newRoute("service-a-v3", serviceRouterName, "/v2/api"))
+		recorder := record.NewFakeRecorder(100)
+		crdService := services.NewCRDService(
+			k8sClient,
+			k8sClient,
+			logr.Discard(),
+			finalizers.FinalizerName,
+			reflect.TypeOf(v1alpha1.ConsulServiceRoute{}),
+			reflect.TypeOf(v1alpha1.ConsulServiceRouteList{}),
+		)
+		merger := services.NewMerger(k8sClient, k8sClient, logr.Discard(), recorder, nil, nil, routes.NewSortItemsFunc(routes.OrderingPriority),
+			adoption.ModeOverwrite, "Routes", "Route", reflect.TypeOf(consulk8s.ServiceRouter{}))
+		reconciler := reconcile.NewReconciler(k8sClient, crdService, merger, logr.Discard(), recorder, controllerlabels.ServiceRouter,
+			routes.NewFindConflictsFunc(routes.OrderingPriority))
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: serviceRouterName}})
+		Expect(err).NotTo(HaveOccurred())
+
+		expected := map[string]metav1.ConditionStatus{"service-a-v1": metav1.ConditionFalse, "service-a-v2": metav1.ConditionFalse, "service-a-v3": metav1.ConditionTrue}
+		for name, conflictStatus := range expected {
+			route := &v1alpha1.ConsulServiceRoute{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, route)).To(Succeed())
+
+			Expect(meta.IsStatusConditionTrue(route.Status.Conditions, v1alpha1.ConditionTypeMerged)).To(BeTrue(), "Merged condition of route %s", name)
+			Expect(meta.FindStatusCondition(route.Status.Conditions, v1alpha1.ConditionTypeSynced)).NotTo(BeNil(), "Synced condition of route %s", name)
+			conflict := meta.FindStatusCondition(route.Status.Conditions, v1alpha1.ConditionTypeConflict)
+			Expect(conflict).NotTo(BeNil(), "Conflict condition of route %s", name)
+			Expect(conflict.Status).To(Equal(conflictStatus), "Conflict condition of route %s", name)
+		}
+
+		Expect(drainEvents(recorder)).To(ContainElement(HavePrefix("Warning " + routes.ConflictReasonShadowedMatch)))
+	})
+
+	Context("unlabeled requests", func() {
+		var ctx context.Context
+
+		reconcileUnlabeled := func(objs ...client.Object) client.Client {
+			k8sClient := newFakeClient(objs...)
+			recorder := record.NewFakeRecorder(100)
+			crdService := services.NewCRDService(
+				k8sClient,
+				k8sClient,
+				logr.Discard(),
+				finalizers.FinalizerName,
+				reflect.TypeOf(v1alpha1.ConsulServiceRoute{}),
+				reflect.TypeOf(v1alpha1.ConsulServiceRouteList{}),
+			)
+			merger := services.NewMerger(k8sClient, k8sClient, logr.Discard(), recorder, nil, nil, nil,
+				adoption.ModeOverwrite, "Routes", "Route", reflect.TypeOf(consulk8s.ServiceRouter{}))
+			reconciler := reconcile.NewReconciler(k8sClient, crdService, merger, logr.Discard(), recorder, controllerlabels.ServiceRouter, nil)
+
+			res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "unlabeled:service-a-v1"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(ctrl.Result{}))
+
+			return k8sClient
+		}
+
+		getRoute := func(k8sClient client.Client) *v1alpha1.ConsulServiceRoute {
+			route := &v1alpha1.ConsulServiceRoute{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "service-a-v1"}, route)).To(Succeed())
+
+			return route
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+		})
+
+		It("sets the status of a resource without the destination label", func() {
+			route := newRoute("service-a-v1", "", "/v1")
+			route.Generation = 2
+
+			k8sClient := reconcileUnlabeled(route)
+
+			route = getRoute(k8sClient)
+			Expect(route.Status.ObservedGeneration).To(BeNumerically("==", 2))
+			Expect(route.Finalizers).To(BeEmpty())
+
+			invalid := meta.FindStatusCondition(route.Status.Conditions, v1alpha1.ConditionTypeInvalid)
+			Expect(invalid).NotTo(BeNil())
+			Expect(invalid.Status).To(Equal(metav1.ConditionTrue))
+			Expect(invalid.Reason).To(Equal(v1alpha1.ConditionReasonMissingLabel))
+			Expect(invalid.Message).To(ContainSubstring(controllerlabels.ServiceRouter))
+
+			merged := meta.FindStatusCondition(route.Status.Conditions, v1alpha1.ConditionTypeMerged)
+			Expect(merged).NotTo(BeNil())
+			Expect(merged.Status).To(Equal(metav1.ConditionFalse))
+			Expect(merged.Reason).To(Equal(v1alpha1.ConditionReasonMissingLabel))
+
+			serviceRouters := &consulk8s.ServiceRouterList{}
+			Expect(k8sClient.List(ctx, serviceRouters)).To(Succeed())
+			Expect(serviceRouters.Items).To(BeEmpty())
+		})
+
+		It("doesn't change a resource which was labeled after the request was queued", func() {
+			k8sClient := reconcileUnlabeled(newRoute("service-a-v1", serviceRouterName, "/v1"))
+
+			Expect(getRoute(k8sClient).Status.Conditions).To(BeEmpty())
+		})
+
+		It("removes the finalizer of a deleted resource without the destination label", func() {
+			k8sClient := reconcileUnlabeled(newDeletedRoute("service-a-v1", "", "/v1"))
+
+			route := &v1alpha1.ConsulServiceRoute{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "service-a-v1"}, route)
+			if err == nil {
+				Expect(route.Finalizers).To(BeEmpty())
+			} else {
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			}
+		})
+
+		It("ignores a request for a resource which doesn't exist", func() {
+			reconcileUnlabeled()
+		})
+	})
+})
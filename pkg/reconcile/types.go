@@ -19,6 +19,7 @@ package reconcile
 import (
 	"context"
 
+	corev1 "k8s.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
@@ -26,3 +27,8 @@ import (
 type Reconciler interface {
 	Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error)
 }
+
+// syncedConditionGetter is implemented by the consul-k8s config entries.
+type syncedConditionGetter interface {
+	SyncedCondition() (status corev1.ConditionStatus, reason, message string)
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/adoption"
+	"github.com/NativeChat/consul-merge-controller/pkg/annotations"
+	e "github.com/NativeChat/consul-merge-controller/pkg/errors"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	"github.com/NativeChat/consul-merge-controller/pkg/strategies"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(consulk8s.AddToScheme(scheme))
+	utilruntime.Must(servicev1alpha1.AddToScheme(scheme))
+}
+
+// Conflict describes resources which can't be merged into the same destination as they are.
+type Conflict struct {
+	// Destination is the kind, the namespace and the name of the destination.
+	Destination string
+
+	// Items are the names of the conflicting resources.
+	Items []string
+
+	Message string
+}
+
+// Result contains the destinations which the controller would produce from the rendered resources.
+type Result struct {
+	// Destinations are sorted by kind, namespace and name.
+	Destinations []client.Object
+
+	Conflicts []Conflict
+
+	// Unlabeled are the resources without a destination label, which are not merged.
+	Unlabeled []string
+}
+
+// Renderer merges the resources of the kinds returned by strategies.GetMergeKinds into their destinations without a cluster.
+type Renderer interface {
+	Render(ctx context.Context, objs []client.Object) (*Result, error)
+}
+
+type renderer struct {
+	log       logr.Logger
+	namespace string
+	options   strategies.MergeOptions
+}
+
+func (r *renderer) Render(ctx context.Context, objs []client.Object) (*Result, error) {
+	result := &Result{}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	for _, obj := range objs {
+		obj = obj.DeepCopyObject().(client.Object)
+		if len(obj.GetNamespace()) == 0 {
+			obj.SetNamespace(r.namespace)
+		}
+
+		err := c.Create(ctx, obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s %s: %w", reflect.TypeOf(obj).Elem().Name(), client.ObjectKeyFromObject(obj), err)
+		}
+	}
+
+	for _, kind := range strategies.GetMergeKinds() {
+		err := r.renderKind(ctx, c, kind, result)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (r *renderer) renderKind(ctx context.Context, c client.Client, kind strategies.MergeKind, result *Result) error {
+	list := reflect.New(kind.ResourceListType).Interface().(client.ObjectList)
+	err := c.List(ctx, list)
+	if err != nil {
+		return err
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+
+	destinations := map[types.NamespacedName]bool{}
+	for _, item := range items {
+		obj := item.(client.Object)
+
+		name := obj.GetLabels()[kind.QueryLabel]
+		if len(name) == 0 {
+			result.Unlabeled = append(result.Unlabeled, fmt.Sprintf("%s %s", kind.ResourceType.Name(), client.ObjectKeyFromObject(obj)))
+
+			continue
+		}
+
+		destinations[types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}] = true
+	}
+
+	keys := []types.NamespacedName{}
+	for key := range destinations {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	crdService := kind.NewCRDService(c, c, r.log)
+	for _, key := range keys {
+		err = r.renderDestination(ctx, c, crdService, kind, key, result)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *renderer) renderDestination(ctx context.Context, c client.Client, crdService services.CRDService, kind strategies.MergeKind, key types.NamespacedName, result *Result) error {
+	resources, err := crdService.GetAllResourcesForService(ctx, kind.QueryLabel, key.Name, key.Namespace)
+	if err != nil {
+		return err
+	}
+
+	merger := kind.NewMerger(c, c, r.log, &record.FakeRecorder{}, r.options)
+	destinationName := fmt.Sprintf("%s %s", merger.GetDestinationReference(key.Name, key.Namespace).Kind, key)
+
+	res, err := merger.Merge(ctx, key.Name, key.Namespace, resources)
+	if err != nil {
+		conflictErr := new(e.ConflictError)
+		if errors.As(err, &conflictErr) {
+			result.Conflicts = append(result.Conflicts, Conflict{Destination: destinationName, Items: conflictErr.Items, Message: err.Error()})
+
+			return nil
+		}
+
+		return fmt.Errorf("failed to merge %s: %w", destinationName, err)
+	}
+
+	// The merger requests a requeue instead of returning some of the client errors.
+	if res != nil {
+		return fmt.Errorf("failed to merge %s", destinationName)
+	}
+
+	// The conflicts are found the same way as for the Conflict condition of the resources.
+	findConflicts := kind.NewFindConflictsFunc(r.options)
+	if findConflicts != nil {
+		conflicts := findConflicts(resources)
+		for _, resource := range resources {
+			conflict, ok := conflicts[resource.GetName()]
+			if ok {
+				result.Conflicts = append(result.Conflicts, Conflict{
+					Destination: destinationName,
+					Items:       []string{conflict.ConflictsWith, resource.GetName()},
+					Message:     fmt.Sprintf("%s: %s", resource.GetName(), conflict.Message),
+				})
+			}
+		}
+	}
+
+	destination, err := merger.GetDestination(ctx, key.Name, key.Namespace)
+	if err != nil {
+		return err
+	}
+
+	if destination == nil {
+		return nil
+	}
+
+	gvk, err := apiutil.GVKForObject(destination, scheme)
+	if err != nil {
+		return err
+	}
+
+	destination.GetObjectKind().SetGroupVersionKind(gvk)
+	clearServerFields(destination)
+	clearControllerMetadata(destination)
+	result.Destinations = append(result.Destinations, destination)
+
+	return nil
+}
+
+// clearServerFields removes the metadata which is set by the API server or refers to resources in the cluster.
+func clearServerFields(obj client.Object) {
+	obj.SetUID("")
+	obj.SetResourceVersion("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetManagedFields(nil)
+	obj.SetOwnerReferences(nil)
+}
+
+// clearControllerMetadata removes the labels and the annotations which the controller uses to track the destinations
+// it writes. They describe the state of a destination in a cluster, e.g. the entries which existed before it was adopted,
+// and aren't part of the merged resource, so a rendered destination applied with kubectl is adopted like any other.
+func clearControllerMetadata(obj client.Object) {
+	labels := obj.GetLabels()
+	delete(labels, controllerlabels.ManagedBy)
+	if len(labels) == 0 {
+		labels = nil
+	}
+
+	obj.SetLabels(labels)
+
+	objAnnotations := obj.GetAnnotations()
+	for _, annotation := range []string{annotations.LastAppliedSpecSHA, annotations.RevertedDrifts, annotations.UnmanagedEntries} {
+		delete(objAnnotations, annotation)
+	}
+
+	if len(objAnnotations) == 0 {
+		objAnnotations = nil
+	}
+
+	obj.SetAnnotations(objAnnotations)
+}
+
+// NewSourceResources returns an empty resource of each kind which is merged by the Renderer, e.g. to decode them.
+func NewSourceResources() []client.Object {
+	objs := []client.Object{}
+	for _, kind := range strategies.GetMergeKinds() {
+		objs = append(objs, kind.NewResource())
+	}
+
+	return objs
+}
+
+// NewRenderer creates new Renderer. The resources without a namespace are rendered in the given namespace.
+func NewRenderer(log logr.Logger, namespace, routeOrdering string, excludeConflictingRoutes bool) Renderer {
+	r := &renderer{
+		log:       log,
+		namespace: namespace,
+		options: strategies.MergeOptions{
+			AdoptionMode:             adoption.ModeOverwrite,
+			RouteOrdering:            routeOrdering,
+			ExcludeConflictingRoutes: excludeConflictingRoutes,
+		},
+	}
+
+	return r
+}
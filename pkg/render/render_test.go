@@ -0,0 +1,192 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render_test
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/render"
+	"github.com/NativeChat/consul-merge-controller/pkg/routes"
+)
+
+func newRoute(name, serviceRouter, pathPrefix string, priority int32) *v1alpha1.ConsulServiceRoute {
+	route := &v1alpha1.ConsulServiceRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{controllerlabels.ServiceRouter: serviceRouter},
+		},
+		Spec: v1alpha1.ConsulServiceRouteSpec{
+			Route: consulk8s.ServiceRoute{
+				Match:       &consulk8s.ServiceRouteMatch{HTTP: &consulk8s.ServiceRouteHTTPMatch{PathPrefix: pathPrefix}},
+				Destination: &consulk8s.ServiceRouteDestination{Service: name},
+			},
+			Priority: priority,
+		},
+	}
+
+	return route
+}
+
+func newSplit(name, serviceSplitter string, weight float32, isDefault bool) *v1alpha1.ConsulServiceSplit {
+	split := &v1alpha1.ConsulServiceSplit{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{controllerlabels.ServiceSplitter: serviceSplitter},
+		},
+		Spec: v1alpha1.ConsulServiceSplitSpec{
+			Split:   consulk8s.ServiceSplit{Weight: weight, ServiceSubset: name},
+			Default: isDefault,
+		},
+	}
+
+	return split
+}
+
+func getPathPrefixes(obj client.Object) []string {
+	pathPrefixes := []string{}
+	for _, route := range obj.(*consulk8s.ServiceRouter).Spec.Routes {
+		pathPrefixes = append(pathPrefixes, route.Match.HTTP.PathPrefix)
+	}
+
+	return pathPrefixes
+}
+
+func getNames(objs []client.Object) []string {
+	names := []string{}
+	for _, obj := range objs {
+		names = append(names, strings.Join([]string{obj.GetObjectKind().GroupVersionKind().Kind, obj.GetNamespace(), obj.GetName()}, " "))
+	}
+
+	return names
+}
+
+var _ = Describe("Renderer", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("renders the destinations of all merge kinds sorted by kind, namespace and name", func() {
+		routeInOtherNamespace := newRoute("service-b-v1", "service-b", "/v1", 0)
+		routeInOtherNamespace.Namespace = "other"
+
+		objs := []client.Object{
+			newRoute("service-b-v1", "service-b", "/v1", 0),
+			routeInOtherNamespace,
+			newRoute("service-a-v1", "service-a", "/v1", 0),
+			newSplit("service-a-v1", "service-a", 10, false),
+			newSplit("service-a-v2", "service-a", 0, true),
+		}
+
+		result, err := render.NewRenderer(logr.Discard(), "default", routes.OrderingPriority, false).Render(ctx, objs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Conflicts).To(BeEmpty())
+		Expect(result.Unlabeled).To(BeEmpty())
+		Expect(getNames(result.Destinations)).To(Equal([]string{
+			"ServiceRouter default service-a",
+			"ServiceRouter default service-b",
+			"ServiceRouter other service-b",
+			"ServiceSplitter default service-a",
+		}))
+
+		serviceSplitter := result.Destinations[3].(*consulk8s.ServiceSplitter)
+		Expect(serviceSplitter.Spec.Splits).To(HaveLen(2))
+		Expect(serviceSplitter.Spec.Splits[1].Weight).To(BeNumerically("==", 90))
+	})
+
+	It("merges the routes in the route ordering", func() {
+		objs := []client.Object{
+			newRoute("service-a-v1", "service-a", "/v1", 0),
+			newRoute("service-a-v1-api", "service-a", "/v1/api", 0),
+			newRoute("service-a-v2", "service-a", "/v2", 10),
+		}
+
+		result, err := render.NewRenderer(logr.Discard(), "default", routes.OrderingPriority, false).Render(ctx, objs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(getPathPrefixes(result.Destinations[0])).To(Equal([]string{"/v2", "/v1", "/v1/api"}))
+
+		result, err = render.NewRenderer(logr.Discard(), "default", routes.OrderingSpecificity, false).Render(ctx, objs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(getPathPrefixes(result.Destinations[0])).To(Equal([]string{"/v1/api", "/v2", "/v1"}))
+	})
+
+	It("doesn't render the metadata which the controller uses to track the destinations", func() {
+		route := newRoute("service-a-v1", "service-a", "/v1", 0)
+
+		result, err := render.NewRenderer(logr.Discard(), "default", routes.OrderingPriority, false).Render(ctx, []client.Object{route})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Destinations).To(HaveLen(1))
+		Expect(result.Destinations[0].GetLabels()).To(BeEmpty())
+		Expect(result.Destinations[0].GetAnnotations()).To(BeEmpty())
+		Expect(result.Destinations[0].GetFinalizers()).To(BeEmpty())
+		Expect(result.Destinations[0].GetResourceVersion()).To(BeEmpty())
+	})
+
+	It("reports the shadowed and duplicated routes as conflicts", func() {
+		objs := []client.Object{
+			newRoute("service-a-v1", "service-a", "/v1", 10),
+			newRoute("service-a-v1-api", "service-a", "/v1/api", 0),
+			newRoute("service-a-v1-copy", "service-a", "/v1", 0),
+		}
+
+		result, err := render.NewRenderer(logr.Discard(), "default", routes.OrderingPriority, false).Render(ctx, objs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Conflicts).To(HaveLen(2))
+		Expect(result.Conflicts[0].Destination).To(Equal("ServiceRouter default/service-a"))
+		Expect(result.Conflicts[0].Items).To(ConsistOf("service-a-v1", "service-a-v1-api"))
+		Expect(result.Conflicts[1].Items).To(ConsistOf("service-a-v1", "service-a-v1-copy"))
+		Expect(getPathPrefixes(result.Destinations[0])).To(Equal([]string{"/v1", "/v1/api", "/v1"}))
+
+		By("excluding the conflicting routes")
+		result, err = render.NewRenderer(logr.Discard(), "default", routes.OrderingPriority, true).Render(ctx, objs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Conflicts).To(HaveLen(2))
+		Expect(getPathPrefixes(result.Destinations[0])).To(Equal([]string{"/v1"}))
+	})
+
+	It("fails for resources which can't be merged", func() {
+		objs := []client.Object{
+			newSplit("service-a-v1", "service-a", 60, false),
+			newSplit("service-a-v2", "service-a", 60, false),
+		}
+
+		result, err := render.NewRenderer(logr.Discard(), "default", routes.OrderingPriority, false).Render(ctx, objs)
+		Expect(err).To(HaveOccurred())
+		Expect(result).To(BeNil())
+	})
+
+	It("reports the resources without a destination label", func() {
+		route := newRoute("service-a-v1", "service-a", "/v1", 0)
+		route.Labels = nil
+
+		result, err := render.NewRenderer(logr.Discard(), "default", routes.OrderingPriority, false).Render(ctx, []client.Object{route})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Destinations).To(BeEmpty())
+		Expect(result.Unlabeled).To(Equal([]string{"ConsulServiceRoute default/service-a-v1"}))
+	})
+})
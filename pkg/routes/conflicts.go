@@ -0,0 +1,237 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ConflictReasonDuplicateMatch is the reason for a route whose match is the same as the match of a route before it.
+	ConflictReasonDuplicateMatch = "DuplicateMatch"
+
+	// ConflictReasonShadowedMatch is the reason for a route whose requests are all matched by a route before it.
+	ConflictReasonShadowedMatch = "ShadowedMatch"
+)
+
+// FindConflicts finds the ConsulServiceRoute items which are duplicated or fully shadowed by an item before them.
+// The items must be sorted in the order of the routes in the service router.
+// Items which conflict with an item before them are not checked against the items after them.
+// The result is keyed by the name of the conflicting item.
+func FindConflicts(items []client.Object) map[string]services.Conflict {
+	conflicts := map[string]services.Conflict{}
+	accepted := []*servicev1alpha1.ConsulServiceRoute{}
+
+	for _, item := range items {
+		route := item.(*servicev1alpha1.ConsulServiceRoute)
+
+		for _, earlier := range accepted {
+			reason, found := findConflict(earlier.Spec.Route, route.Spec.Route)
+			if !found {
+				continue
+			}
+
+			conflicts[route.Name] = services.Conflict{
+				ConflictsWith: earlier.Name,
+				Reason:        reason,
+				Message:       fmt.Sprintf("the route never receives traffic because its match is %s by %s", conflictVerbs[reason], earlier.Name),
+			}
+
+			break
+		}
+
+		if _, ok := conflicts[route.Name]; !ok {
+			accepted = append(accepted, route)
+		}
+	}
+
+	return conflicts
+}
+
+// NewFindConflictsFunc returns a FindConflictsFunc which finds the conflicts of the routes
+// in the order in which they are placed in the service router.
+func NewFindConflictsFunc(ordering string) services.FindConflictsFunc {
+	sortItems := NewSortItemsFunc(ordering)
+
+	findConflicts := func(items []client.Object) map[string]services.Conflict {
+		// The items are sorted by name first like the items which are merged into the service router.
+		items = append([]client.Object{}, items...)
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].GetName() < items[j].GetName()
+		})
+		sortItems(items)
+
+		return FindConflicts(items)
+	}
+
+	return findConflicts
+}
+
+// ExcludeConflicts removes the routes of the conflicting items from the service router.
+// The routes of the service router must be in the order of the items.
+func ExcludeConflicts(obj client.Object, items []client.Object) (client.Object, error) {
+	serviceRouter := obj.(*consulk8s.ServiceRouter)
+	conflicts := FindConflicts(items)
+
+	serviceRoutes := []consulk8s.ServiceRoute{}
+	for i, item := range items {
+		if _, ok := conflicts[item.GetName()]; !ok {
+			serviceRoutes = append(serviceRoutes, serviceRouter.Spec.Routes[i])
+		}
+	}
+
+	serviceRouter.Spec.Routes = serviceRoutes
+
+	return serviceRouter, nil
+}
+
+// FindDuplicate finds the first of the ConsulServiceRoute items whose match is the same as the match of the route.
+// The route itself is skipped if it is one of the items.
+func FindDuplicate(route *servicev1alpha1.ConsulServiceRoute, items []client.Object) (string, bool) {
+	match := getHTTPMatch(route.Spec.Route)
+
+	for _, item := range items {
+		other := item.(*servicev1alpha1.ConsulServiceRoute)
+		if other.Name == route.Name {
+			continue
+		}
+
+		if reflect.DeepEqual(getHTTPMatch(other.Spec.Route), match) {
+			return other.Name, true
+		}
+	}
+
+	return "", false
+}
+
+var conflictVerbs = map[string]string{
+	ConflictReasonDuplicateMatch: "duplicated",
+	ConflictReasonShadowedMatch:  "shadowed",
+}
+
+func findConflict(earlier, later consulk8s.ServiceRoute) (string, bool) {
+	earlierMatch := getHTTPMatch(earlier)
+	laterMatch := getHTTPMatch(later)
+
+	if reflect.DeepEqual(earlierMatch, laterMatch) {
+		return ConflictReasonDuplicateMatch, true
+	}
+
+	if coversPath(earlierMatch, laterMatch) &&
+		coversHeaders(earlierMatch.Header, laterMatch.Header) &&
+		coversQueryParams(earlierMatch.QueryParam, laterMatch.QueryParam) &&
+		coversMethods(earlierMatch.Methods, laterMatch.Methods) {
+		return ConflictReasonShadowedMatch, true
+	}
+
+	return "", false
+}
+
+func getHTTPMatch(route consulk8s.ServiceRoute) consulk8s.ServiceRouteHTTPMatch {
+	if route.Match == nil || route.Match.HTTP == nil {
+		return consulk8s.ServiceRouteHTTPMatch{}
+	}
+
+	return *route.Match.HTTP
+}
+
+func coversPath(earlier, later consulk8s.ServiceRouteHTTPMatch) bool {
+	switch {
+	case len(earlier.PathExact) > 0:
+		return later.PathExact == earlier.PathExact
+	case len(earlier.PathPrefix) > 0:
+		return len(later.PathExact) > 0 && strings.HasPrefix(later.PathExact, earlier.PathPrefix) ||
+			len(later.PathPrefix) > 0 && strings.HasPrefix(later.PathPrefix, earlier.PathPrefix)
+	case len(earlier.PathRegex) > 0:
+		return later.PathRegex == earlier.PathRegex
+	}
+
+	return true
+}
+
+func coversHeaders(earlier, later []consulk8s.ServiceRouteHTTPMatchHeader) bool {
+	for _, header := range earlier {
+		found := false
+		for _, laterHeader := range later {
+			if reflect.DeepEqual(header, laterHeader) {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func coversQueryParams(earlier, later []consulk8s.ServiceRouteHTTPMatchQueryParam) bool {
+	for _, queryParam := range earlier {
+		found := false
+		for _, laterQueryParam := range later {
+			if reflect.DeepEqual(queryParam, laterQueryParam) {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func coversMethods(earlier, later []string) bool {
+	if len(earlier) == 0 {
+		return true
+	}
+
+	if len(later) == 0 {
+		return false
+	}
+
+	for _, method := range later {
+		found := false
+		for _, earlierMethod := range earlier {
+			if strings.EqualFold(method, earlierMethod) {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"fmt"
+	"sort"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// OrderingPriority sorts the routes by descending priority and name.
+	OrderingPriority = "priority"
+
+	// OrderingSpecificity sorts the routes by the specificity of their match.
+	OrderingSpecificity = "specificity"
+)
+
+// GetOrderingAnnotation returns the name of the annotation which selects the ordering of the routes in a service router.
+// The annotation has the prefix of the labels, so it follows the prefix set in the config file.
+func GetOrderingAnnotation() string {
+	return fmt.Sprintf("%s/route-ordering", controllerlabels.GetPrefix())
+}
+
+// IsValidOrdering checks if ordering is one of the supported route orderings.
+func IsValidOrdering(ordering string) bool {
+	return ordering == OrderingPriority || ordering == OrderingSpecificity
+}
+
+// NewSortItemsFunc returns a SortItemsFunc which sorts the ConsulServiceRoute items of a service router.
+// The ordering is taken from the ordering annotation of the first route by name which sets a valid value,
+// otherwise defaultOrdering is used.
+func NewSortItemsFunc(defaultOrdering string) services.SortItemsFunc {
+	sortItems := func(items []client.Object) {
+		ordering := getOrdering(items, defaultOrdering)
+		if ordering == OrderingSpecificity {
+			SortBySpecificity(items)
+
+			return
+		}
+
+		SortByPriority(items)
+	}
+
+	return sortItems
+}
+
+// SortBySpecificity sorts the ConsulServiceRoute items by the specificity of their match.
+// Routes with pathExact are placed first, followed by routes with pathPrefix from the longest to the shortest prefix,
+// routes with pathRegex, routes without a path match and routes with an empty match.
+// Routes with header or query param matches are placed before routes with the same path match without them.
+// Routes with the same specificity are sorted by priority and name.
+func SortBySpecificity(items []client.Object) {
+	sort.SliceStable(items, func(i, j int) bool {
+		left := items[i].(*servicev1alpha1.ConsulServiceRoute)
+		right := items[j].(*servicev1alpha1.ConsulServiceRoute)
+
+		leftSpecificity := getSpecificity(left.Spec.Route)
+		rightSpecificity := getSpecificity(right.Spec.Route)
+
+		if leftSpecificity != rightSpecificity {
+			return leftSpecificity.isMoreSpecificThan(rightSpecificity)
+		}
+
+		if left.Spec.Priority != right.Spec.Priority {
+			return left.Spec.Priority > right.Spec.Priority
+		}
+
+		return left.Name < right.Name
+	})
+}
+
+const (
+	pathMatchExact = iota
+	pathMatchPrefix
+	pathMatchRegex
+	pathMatchNone
+	matchEmpty
+)
+
+type specificity struct {
+	pathMatch             int
+	pathPrefixLength      int
+	hasHeaderOrQueryParam bool
+}
+
+func (s specificity) isMoreSpecificThan(other specificity) bool {
+	if s.pathMatch != other.pathMatch {
+		return s.pathMatch < other.pathMatch
+	}
+
+	if s.pathPrefixLength != other.pathPrefixLength {
+		return s.pathPrefixLength > other.pathPrefixLength
+	}
+
+	return s.hasHeaderOrQueryParam && !other.hasHeaderOrQueryParam
+}
+
+func getSpecificity(route consulk8s.ServiceRoute) specificity {
+	if route.Match == nil || route.Match.HTTP == nil {
+		return specificity{pathMatch: matchEmpty}
+	}
+
+	http := route.Match.HTTP
+	result := specificity{
+		pathMatch:             pathMatchNone,
+		hasHeaderOrQueryParam: len(http.Header) > 0 || len(http.QueryParam) > 0,
+	}
+
+	switch {
+	case len(http.PathExact) > 0:
+		result.pathMatch = pathMatchExact
+	case len(http.PathPrefix) > 0:
+		result.pathMatch = pathMatchPrefix
+		result.pathPrefixLength = len(http.PathPrefix)
+	case len(http.PathRegex) > 0:
+		result.pathMatch = pathMatchRegex
+	case !result.hasHeaderOrQueryParam && len(http.Methods) == 0:
+		result.pathMatch = matchEmpty
+	}
+
+	return result
+}
+
+func getOrdering(items []client.Object, defaultOrdering string) string {
+	ordering := defaultOrdering
+	firstName := ""
+	orderingAnnotation := GetOrderingAnnotation()
+
+	for _, item := range items {
+		value := item.GetAnnotations()[orderingAnnotation]
+		if !IsValidOrdering(value) {
+			continue
+		}
+
+		if len(firstName) == 0 || item.GetName() < firstName {
+			firstName = item.GetName()
+			ordering = value
+		}
+	}
+
+	return ordering
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes_test
+
+import (
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/routes"
+)
+
+func newRoute(name string, priority int32, pathPrefix string, annotations map[string]string) *servicev1alpha1.ConsulServiceRoute {
+	route := &servicev1alpha1.ConsulServiceRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations},
+		Spec: servicev1alpha1.ConsulServiceRouteSpec{
+			Route: consulk8s.ServiceRoute{
+				Match: &consulk8s.ServiceRouteMatch{HTTP: &consulk8s.ServiceRouteHTTPMatch{PathPrefix: pathPrefix}},
+			},
+			Priority: priority,
+		},
+	}
+
+	return route
+}
+
+func getNames(items []client.Object) []string {
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.GetName())
+	}
+
+	return names
+}
+
+var _ = Describe("Ordering", func() {
+	AfterEach(func() {
+		controllerlabels.SetPrefix(servicev1alpha1.GroupVersion.Group)
+	})
+
+	It("uses the ordering annotation with the prefix which is set", func() {
+		controllerlabels.SetPrefix("example.com")
+		Expect(routes.GetOrderingAnnotation()).To(Equal("example.com/route-ordering"))
+
+		items := []client.Object{
+			newRoute("route-a", 2, "/", map[string]string{"example.com/route-ordering": routes.OrderingSpecificity}),
+			newRoute("route-b", 1, "/v1", nil),
+		}
+
+		routes.NewSortItemsFunc(routes.OrderingPriority)(items)
+		Expect(getNames(items)).To(Equal([]string{"route-b", "route-a"}))
+	})
+
+	It("ignores the ordering annotation with another prefix", func() {
+		controllerlabels.SetPrefix("example.com")
+
+		items := []client.Object{
+			newRoute("route-a", 2, "/", map[string]string{servicev1alpha1.GroupVersion.Group + "/route-ordering": routes.OrderingSpecificity}),
+			newRoute("route-b", 1, "/v1", nil),
+		}
+
+		routes.NewSortItemsFunc(routes.OrderingPriority)(items)
+		Expect(getNames(items)).To(Equal([]string{"route-a", "route-b"}))
+	})
+})
@@ -0,0 +1,39 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"sort"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SortByPriority sorts the ConsulServiceRoute items by descending priority.
+// Routes with the same priority are sorted by name in ascending order.
+func SortByPriority(items []client.Object) {
+	sort.SliceStable(items, func(i, j int) bool {
+		left := items[i].(*servicev1alpha1.ConsulServiceRoute)
+		right := items[j].(*servicev1alpha1.ConsulServiceRoute)
+
+		if left.Spec.Priority != right.Spec.Priority {
+			return left.Spec.Priority > right.Spec.Priority
+		}
+
+		return left.Name < right.Name
+	})
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"context"
+	"fmt"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/adoption"
+	"github.com/NativeChat/consul-merge-controller/pkg/annotations"
+	"github.com/NativeChat/consul-merge-controller/pkg/consul"
+	e "github.com/NativeChat/consul-merge-controller/pkg/errors"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/metrics"
+	"github.com/NativeChat/consul-merge-controller/pkg/utils"
+	"github.com/hashicorp/consul-k8s/api/common"
+	capi "github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConsulBackend writes the merge destinations directly to the Consul config entries API
+// instead of creating the consul-k8s resources.
+type ConsulBackend struct {
+	ConfigEntries consul.ConfigEntries
+	TokenSource   consul.TokenSource
+
+	// Datacenter is stored in the meta of the config entries the same way as consul-k8s does.
+	Datacenter string
+}
+
+type consulMerger struct {
+	*merger
+	backend *ConsulBackend
+}
+
+func (m *consulMerger) Merge(ctx context.Context, destinationResourceName, namespace string, items []client.Object) (*ctrl.Result, error) {
+	// The merge is recorded as failed unless a result is set before returning.
+	result, merged := metrics.MergeResultFailed, client.Object(nil)
+	defer func() {
+		m.recordMerge(destinationResourceName, namespace, result, merged)
+	}()
+
+	expected, err := m.getExpectedDefinition(destinationResourceName, namespace, items)
+	if err != nil {
+		m.log.Error(err, "failed to get the expected definition")
+
+		return &ctrl.Result{}, err
+	}
+
+	resource, ok := expected.(common.ConfigEntryResource)
+	if !ok {
+		err = fmt.Errorf("%s isn't a Consul config entry", m.kind)
+		m.log.Error(err, "failed to get the expected definition")
+
+		return &ctrl.Result{}, err
+	}
+
+	token, err := m.backend.TokenSource.GetToken(ctx)
+	if err != nil {
+		m.log.Error(err, "failed to get the Consul ACL token")
+
+		return &ctrl.Result{Requeue: true}, nil
+	}
+
+	configEntryName := fmt.Sprintf("%s %s", resource.ConsulKind(), resource.ConsulName())
+	actual, _, err := m.backend.ConfigEntries.Get(resource.ConsulKind(), resource.ConsulName(), &capi.QueryOptions{Token: token})
+	if err != nil && !consul.IsNotFound(err) {
+		m.log.Error(err, fmt.Sprintf("failed to get the config entry %s", configEntryName))
+
+		return &ctrl.Result{Requeue: true}, nil
+	}
+
+	entry := resource.ToConsul(m.backend.Datacenter)
+	expectedSpecSHA := utils.GetSHA(m.strategy.GetSpec(expected))
+	entry.GetMeta()[controllerlabels.ManagedBy] = controllerlabels.ManagedByValue
+	entry.GetMeta()[annotations.LastAppliedSpecSHA] = expectedSpecSHA
+	writeOptions := &capi.WriteOptions{Token: token}
+
+	if actual == nil {
+		if m.strategy.CountEntries(expected) == 0 {
+			m.log.Info(fmt.Sprintf("no %s for the config entry %s, nothing to create", m.strategy.GetEntriesName(), configEntryName))
+
+			result, merged = metrics.MergeResultUnchanged, nil
+			return nil, nil
+		}
+
+		m.log.Info(fmt.Sprintf("creating the config entry %s...", configEntryName))
+
+		// The index 0 creates the config entry only if it still doesn't exist.
+		res := m.write(entry, 0, writeOptions, configEntryName)
+		if res != nil {
+			return res, nil
+		}
+
+		result, merged = metrics.MergeResultCreated, expected
+		return nil, nil
+	}
+
+	isManaged := actual.GetMeta()[controllerlabels.ManagedBy] == controllerlabels.ManagedByValue
+	if !isManaged {
+		if len(items) == 0 {
+			m.log.Info(fmt.Sprintf("the config entry %s wasn't created by the controller and there is nothing to merge into it", configEntryName))
+
+			result, merged = metrics.MergeResultUnchanged, nil
+			return nil, nil
+		}
+
+		if m.adoptionMode == adoption.ModeStrict {
+			err = fmt.Errorf("the config entry %s wasn't created by the controller and the adoption mode is %s", configEntryName, m.adoptionMode)
+			m.log.Error(err, fmt.Sprintf("refusing to change the config entry %s", configEntryName))
+
+			return &ctrl.Result{}, e.NewUnmanagedDestinationError(err)
+		}
+	}
+
+	if isManaged && resource.MatchesConsul(actual) {
+		m.log.Info(fmt.Sprintf("the config entry %s is up to date", configEntryName))
+
+		result, merged = metrics.MergeResultUnchanged, expected
+		return nil, nil
+	}
+
+	if m.strategy.CountEntries(expected) == 0 {
+		m.log.Info(fmt.Sprintf("no %s left for the config entry %s, it will be deleted", m.strategy.GetEntriesName(), configEntryName))
+
+		// The config entry is deleted only if it wasn't changed after it was read, e.g. by a merge into it.
+		deleted, _, err := m.backend.ConfigEntries.DeleteCAS(resource.ConsulKind(), resource.ConsulName(), actual.GetModifyIndex(), writeOptions)
+		if err != nil {
+			m.log.Error(err, fmt.Sprintf("failed to delete the config entry %s", configEntryName))
+
+			return &ctrl.Result{}, err
+		}
+
+		if !deleted {
+			m.log.Info(fmt.Sprintf("the config entry %s was changed concurrently, retrying", configEntryName))
+
+			return &ctrl.Result{Requeue: true}, nil
+		}
+
+		m.log.Info(fmt.Sprintf("successfully deleted the config entry %s", configEntryName))
+
+		result, merged = metrics.MergeResultDeleted, nil
+		return nil, nil
+	}
+
+	// The config entry differs from the one written for the same resources, so it was changed outside of the controller.
+	if isManaged && actual.GetMeta()[annotations.LastAppliedSpecSHA] == expectedSpecSHA {
+		m.log.Info(fmt.Sprintf("the config entry %s was changed outside of the controller, reverting the drift", configEntryName))
+		metrics.DriftRepairs.WithLabelValues(m.kind).Inc()
+	}
+
+	m.log.Info(fmt.Sprintf("updating the config entry %s...", configEntryName))
+
+	res := m.write(entry, actual.GetModifyIndex(), writeOptions, configEntryName)
+	if res != nil {
+		return res, nil
+	}
+
+	result, merged = metrics.MergeResultUpdated, expected
+	return nil, nil
+}
+
+// write writes the config entry if its modify index is still the given index.
+// A requeue is returned when the write fails or the config entry was changed after it was read.
+func (m *consulMerger) write(entry capi.ConfigEntry, index uint64, writeOptions *capi.WriteOptions, configEntryName string) *ctrl.Result {
+	written, _, err := m.backend.ConfigEntries.CAS(entry, index, writeOptions)
+	if err != nil {
+		m.log.Error(err, fmt.Sprintf("failed to write the config entry %s", configEntryName))
+
+		return &ctrl.Result{Requeue: true}
+	}
+
+	if !written {
+		m.log.Info(fmt.Sprintf("the config entry %s was changed concurrently, retrying", configEntryName))
+
+		return &ctrl.Result{Requeue: true}
+	}
+
+	m.log.Info(fmt.Sprintf("the config entry %s was written", configEntryName))
+
+	return nil
+}
+
+// GetDestination returns a resource with the name of the config entry and a Synced condition
+// or nil if the config entry doesn't exist. The spec of the resource isn't set.
+func (m *consulMerger) GetDestination(ctx context.Context, destinationResourceName, namespace string) (client.Object, error) {
+	destination, ok := m.strategy.NewDestination().(common.ConfigEntryResource)
+	if !ok {
+		return nil, fmt.Errorf("%s isn't a Consul config entry", m.kind)
+	}
+
+	destination.SetName(destinationResourceName)
+	destination.SetNamespace(namespace)
+
+	token, err := m.backend.TokenSource.GetToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The merged service intentions are always for the service with the same name.
+	_, _, err = m.backend.ConfigEntries.Get(destination.ConsulKind(), destinationResourceName, &capi.QueryOptions{Token: token})
+	if consul.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	destination.SetSyncedCondition(corev1.ConditionTrue, servicev1alpha1.ConditionReasonWrittenToConsul, "")
+
+	return destination, nil
+}
+
+// NewConsulMerger creates a Merger which merges the items the same way as m
+// but writes the destinations to the Consul config entries API of the backend.
+// The merger m must be created with NewMerger or NewStrategyMerger. The destinations which weren't created by the controller
+// are overwritten or left unchanged in the strict adoption mode.
+func NewConsulMerger(m Merger, backend *ConsulBackend) Merger {
+	consulMerger := &consulMerger{
+		merger:  m.(*merger),
+		backend: backend,
+	}
+
+	return consulMerger
+}
@@ -0,0 +1,342 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	capi "github.com/hashicorp/consul/api"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/adoption"
+	"github.com/NativeChat/consul-merge-controller/pkg/consul"
+	e "github.com/NativeChat/consul-merge-controller/pkg/errors"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/routes"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	"github.com/NativeChat/consul-merge-controller/testutils"
+)
+
+const serviceRouterName = "service-a"
+
+// concurrentConfigEntries calls beforeCAS before each write or delete, so a concurrent change can be simulated.
+type concurrentConfigEntries struct {
+	consul.ConfigEntries
+
+	beforeCAS func()
+}
+
+func (c *concurrentConfigEntries) CAS(entry capi.ConfigEntry, index uint64, w *capi.WriteOptions) (bool, *capi.WriteMeta, error) {
+	c.beforeCAS()
+
+	return c.ConfigEntries.CAS(entry, index, w)
+}
+
+func (c *concurrentConfigEntries) DeleteCAS(kind string, name string, index uint64, w *capi.WriteOptions) (bool, *capi.WriteMeta, error) {
+	c.beforeCAS()
+
+	return c.ConfigEntries.DeleteCAS(kind, name, index, w)
+}
+
+func newRoute(name, pathPrefix string, priority int32) client.Object {
+	route := &v1alpha1.ConsulServiceRoute{
+		TypeMeta: metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: "ConsulServiceRoute"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID(name),
+			Labels:    map[string]string{controllerlabels.ServiceRouter: serviceRouterName},
+		},
+		Spec: v1alpha1.ConsulServiceRouteSpec{
+			Route: consulk8s.ServiceRoute{
+				Match:       &consulk8s.ServiceRouteMatch{HTTP: &consulk8s.ServiceRouteHTTPMatch{PathPrefix: pathPrefix}},
+				Destination: &consulk8s.ServiceRouteDestination{Service: name},
+			},
+			Priority: priority,
+		},
+	}
+
+	return route
+}
+
+func newConsulMerger(backend *services.ConsulBackend, adoptionMode string) services.Merger {
+	merger := services.NewMerger(
+		nil,
+		nil,
+		logr.Discard(),
+		record.NewFakeRecorder(100),
+		nil,
+		nil,
+		routes.NewSortItemsFunc(routes.OrderingPriority),
+		adoptionMode,
+		"Routes",
+		"Route",
+		reflect.TypeOf(consulk8s.ServiceRouter{}),
+	)
+
+	return services.NewConsulMerger(merger, backend)
+}
+
+func getServiceRouter(server *testutils.ConsulServer) *capi.ServiceRouterConfigEntry {
+	entry, err := server.GetConfigEntry(capi.ServiceRouter, serviceRouterName)
+	Expect(err).NotTo(HaveOccurred())
+
+	if entry == nil {
+		return nil
+	}
+
+	return entry.(*capi.ServiceRouterConfigEntry)
+}
+
+func getPathPrefixes(serviceRouter *capi.ServiceRouterConfigEntry) []string {
+	pathPrefixes := []string{}
+	for _, route := range serviceRouter.Routes {
+		pathPrefixes = append(pathPrefixes, route.Match.HTTP.PathPrefix)
+	}
+
+	return pathPrefixes
+}
+
+var _ = Describe("Consul merger", func() {
+	var ctx context.Context
+	var server *testutils.ConsulServer
+	var backend *services.ConsulBackend
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		server = testutils.NewConsulServer()
+
+		configEntries, err := consul.NewConfigEntries(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		backend = &services.ConsulBackend{
+			ConfigEntries: configEntries,
+			TokenSource:   consul.NewStaticTokenSource(""),
+			Datacenter:    "dc1",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("Should write the merged config entry", func() {
+		merger := newConsulMerger(backend, adoption.ModeOverwrite)
+		items := []client.Object{newRoute("service-a-v1", "/v1", 0), newRoute("service-a-v2", "/v2", 10)}
+
+		res, err := merger.Merge(ctx, serviceRouterName, "default", items)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeNil())
+
+		serviceRouter := getServiceRouter(server)
+		Expect(serviceRouter).NotTo(BeNil())
+		Expect(getPathPrefixes(serviceRouter)).To(Equal([]string{"/v2", "/v1"}))
+		Expect(serviceRouter.Meta[controllerlabels.ManagedBy]).To(Equal(controllerlabels.ManagedByValue))
+		Expect(serviceRouter.Meta["consul.hashicorp.com/source-datacenter"]).To(Equal("dc1"))
+
+		By("not writing an unchanged config entry")
+		modifyIndex := serviceRouter.ModifyIndex
+
+		res, err = merger.Merge(ctx, serviceRouterName, "default", items)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeNil())
+		Expect(getServiceRouter(server).ModifyIndex).To(Equal(modifyIndex))
+
+		By("updating the config entry")
+		items = append(items, newRoute("service-a-v3", "/v3", 5))
+
+		res, err = merger.Merge(ctx, serviceRouterName, "default", items)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeNil())
+		Expect(getPathPrefixes(getServiceRouter(server))).To(Equal([]string{"/v2", "/v3", "/v1"}))
+
+		By("deleting the config entry without items")
+		res, err = merger.Merge(ctx, serviceRouterName, "default", []client.Object{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeNil())
+		Expect(getServiceRouter(server)).To(BeNil())
+	})
+
+	It("Should report the written config entry as the destination", func() {
+		merger := newConsulMerger(backend, adoption.ModeOverwrite)
+
+		destination, err := merger.GetDestination(ctx, serviceRouterName, "default")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(destination).To(BeNil())
+
+		_, err = merger.Merge(ctx, serviceRouterName, "default", []client.Object{newRoute("service-a-v1", "/v1", 0)})
+		Expect(err).NotTo(HaveOccurred())
+
+		destination, err = merger.GetDestination(ctx, serviceRouterName, "default")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(destination).NotTo(BeNil())
+
+		status, reason, _ := destination.(*consulk8s.ServiceRouter).SyncedCondition()
+		Expect(status).To(Equal(corev1.ConditionTrue))
+		Expect(reason).To(Equal(v1alpha1.ConditionReasonWrittenToConsul))
+	})
+
+	It("Should retry when the config entry is changed concurrently", func() {
+		concurrentChange := &capi.ServiceRouterConfigEntry{
+			Kind:   capi.ServiceRouter,
+			Name:   serviceRouterName,
+			Routes: []capi.ServiceRoute{{Match: &capi.ServiceRouteMatch{HTTP: &capi.ServiceRouteHTTPMatch{PathPrefix: "/concurrent"}}}},
+			Meta:   map[string]string{controllerlabels.ManagedBy: controllerlabels.ManagedByValue},
+		}
+
+		backend.ConfigEntries = &concurrentConfigEntries{
+			ConfigEntries: backend.ConfigEntries,
+			beforeCAS: func() {
+				Expect(server.SetConfigEntry(concurrentChange)).To(Succeed())
+			},
+		}
+
+		merger := newConsulMerger(backend, adoption.ModeOverwrite)
+		items := []client.Object{newRoute("service-a-v1", "/v1", 0)}
+
+		By("not creating a config entry which was created concurrently")
+		res, err := merger.Merge(ctx, serviceRouterName, "default", items)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).NotTo(BeNil())
+		Expect(res.Requeue).To(BeTrue())
+		Expect(getPathPrefixes(getServiceRouter(server))).To(Equal([]string{"/concurrent"}))
+
+		By("not updating a config entry which was updated concurrently")
+		res, err = merger.Merge(ctx, serviceRouterName, "default", items)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).NotTo(BeNil())
+		Expect(res.Requeue).To(BeTrue())
+		Expect(getPathPrefixes(getServiceRouter(server))).To(Equal([]string{"/concurrent"}))
+	})
+
+	It("Should not delete a config entry which was updated concurrently", func() {
+		items := []client.Object{newRoute("service-a-v1", "/v1", 0)}
+		_, err := newConsulMerger(backend, adoption.ModeOverwrite).Merge(ctx, serviceRouterName, "default", items)
+		Expect(err).NotTo(HaveOccurred())
+
+		concurrentChange := getServiceRouter(server)
+		concurrentChange.Routes = append(concurrentChange.Routes, capi.ServiceRoute{
+			Match: &capi.ServiceRouteMatch{HTTP: &capi.ServiceRouteHTTPMatch{PathPrefix: "/concurrent"}},
+		})
+
+		configEntries := backend.ConfigEntries
+		backend.ConfigEntries = &concurrentConfigEntries{
+			ConfigEntries: configEntries,
+			beforeCAS: func() {
+				Expect(server.SetConfigEntry(concurrentChange)).To(Succeed())
+			},
+		}
+
+		res, err := newConsulMerger(backend, adoption.ModeOverwrite).Merge(ctx, serviceRouterName, "default", []client.Object{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).NotTo(BeNil())
+		Expect(res.Requeue).To(BeTrue())
+		Expect(getPathPrefixes(getServiceRouter(server))).To(Equal([]string{"/v1", "/concurrent"}))
+
+		By("deleting the config entry when it isn't changed anymore")
+		backend.ConfigEntries = configEntries
+
+		res, err = newConsulMerger(backend, adoption.ModeOverwrite).Merge(ctx, serviceRouterName, "default", []client.Object{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeNil())
+		Expect(getServiceRouter(server)).To(BeNil())
+	})
+
+	It("Should report only a missing config entry as not found", func() {
+		_, _, err := backend.ConfigEntries.Get(capi.ServiceRouter, serviceRouterName, &capi.QueryOptions{})
+		Expect(consul.IsNotFound(err)).To(BeTrue())
+
+		server.SetToken("secret-token")
+
+		_, _, err = backend.ConfigEntries.Get(capi.ServiceRouter, serviceRouterName, &capi.QueryOptions{})
+		Expect(err).To(HaveOccurred())
+		Expect(consul.IsNotFound(err)).To(BeFalse())
+		Expect(consul.IsNotFound(errors.New("Unexpected response code: 404"))).To(BeFalse())
+	})
+
+	It("Should use the ACL token from the secret", func() {
+		server.SetToken("secret-token")
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "consul-token", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte("secret-token")},
+		}
+		reader := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(secret).Build()
+		items := []client.Object{newRoute("service-a-v1", "/v1", 0)}
+
+		By("failing without the token")
+		res, err := newConsulMerger(backend, adoption.ModeOverwrite).Merge(ctx, serviceRouterName, "default", items)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).NotTo(BeNil())
+		Expect(res.Requeue).To(BeTrue())
+
+		By("writing the config entry with the token")
+		backend.TokenSource = consul.NewSecretTokenSource(reader, types.NamespacedName{Name: "consul-token", Namespace: "default"}, "token")
+
+		res, err = newConsulMerger(backend, adoption.ModeOverwrite).Merge(ctx, serviceRouterName, "default", items)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeNil())
+
+		server.SetToken("")
+		Expect(getPathPrefixes(getServiceRouter(server))).To(Equal([]string{"/v1"}))
+	})
+
+	It("Should handle config entries which weren't written by the controller", func() {
+		unmanaged := &capi.ServiceRouterConfigEntry{
+			Kind:   capi.ServiceRouter,
+			Name:   serviceRouterName,
+			Routes: []capi.ServiceRoute{{Match: &capi.ServiceRouteMatch{HTTP: &capi.ServiceRouteHTTPMatch{PathPrefix: "/unmanaged"}}}},
+		}
+		Expect(server.SetConfigEntry(unmanaged)).To(Succeed())
+
+		items := []client.Object{newRoute("service-a-v1", "/v1", 0)}
+
+		By("not changing the config entry in the strict adoption mode")
+		_, err := newConsulMerger(backend, adoption.ModeStrict).Merge(ctx, serviceRouterName, "default", items)
+		unmanagedErr := new(e.UnmanagedDestinationError)
+		Expect(errors.As(err, &unmanagedErr)).To(BeTrue())
+		Expect(getPathPrefixes(getServiceRouter(server))).To(Equal([]string{"/unmanaged"}))
+
+		By("not deleting the config entry without items")
+		res, err := newConsulMerger(backend, adoption.ModeOverwrite).Merge(ctx, serviceRouterName, "default", []client.Object{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeNil())
+		Expect(getServiceRouter(server)).NotTo(BeNil())
+
+		By("overwriting the config entry in the overwrite adoption mode")
+		res, err = newConsulMerger(backend, adoption.ModeOverwrite).Merge(ctx, serviceRouterName, "default", items)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeNil())
+
+		serviceRouter := getServiceRouter(server)
+		Expect(getPathPrefixes(serviceRouter)).To(Equal([]string{"/v1"}))
+		Expect(serviceRouter.Meta[controllerlabels.ManagedBy]).To(Equal(controllerlabels.ManagedByValue))
+	})
+})
@@ -18,14 +18,15 @@ package services
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/json"
-	"fmt"
 	"reflect"
 
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
 	e "github.com/NativeChat/consul-merge-controller/pkg/errors"
+	"github.com/NativeChat/consul-merge-controller/pkg/finalizers"
 	"github.com/NativeChat/consul-merge-controller/pkg/utils"
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -53,10 +54,23 @@ func (c *crdService) GetResourceFromRequest(ctx context.Context, req ctrl.Reques
 }
 
 func (c *crdService) GetAllResourcesForService(ctx context.Context, label, serviceName, namespace string) ([]client.Object, error) {
-	resourceListReflectValue := reflect.New(c.resourceListType)
-	listItemsReflectValue := resourceListReflectValue.Elem().FieldByName("Items")
+	resources, err := c.ListResources(ctx, label, serviceName, namespace)
+	if err != nil {
+		return nil, err
+	}
 
-	resourceObjectList := resourceListReflectValue.Interface().(client.ObjectList)
+	notMarkedForDeletion := []client.Object{}
+	for _, resource := range resources {
+		if !c.IsDeleted(resource) {
+			notMarkedForDeletion = append(notMarkedForDeletion, resource)
+		}
+	}
+
+	return notMarkedForDeletion, nil
+}
+
+func (c *crdService) ListResources(ctx context.Context, label, serviceName, namespace string) ([]client.Object, error) {
+	resourceObjectList := reflect.New(c.resourceListType).Interface().(client.ObjectList)
 
 	requirement, err := labels.NewRequirement(label, selection.Equals, []string{serviceName})
 	if err != nil {
@@ -72,35 +86,47 @@ func (c *crdService) GetAllResourcesForService(ctx context.Context, label, servi
 		return nil, err
 	}
 
-	notMarkedForDeletion := &[]client.Object{}
-	notMarkedForDeletionReflectValue := reflect.ValueOf(notMarkedForDeletion).Elem()
-	for i := 0; i < listItemsReflectValue.Len(); i++ {
-		item := listItemsReflectValue.Index(i)
-		if !c.IsDeleted(item.Addr().Interface().(client.Object)) {
-			notMarkedForDeletionReflectValue.Set(reflect.Append(notMarkedForDeletionReflectValue, item.Addr()))
-		}
+	items, err := meta.ExtractList(resourceObjectList)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []client.Object{}
+	for _, item := range items {
+		resources = append(resources, item.(client.Object))
 	}
 
-	return *notMarkedForDeletion, nil
+	return resources, nil
 }
 
+// UpdateFinalizer adds the finalizer to a resource and removes it from a deleted resource.
+// The finalizer is shared by all merge kinds, so only the finalizers with the default prefix are migrated
+// to the finalizer with the configured prefix.
 func (c *crdService) UpdateFinalizer(ctx context.Context, obj client.Object) error {
 	var err error = nil
 	containsFinalizer := controllerutil.ContainsFinalizer(obj, c.finalizer)
+	replacedFinalizers := c.getReplacedFinalizers(obj)
 
 	if c.IsDeleted(obj) {
-		if containsFinalizer {
+		if containsFinalizer || len(replacedFinalizers) > 0 {
 			c.log.Info("removing finalizer")
 			controllerutil.RemoveFinalizer(obj, c.finalizer)
+			for _, finalizer := range replacedFinalizers {
+				controllerutil.RemoveFinalizer(obj, finalizer)
+			}
 
 			err = c.writer.Update(ctx, obj)
 			if err == nil {
 				c.log.Info("finalizer removed")
 			}
 		}
-	} else if !containsFinalizer {
+	} else if !containsFinalizer || len(replacedFinalizers) > 0 {
 		c.log.Info("adding finalizer")
 		controllerutil.AddFinalizer(obj, c.finalizer)
+		for _, finalizer := range replacedFinalizers {
+			c.log.Info("removing replaced finalizer", "finalizer", finalizer)
+			controllerutil.RemoveFinalizer(obj, finalizer)
+		}
 
 		err = c.writer.Update(ctx, obj)
 		if err == nil {
@@ -111,6 +137,18 @@ func (c *crdService) UpdateFinalizer(ctx context.Context, obj client.Object) err
 	return err
 }
 
+// getReplacedFinalizers returns the finalizers of the resource which are replaced by the finalizer of the service.
+func (c *crdService) getReplacedFinalizers(obj client.Object) []string {
+	replaced := []string{}
+	for _, finalizer := range finalizers.GetReplacedFinalizerNames(c.finalizer) {
+		if controllerutil.ContainsFinalizer(obj, finalizer) {
+			replaced = append(replaced, finalizer)
+		}
+	}
+
+	return replaced
+}
+
 func (c *crdService) IsDeleted(obj client.Object) bool {
 	isDeleted := obj.GetDeletionTimestamp() != nil
 
@@ -130,40 +168,54 @@ func (c *crdService) IsChanged(obj client.Object) bool {
 }
 
 func (c *crdService) GetContentSHA(obj client.Object) string {
-	serialized, _ := json.Marshal(c.getSpec(obj).Interface())
-
-	h := sha256.New()
-
-	h.Write(serialized)
-	result := fmt.Sprintf("%x", h.Sum(nil))
+	result := utils.GetSHA(obj.(servicev1alpha1.MergeSource).GetContent())
 
 	return result
 }
 
 func (c *crdService) SetUpdatedAt(obj client.Object, updatedAt string) {
-	c.getStatus(obj).FieldByName("UpdatedAt").SetString(updatedAt)
+	*c.getMergeStatus(obj).UpdatedAt = updatedAt
 }
 
 func (c *crdService) SetContentSHA(obj client.Object, contentSHA string) {
-	c.getStatus(obj).FieldByName("ContentSHA").SetString(contentSHA)
+	*c.getMergeStatus(obj).ContentSHA = contentSHA
 }
 
-func (c *crdService) getCurrentContentSHA(obj client.Object) string {
-	contentSHA := c.getStatus(obj).FieldByName("ContentSHA").String()
+func (c *crdService) GetConditions(obj client.Object) *[]metav1.Condition {
+	return c.getMergeStatus(obj).Conditions
+}
 
-	return contentSHA
+func (c *crdService) SetObservedGeneration(obj client.Object, observedGeneration int64) {
+	field := c.getMergeStatus(obj).ObservedGeneration
+	if field != nil {
+		*field = observedGeneration
+	}
 }
 
-func (c *crdService) getStatus(obj client.Object) reflect.Value {
-	spec := reflect.ValueOf(obj).Elem().FieldByName("Status")
+func (c *crdService) SetDestination(obj client.Object, destination *servicev1alpha1.DestinationReference) {
+	field := c.getMergeStatus(obj).Destination
+	if field != nil {
+		*field = destination
+	}
+}
+
+func (c *crdService) GetLastDestination(obj client.Object) *servicev1alpha1.DestinationReference {
+	field := c.getMergeStatus(obj).Destination
+	if field == nil {
+		return nil
+	}
 
-	return spec
+	return *field
 }
 
-func (c *crdService) getSpec(obj client.Object) reflect.Value {
-	spec := reflect.ValueOf(obj).Elem().FieldByName("Spec")
+func (c *crdService) getCurrentContentSHA(obj client.Object) string {
+	contentSHA := *c.getMergeStatus(obj).ContentSHA
+
+	return contentSHA
+}
 
-	return spec
+func (c *crdService) getMergeStatus(obj client.Object) servicev1alpha1.MergeStatus {
+	return obj.(servicev1alpha1.MergeSource).GetMergeStatus()
 }
 
 // NewCRDService returns new CRD service.
@@ -0,0 +1,158 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services_test
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/finalizers"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+)
+
+const prefixedFinalizer = "finalizer.example.com"
+
+func newRouteCRDService(k8sClient client.Client) services.CRDService {
+	crdService := services.NewCRDService(
+		k8sClient,
+		k8sClient,
+		logr.Discard(),
+		finalizers.FinalizerName,
+		reflect.TypeOf(v1alpha1.ConsulServiceRoute{}),
+		reflect.TypeOf(v1alpha1.ConsulServiceRouteList{}),
+	)
+
+	return crdService
+}
+
+var _ = Describe("CRD service", func() {
+	table.DescribeTable("UpdateFinalizer",
+		func(deleted bool, finalizers []string, expectedFinalizers []string, expectUpdate bool) {
+			ctx := context.Background()
+
+			route := newRoute("service-a-v1", "/v1", 0)
+			route.SetFinalizers(finalizers)
+			if deleted {
+				deletionTimestamp := metav1.Now()
+				route.SetDeletionTimestamp(&deletionTimestamp)
+			}
+
+			k8sClient := newFakeClient(route)
+			crdService := newRouteCRDService(k8sClient)
+
+			obj := &v1alpha1.ConsulServiceRoute{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(route), obj)).To(Succeed())
+			resourceVersion := obj.ResourceVersion
+
+			Expect(crdService.UpdateFinalizer(ctx, obj)).To(Succeed())
+			Expect(obj.GetFinalizers()).To(Equal(expectedFinalizers))
+
+			actual := &v1alpha1.ConsulServiceRoute{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(route), actual)).To(Succeed())
+			Expect(actual.GetFinalizers()).To(Equal(expectedFinalizers))
+			Expect(actual.ResourceVersion != resourceVersion).To(Equal(expectUpdate))
+		},
+		table.Entry("adds the finalizer to a new resource",
+			false, nil, []string{finalizers.FinalizerName}, true,
+		),
+		table.Entry("keeps the finalizer of an existing resource",
+			false, []string{finalizers.FinalizerName}, []string{finalizers.FinalizerName}, false,
+		),
+		table.Entry("removes the finalizer of a deleted resource",
+			true, []string{"other", finalizers.FinalizerName}, []string{"other"}, true,
+		),
+		table.Entry("doesn't add the finalizer to a deleted resource",
+			true, []string{"other"}, []string{"other"}, false,
+		),
+	)
+
+	table.DescribeTable("UpdateFinalizer with a finalizer prefix",
+		func(deleted bool, existingFinalizers []string, expectedFinalizers []string) {
+			ctx := context.Background()
+
+			route := newRoute("service-a-v1", "/v1", 0)
+			route.SetFinalizers(existingFinalizers)
+			if deleted {
+				deletionTimestamp := metav1.Now()
+				route.SetDeletionTimestamp(&deletionTimestamp)
+			}
+
+			k8sClient := newFakeClient(route)
+			crdService := services.NewCRDService(
+				k8sClient,
+				k8sClient,
+				logr.Discard(),
+				prefixedFinalizer,
+				reflect.TypeOf(v1alpha1.ConsulServiceRoute{}),
+				reflect.TypeOf(v1alpha1.ConsulServiceRouteList{}),
+			)
+
+			obj := &v1alpha1.ConsulServiceRoute{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(route), obj)).To(Succeed())
+
+			Expect(crdService.UpdateFinalizer(ctx, obj)).To(Succeed())
+
+			actual := &v1alpha1.ConsulServiceRoute{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(route), actual)).To(Succeed())
+			Expect(actual.GetFinalizers()).To(Equal(expectedFinalizers))
+		},
+		table.Entry("replaces the default finalizer of an existing resource",
+			false, []string{finalizers.DefaultFinalizerName, "other"}, []string{"other", prefixedFinalizer},
+		),
+		table.Entry("removes the default finalizer of a deleted resource",
+			true, []string{"other", finalizers.DefaultFinalizerName}, []string{"other"},
+		),
+		table.Entry("removes both finalizers of a deleted resource",
+			true, []string{finalizers.DefaultFinalizerName, prefixedFinalizer, "other"}, []string{"other"},
+		),
+	)
+
+	table.DescribeTable("IsNew and IsChanged",
+		func(getContentSHA func(crdService services.CRDService, obj client.Object) string, expectedNew, expectedChanged bool) {
+			crdService := newRouteCRDService(newFakeClient())
+			obj := newRoute("service-a-v1", "/v1", 0)
+			crdService.SetContentSHA(obj, getContentSHA(crdService, obj))
+
+			Expect(crdService.IsNew(obj)).To(Equal(expectedNew))
+			Expect(crdService.IsChanged(obj)).To(Equal(expectedChanged))
+		},
+		table.Entry("a resource without content SHA is new and changed",
+			func(services.CRDService, client.Object) string { return "" },
+			true, true,
+		),
+		table.Entry("a resource with the SHA of its spec is unchanged",
+			func(crdService services.CRDService, obj client.Object) string { return crdService.GetContentSHA(obj) },
+			false, false,
+		),
+		table.Entry("a resource whose spec changed after the last merge is changed",
+			func(crdService services.CRDService, obj client.Object) string {
+				previous := newRoute("service-a-v1", "/v1-previous", 0)
+
+				return crdService.GetContentSHA(previous)
+			},
+			false, true,
+		),
+	)
+})
@@ -18,13 +18,29 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
-
+	"sort"
+	"strconv"
+	"strings"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/adoption"
+	"github.com/NativeChat/consul-merge-controller/pkg/annotations"
+	e "github.com/NativeChat/consul-merge-controller/pkg/errors"
+	"github.com/NativeChat/consul-merge-controller/pkg/events"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/metrics"
+	"github.com/NativeChat/consul-merge-controller/pkg/utils"
 	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -33,18 +49,31 @@ type merger struct {
 	reader                  client.Reader
 	writer                  client.Writer
 	log                     logr.Logger
-	patchExpectedDefinition func(obj client.Object) client.Object
-	mergeIntoPropertyName   string
-	mergeItemPropertyName   string
-	mergeDestinationType    reflect.Type
+	recorder                record.EventRecorder
+	patchExpectedDefinition func(obj client.Object, items []client.Object) (client.Object, error)
+	sortItems               SortItemsFunc
+	adoptionMode            string
+	strategy                MergeStrategy
+	kind                    string
 }
 
 func (m *merger) Merge(ctx context.Context, destinationResourceName, namespace string, items []client.Object) (*ctrl.Result, error) {
-	expected := m.getExpectedDefinition(destinationResourceName, namespace, items)
+	// The merge is recorded as failed unless a result is set before returning.
+	result, merged := metrics.MergeResultFailed, client.Object(nil)
+	defer func() {
+		m.recordMerge(destinationResourceName, namespace, result, merged)
+	}()
+
+	expected, err := m.getExpectedDefinition(destinationResourceName, namespace, items)
+	if err != nil {
+		m.log.Error(err, "failed to get the expected definition")
 
-	actual := reflect.New(m.mergeDestinationType).Interface().(client.Object)
+		return &ctrl.Result{}, err
+	}
+
+	actual := m.strategy.NewDestination()
 	destinationResourceKind := expected.GetObjectKind().GroupVersionKind().GroupVersion().String()
-	err := m.reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: destinationResourceName}, actual)
+	err = m.reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: destinationResourceName}, actual)
 	if err != nil {
 		if !errors.IsNotFound(err) {
 			m.log.Error(err, fmt.Sprintf("failed to get %s", destinationResourceKind))
@@ -52,6 +81,21 @@ func (m *merger) Merge(ctx context.Context, destinationResourceName, namespace s
 			return &ctrl.Result{Requeue: true}, nil
 		}
 
+		if m.strategy.CountEntries(expected) == 0 {
+			m.log.Info(fmt.Sprintf("no %s for %s, nothing to create", m.strategy.GetEntriesName(), destinationResourceKind))
+
+			result, merged = metrics.MergeResultUnchanged, nil
+			return nil, nil
+		}
+
+		if m.wasMergedInto(items, destinationResourceName) {
+			m.log.Info(fmt.Sprintf("%s was deleted outside of the controller, reverting the drift", destinationResourceKind))
+			m.recordRevertedDrift(expected, nil)
+		}
+
+		m.setLastAppliedSpecSHA(expected)
+		m.setManagedByLabel(expected)
+
 		m.log.Info(fmt.Sprintf("creating expected resource %s...", destinationResourceKind))
 
 		err = m.writer.Create(ctx, expected)
@@ -62,20 +106,55 @@ func (m *merger) Merge(ctx context.Context, destinationResourceName, namespace s
 		}
 
 		m.log.Info(fmt.Sprintf("%s created", destinationResourceKind))
+		m.recorder.Event(expected, corev1.EventTypeNormal, events.ReasonCreated, fmt.Sprintf("created from %s", m.getItemNames(items)))
+		result, merged = metrics.MergeResultCreated, expected
 		return nil, nil
 	}
 
-	expectedSpec := m.getSpec(expected)
-	actualSpec := m.getSpec(actual)
+	if !m.isManaged(actual) {
+		if len(items) == 0 {
+			m.log.Info(fmt.Sprintf("%s wasn't created by the controller and there is nothing to merge into it", destinationResourceKind))
+
+			result, merged = metrics.MergeResultUnchanged, nil
+			return nil, nil
+		}
+
+		switch m.adoptionMode {
+		case adoption.ModeStrict:
+			err = fmt.Errorf("%s %s wasn't created by the controller and the adoption mode is %s", m.kind, destinationResourceName, m.adoptionMode)
+			m.log.Error(err, fmt.Sprintf("refusing to change %s", destinationResourceKind))
+
+			return &ctrl.Result{}, e.NewUnmanagedDestinationError(err)
+		case adoption.ModeAdopt:
+			m.log.Info(fmt.Sprintf("adopting the %s of %s", m.strategy.GetEntriesName(), destinationResourceKind))
+
+			err = m.adoptEntries(actual)
+			if err != nil {
+				m.log.Error(err, fmt.Sprintf("failed to adopt the %s of %s", m.strategy.GetEntriesName(), destinationResourceKind))
+
+				return &ctrl.Result{}, err
+			}
+		}
+	}
+
+	if m.adoptionMode != adoption.ModeOverwrite {
+		err = m.addUnmanagedEntries(expected, actual)
+		if err != nil {
+			m.log.Error(err, fmt.Sprintf("failed to add the unmanaged %s of %s", m.strategy.GetEntriesName(), destinationResourceKind))
+
+			return &ctrl.Result{}, err
+		}
+	}
 
-	if reflect.DeepEqual(expectedSpec.Interface(), actualSpec.Interface()) {
+	if reflect.DeepEqual(m.strategy.GetSpec(expected), m.strategy.GetSpec(actual)) {
 		m.log.Info(fmt.Sprintf("%s is up to date", destinationResourceKind))
 
+		result, merged = metrics.MergeResultUnchanged, actual
 		return nil, nil
 	}
 
-	if m.getMergeDestinationProp(expected).Len() == 0 {
-		m.log.Info(fmt.Sprintf("no %s left for %s, it will be deleted", m.mergeIntoPropertyName, destinationResourceKind))
+	if m.strategy.CountEntries(expected) == 0 {
+		m.log.Info(fmt.Sprintf("no %s left for %s, it will be deleted", m.strategy.GetEntriesName(), destinationResourceKind))
 
 		err = m.writer.Delete(ctx, actual)
 		if err != nil {
@@ -85,13 +164,22 @@ func (m *merger) Merge(ctx context.Context, destinationResourceName, namespace s
 		}
 
 		m.log.Info(fmt.Sprintf("successfully deleted %s", destinationResourceKind))
+		m.recorder.Event(actual, corev1.EventTypeNormal, events.ReasonDeleted, "deleted because no resources are merged into it")
 
+		result, merged = metrics.MergeResultDeleted, nil
 		return nil, nil
 	}
 
+	if lastAppliedSpecSHA, ok := actual.GetAnnotations()[annotations.LastAppliedSpecSHA]; ok && lastAppliedSpecSHA != utils.GetSHA(m.strategy.GetSpec(actual)) {
+		m.log.Info(fmt.Sprintf("%s was changed outside of the controller, reverting the drift", destinationResourceKind))
+		m.recordRevertedDrift(actual, actual)
+	}
+
 	m.log.Info(fmt.Sprintf("updating %s...", destinationResourceKind))
 
-	actualSpec.Set(expectedSpec)
+	m.strategy.SetSpec(actual, expected)
+	m.setLastAppliedSpecSHA(actual)
+	m.setManagedByLabel(actual)
 	actual.SetOwnerReferences(expected.GetOwnerReferences())
 	err = m.writer.Update(ctx, actual)
 	if err != nil {
@@ -101,32 +189,200 @@ func (m *merger) Merge(ctx context.Context, destinationResourceName, namespace s
 	}
 
 	m.log.Info(fmt.Sprintf("%s updated", destinationResourceKind))
+	m.recorder.Event(actual, corev1.EventTypeNormal, events.ReasonUpdated, fmt.Sprintf("updated from %s", m.getItemNames(items)))
 
+	result, merged = metrics.MergeResultUpdated, actual
 	return nil, nil
 }
 
-func (m *merger) getSpec(obj client.Object) reflect.Value {
-	spec := reflect.ValueOf(obj).Elem().FieldByName("Spec")
+// getItemNames returns the kind and the names of the items which are merged into the destination.
+func (m *merger) getItemNames(items []client.Object) string {
+	if len(items) == 0 {
+		return "no resources"
+	}
+
+	names := []string{}
+	for _, item := range items {
+		names = append(names, item.GetName())
+	}
+
+	sort.Strings(names)
 
-	return spec
+	return fmt.Sprintf("%s %s", reflect.TypeOf(items[0]).Elem().Name(), strings.Join(names, ", "))
 }
 
-func (m *merger) getMergeDestinationProp(obj client.Object) reflect.Value {
-	destination := m.getSpec(obj).FieldByName(m.mergeIntoPropertyName)
+// recordMerge updates the merge metrics. The merged destination is nil when it doesn't exist after the merge.
+func (m *merger) recordMerge(destinationResourceName, namespace, result string, merged client.Object) {
+	kind := m.kind
+	metrics.Merges.WithLabelValues(kind, result).Inc()
 
-	return destination
+	if result == metrics.MergeResultFailed {
+		return
+	}
+
+	if merged == nil {
+		metrics.DeleteDestination(kind, namespace, destinationResourceName)
+
+		return
+	}
+
+	metrics.DestinationItems.WithLabelValues(kind, namespace, destinationResourceName).Set(float64(m.strategy.CountEntries(merged)))
+	metrics.LastSuccessfulMerge.WithLabelValues(kind, namespace, destinationResourceName).SetToCurrentTime()
 }
 
-func (m *merger) getExpectedDefinition(destinationResourceName, namespace string, items []client.Object) client.Object {
-	expectedReflectValue := reflect.New(m.mergeDestinationType)
-	expected := expectedReflectValue.Interface().(client.Object)
+// wasMergedInto checks if any of the items was last merged into the destination
+// based on the destination in the status of the items.
+func (m *merger) wasMergedInto(items []client.Object, destinationResourceName string) bool {
+	for _, item := range items {
+		source, ok := item.(servicev1alpha1.MergeSource)
+		if !ok {
+			continue
+		}
+
+		destination := source.GetMergeStatus().Destination
+		if destination != nil && *destination != nil && (*destination).Name == destinationResourceName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recordRevertedDrift increments the number of reverted drifts of the actual destination and stores it in obj.
+// When actual is nil the count starts from zero.
+func (m *merger) recordRevertedDrift(obj client.Object, actual client.Object) {
+	revertedDrifts := 0
+	if actual != nil {
+		revertedDrifts, _ = strconv.Atoi(actual.GetAnnotations()[annotations.RevertedDrifts])
+	}
+
+	m.setAnnotation(obj, annotations.RevertedDrifts, strconv.Itoa(revertedDrifts+1))
+	metrics.DriftRepairs.WithLabelValues(m.kind).Inc()
+}
+
+func (m *merger) setLastAppliedSpecSHA(obj client.Object) {
+	m.setAnnotation(obj, annotations.LastAppliedSpecSHA, utils.GetSHA(m.strategy.GetSpec(obj)))
+}
+
+func (m *merger) setManagedByLabel(obj client.Object) {
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = map[string]string{}
+	}
+
+	objLabels[controllerlabels.ManagedBy] = controllerlabels.ManagedByValue
+	obj.SetLabels(objLabels)
+}
+
+// isManaged checks if the destination was created by the controller. Destinations created
+// before the ManagedBy label was introduced are recognized by their owner references.
+func (m *merger) isManaged(obj client.Object) bool {
+	if obj.GetLabels()[controllerlabels.ManagedBy] == controllerlabels.ManagedByValue {
+		return true
+	}
+
+	for _, ownerReference := range obj.GetOwnerReferences() {
+		groupVersion, err := schema.ParseGroupVersion(ownerReference.APIVersion)
+		if err == nil && groupVersion.Group == servicev1alpha1.GroupVersion.Group {
+			return true
+		}
+	}
+
+	return false
+}
+
+// adoptEntries marks the current entries of a destination which wasn't created by the controller as unmanaged
+// unless they are already marked through the UnmanagedEntries annotation.
+func (m *merger) adoptEntries(actual client.Object) error {
+	if _, ok := actual.GetAnnotations()[annotations.UnmanagedEntries]; ok {
+		return nil
+	}
+
+	entries, err := json.Marshal(m.strategy.GetEntries(actual))
+	if err != nil {
+		return err
+	}
+
+	m.setAnnotation(actual, annotations.UnmanagedEntries, string(entries))
+
+	return nil
+}
+
+// addUnmanagedEntries places the entries from the UnmanagedEntries annotation of the actual destination
+// before the merged entries of the expected destination. Only list entries can be unmanaged.
+func (m *merger) addUnmanagedEntries(expected client.Object, actual client.Object) error {
+	value, ok := actual.GetAnnotations()[annotations.UnmanagedEntries]
+	if !ok {
+		return nil
+	}
+
+	err := m.strategy.AddUnmanagedEntries(expected, []byte(value))
+	if err != nil {
+		return fmt.Errorf("invalid %s annotation: %w", annotations.UnmanagedEntries, err)
+	}
+
+	return nil
+}
+
+func (m *merger) setAnnotation(obj client.Object, name, value string) {
+	objAnnotations := obj.GetAnnotations()
+	if objAnnotations == nil {
+		objAnnotations = map[string]string{}
+	}
+
+	objAnnotations[name] = value
+	obj.SetAnnotations(objAnnotations)
+}
+
+func (m *merger) GetDestination(ctx context.Context, destinationResourceName, namespace string) (client.Object, error) {
+	destination := m.strategy.NewDestination()
+	err := m.reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: destinationResourceName}, destination)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return destination, nil
+}
+
+func (m *merger) GetDestinationReference(destinationResourceName, namespace string) *servicev1alpha1.DestinationReference {
+	reference := &servicev1alpha1.DestinationReference{
+		APIVersion: consulk8s.GroupVersion.String(),
+		Kind:       m.kind,
+		Name:       destinationResourceName,
+		Namespace:  namespace,
+	}
+
+	return reference
+}
+
+func (m *merger) getExpectedDefinition(destinationResourceName, namespace string, items []client.Object) (client.Object, error) {
+	expected := m.strategy.NewDestination()
 
 	expected.SetName(destinationResourceName)
 	expected.SetNamespace(namespace)
 
-	mergeDestinationProp := m.getMergeDestinationProp(expected)
+	mergeItem := m.strategy.NewMergeItemFunc()
+
+	// The items are sorted by name before the custom sort, so that the expected definition
+	// doesn't depend on the order in which the items are listed from the cache.
+	items = append([]client.Object{}, items...)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].GetName() < items[j].GetName()
+	})
+
+	if m.sortItems != nil {
+		m.sortItems(items)
+	}
+
 	for _, item := range items {
-		mergeDestinationProp.Set(reflect.Append(mergeDestinationProp, m.getSpec(item).FieldByName(m.mergeItemPropertyName)))
+		err := mergeItem(expected, item)
+		if err != nil {
+			return nil, err
+		}
 
 		ownerReference := metav1.OwnerReference{
 			APIVersion: item.GetObjectKind().GroupVersionKind().GroupVersion().String(),
@@ -139,30 +395,38 @@ func (m *merger) getExpectedDefinition(destinationResourceName, namespace string
 	}
 
 	if m.patchExpectedDefinition != nil {
-		expected = m.patchExpectedDefinition(expected)
+		return m.patchExpectedDefinition(expected, items)
 	}
 
-	return expected
+	return expected, nil
 }
 
-// NewMerger creates new merger instance.
-func NewMerger(
+// NewStrategyMerger creates a merger which merges the items into the destinations described by strategy.
+// When sortItems is nil the items are merged in the order of their names.
+// When adoptionMode is empty the destinations which weren't created by the controller are overwritten.
+func NewStrategyMerger(
 	reader client.Reader,
 	writer client.Writer,
 	log logr.Logger,
-	patchExpectedDefinition func(obj client.Object) client.Object,
-	mergeIntoPropertyName string,
-	mergeItemPropertyName string,
-	mergeDestinationType reflect.Type,
+	recorder record.EventRecorder,
+	patchExpectedDefinition func(obj client.Object, items []client.Object) (client.Object, error),
+	sortItems SortItemsFunc,
+	adoptionMode string,
+	strategy MergeStrategy,
 ) Merger {
 	m := new(merger)
 	m.reader = reader
 	m.writer = writer
 	m.log = log
-	m.mergeIntoPropertyName = mergeIntoPropertyName
-	m.mergeItemPropertyName = mergeItemPropertyName
-	m.mergeDestinationType = mergeDestinationType
+	m.recorder = recorder
 	m.patchExpectedDefinition = patchExpectedDefinition
+	m.sortItems = sortItems
+	m.strategy = strategy
+	m.kind = reflect.TypeOf(strategy.NewDestination()).Elem().Name()
+	m.adoptionMode = adoptionMode
+	if len(m.adoptionMode) == 0 {
+		m.adoptionMode = adoption.ModeOverwrite
+	}
 
 	return m
 }
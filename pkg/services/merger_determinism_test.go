@@ -0,0 +1,308 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/adoption"
+	"github.com/NativeChat/consul-merge-controller/pkg/intentions"
+	"github.com/NativeChat/consul-merge-controller/pkg/routes"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	"github.com/NativeChat/consul-merge-controller/pkg/strategies"
+)
+
+const (
+	// maxDeterminismItems limits the number of items generated from the fuzz input.
+	maxDeterminismItems = 16
+
+	// determinismPermutations is the number of random orders of the items checked by the property tests.
+	determinismPermutations = 20
+
+	determinismDestinationName = "destination"
+)
+
+// determinismCase generates items of one kind from arbitrary bytes and merges them into their destination.
+type determinismCase struct {
+	destinationType reflect.Type
+	newMerger       func(k8sClient client.Client) services.Merger
+	newItem         func(name string, b byte) client.Object
+}
+
+func newDeterminismMerger(k8sClient client.Client, strategy services.MergeStrategy, sortItems services.SortItemsFunc, patch func(obj client.Object, items []client.Object) (client.Object, error)) services.Merger {
+	merger := services.NewStrategyMerger(
+		k8sClient,
+		k8sClient,
+		logr.Discard(),
+		record.NewFakeRecorder(1000),
+		patch,
+		sortItems,
+		adoption.ModeOverwrite,
+		strategy,
+	)
+
+	return merger
+}
+
+func newItemMeta(name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)}
+}
+
+var determinismCases = map[string]determinismCase{
+	"ServiceRouter": {
+		destinationType: reflect.TypeOf(consulk8s.ServiceRouter{}),
+		newMerger: func(k8sClient client.Client) services.Merger {
+			return newDeterminismMerger(k8sClient, strategies.NewServiceRouterStrategy(), routes.NewSortItemsFunc(routes.OrderingPriority), nil)
+		},
+		newItem: func(name string, b byte) client.Object {
+			route := &v1alpha1.ConsulServiceRoute{
+				ObjectMeta: newItemMeta(name),
+				Spec: v1alpha1.ConsulServiceRouteSpec{
+					Route: consulk8s.ServiceRoute{
+						Match:       &consulk8s.ServiceRouteMatch{HTTP: &consulk8s.ServiceRouteHTTPMatch{PathPrefix: fmt.Sprintf("/p%d", b%5)}},
+						Destination: &consulk8s.ServiceRouteDestination{Service: name},
+					},
+					Priority: int32(b % 3),
+				},
+			}
+
+			if b%7 == 0 {
+				route.Annotations = map[string]string{routes.GetOrderingAnnotation(): routes.OrderingSpecificity}
+			}
+
+			return route
+		},
+	},
+	"ServiceSplitter": {
+		destinationType: reflect.TypeOf(consulk8s.ServiceSplitter{}),
+		newMerger: func(k8sClient client.Client) services.Merger {
+			return newDeterminismMerger(k8sClient, strategies.NewServiceSplitterStrategy(), nil, nil)
+		},
+		newItem: func(name string, b byte) client.Object {
+			return &v1alpha1.ConsulServiceSplit{
+				ObjectMeta: newItemMeta(name),
+				Spec: v1alpha1.ConsulServiceSplitSpec{
+					Split: consulk8s.ServiceSplit{Service: fmt.Sprintf("service-%d", b%4), Weight: float32(b % 50)},
+				},
+			}
+		},
+	},
+	"ServiceIntentions": {
+		destinationType: reflect.TypeOf(consulk8s.ServiceIntentions{}),
+		newMerger: func(k8sClient client.Client) services.Merger {
+			return newDeterminismMerger(k8sClient, strategies.NewServiceIntentionsStrategy(), nil, intentions.SetDestinationName)
+		},
+		newItem: func(name string, b byte) client.Object {
+			source := &consulk8s.SourceIntention{Name: fmt.Sprintf("service-%d", b%3)}
+			if b%11 == 0 {
+				source.Action = "deny"
+			} else {
+				source.Permissions = consulk8s.IntentionPermissions{{
+					Action: "allow",
+					HTTP:   &consulk8s.IntentionHTTPPermission{PathPrefix: fmt.Sprintf("/p%d", b%5)},
+				}}
+			}
+
+			return &v1alpha1.ConsulServiceIntentionsSource{
+				ObjectMeta: newItemMeta(name),
+				Spec:       v1alpha1.ConsulServiceIntentionsSourceSpec{Source: source},
+			}
+		},
+	},
+	"ServiceResolver": {
+		destinationType: reflect.TypeOf(consulk8s.ServiceResolver{}),
+		newMerger: func(k8sClient client.Client) services.Merger {
+			return newDeterminismMerger(k8sClient, strategies.NewServiceResolverStrategy(), nil, nil)
+		},
+		newItem: func(name string, b byte) client.Object {
+			return &v1alpha1.ConsulServiceResolverSubset{
+				ObjectMeta: newItemMeta(name),
+				Spec: v1alpha1.ConsulServiceResolverSubsetSpec{
+					Subsets: consulk8s.ServiceResolverSubsetMap{
+						fmt.Sprintf("v%d", b%6): {Filter: fmt.Sprintf("Service.Meta.version == v%d", b%7)},
+					},
+				},
+			}
+		},
+	},
+	"IngressGateway": {
+		destinationType: reflect.TypeOf(consulk8s.IngressGateway{}),
+		newMerger: func(k8sClient client.Client) services.Merger {
+			return newDeterminismMerger(k8sClient, strategies.NewIngressGatewayStrategy(), nil, nil)
+		},
+		newItem: func(name string, b byte) client.Object {
+			protocol := "http"
+			if b%5 == 0 {
+				protocol = "tcp"
+			}
+
+			return &v1alpha1.ConsulIngressGatewayService{
+				ObjectMeta: newItemMeta(name),
+				Spec: v1alpha1.ConsulIngressGatewayServiceSpec{
+					Port:     8080 + int(b%3),
+					Protocol: protocol,
+					Service:  consulk8s.IngressService{Name: fmt.Sprintf("service-%d", b%4)},
+				},
+			}
+		},
+	},
+	"TerminatingGateway": {
+		destinationType: reflect.TypeOf(consulk8s.TerminatingGateway{}),
+		newMerger: func(k8sClient client.Client) services.Merger {
+			return newDeterminismMerger(k8sClient, strategies.NewTerminatingGatewayStrategy(), nil, nil)
+		},
+		newItem: func(name string, b byte) client.Object {
+			return &v1alpha1.ConsulTerminatingGatewayService{
+				ObjectMeta: newItemMeta(name),
+				Spec: v1alpha1.ConsulTerminatingGatewayServiceSpec{
+					Service: consulk8s.LinkedService{Name: fmt.Sprintf("service-%d", b%4)},
+				},
+			}
+		},
+	},
+}
+
+// newItems generates an item for each byte of data.
+func (c determinismCase) newItems(data []byte) []client.Object {
+	if len(data) > maxDeterminismItems {
+		data = data[:maxDeterminismItems]
+	}
+
+	items := []client.Object{}
+	for i, b := range data {
+		items = append(items, c.newItem(fmt.Sprintf("item-%02d", i), b))
+	}
+
+	return items
+}
+
+// merge merges the items into the destination and returns the outcome of the merge
+// as the YAML of the error, the spec and the owner references of the destination.
+func (c determinismCase) merge(k8sClient client.Client, items []client.Object) (output string, mergeErr string, resourceVersion string) {
+	ctx := context.Background()
+
+	outcome := struct {
+		Error           string                  `json:"error,omitempty"`
+		Spec            interface{}             `json:"spec,omitempty"`
+		OwnerReferences []metav1.OwnerReference `json:"ownerReferences,omitempty"`
+	}{}
+
+	res, err := c.newMerger(k8sClient).Merge(ctx, determinismDestinationName, "default", items)
+	if err != nil {
+		outcome.Error = err.Error()
+	} else if res != nil {
+		outcome.Error = fmt.Sprintf("unexpected result %v", *res)
+	}
+
+	destination := reflect.New(c.destinationType).Interface().(client.Object)
+	err = k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: determinismDestinationName}, destination)
+	if err == nil {
+		outcome.Spec = reflect.ValueOf(destination).Elem().FieldByName("Spec").Interface()
+		outcome.OwnerReferences = destination.GetOwnerReferences()
+		resourceVersion = destination.GetResourceVersion()
+	} else if !errors.IsNotFound(err) {
+		outcome.Error = err.Error()
+	}
+
+	serialized, err := yaml.Marshal(outcome)
+	if err != nil {
+		return err.Error(), err.Error(), ""
+	}
+
+	return string(serialized), outcome.Error, resourceVersion
+}
+
+// checkDeterminism checks that the merge of the items generated from data doesn't depend on the order of the items,
+// that merging the same items again is a no-op and that removing an item and adding it again restores the destination.
+// A failed merge keeps the last merged destination, so only the error is restored when the items can't be merged.
+// The orders of the items are taken from a random source with the given seed.
+func (c determinismCase) checkDeterminism(data []byte, seed int64, permutations int) error {
+	items := c.newItems(data)
+	random := rand.New(rand.NewSource(seed))
+
+	expected, expectedErr, _ := c.merge(newFakeClient(), items)
+
+	for i := 0; i < permutations; i++ {
+		shuffled := append([]client.Object{}, items...)
+		random.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+		k8sClient := newFakeClient()
+		actual, _, resourceVersion := c.merge(k8sClient, shuffled)
+		if actual != expected {
+			return fmt.Errorf("the merge depends on the order of the items, expected:\n%s\nactual:\n%s", expected, actual)
+		}
+
+		random.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+		remerged, _, remergedResourceVersion := c.merge(k8sClient, shuffled)
+		if remerged != expected || remergedResourceVersion != resourceVersion {
+			return fmt.Errorf("merging the same items again isn't a no-op, expected:\n%s\nactual:\n%s", expected, remerged)
+		}
+
+		if len(shuffled) == 0 {
+			continue
+		}
+
+		removed := random.Intn(len(shuffled))
+		c.merge(k8sClient, append(append([]client.Object{}, shuffled[:removed]...), shuffled[removed+1:]...))
+
+		readded, readdedErr, _ := c.merge(k8sClient, shuffled)
+		if len(expectedErr) > 0 && readdedErr != expectedErr || len(expectedErr) == 0 && readded != expected {
+			return fmt.Errorf("removing %s and adding it again doesn't restore the destination, expected:\n%s\nactual:\n%s", shuffled[removed].GetName(), expected, readded)
+		}
+	}
+
+	return nil
+}
+
+var _ = Describe("Merge determinism", func() {
+	entries := []table.TableEntry{}
+	for name := range determinismCases {
+		entries = append(entries, table.Entry(name, name))
+	}
+
+	table.DescribeTable("the merged destination doesn't depend on the order of the items",
+		func(name string) {
+			c := determinismCases[name]
+
+			random := rand.New(rand.NewSource(1))
+			for size := 0; size <= maxDeterminismItems; size += 4 {
+				data := make([]byte, size)
+				random.Read(data)
+
+				Expect(c.checkDeterminism(data, int64(size), determinismPermutations)).To(Succeed(), "items generated from %v", data)
+			}
+		},
+		entries...,
+	)
+})
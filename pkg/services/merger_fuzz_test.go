@@ -0,0 +1,49 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services_test
+
+import (
+	"sort"
+	"testing"
+)
+
+// FuzzMergeDeterminism checks the merge determinism properties of each destination kind
+// for items generated from the fuzz input. Run it with go test ./pkg/services -run '^$' -fuzz FuzzMergeDeterminism.
+func FuzzMergeDeterminism(f *testing.F) {
+	f.Add([]byte{1, 2, 3}, int64(1))
+	f.Add([]byte{82, 253, 252, 7}, int64(4))
+	f.Add([]byte{0, 7, 14, 21, 11, 22, 33, 44}, int64(8))
+
+	names := []string{}
+	for name := range determinismCases {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	f.Fuzz(func(t *testing.T, data []byte, seed int64) {
+		for _, name := range names {
+			err := determinismCases[name].checkDeterminism(data, seed, 2)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services_test
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/NativeChat/consul-merge-controller/pkg/adoption"
+	"github.com/NativeChat/consul-merge-controller/pkg/routes"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	"github.com/NativeChat/consul-merge-controller/pkg/strategies"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "update the golden files with the actual test output")
+
+func newServiceRouterMerger(k8sClient client.Client, recorder record.EventRecorder) services.Merger {
+	merger := services.NewMerger(
+		k8sClient,
+		k8sClient,
+		logr.Discard(),
+		recorder,
+		nil,
+		nil,
+		routes.NewSortItemsFunc(routes.OrderingPriority),
+		adoption.ModeAdopt,
+		"Routes",
+		"Route",
+		reflect.TypeOf(consulk8s.ServiceRouter{}),
+	)
+
+	return merger
+}
+
+// newServiceRouterStrategyMerger creates the same merger as newServiceRouterMerger
+// with the typed service router strategy.
+func newServiceRouterStrategyMerger(k8sClient client.Client, recorder record.EventRecorder) services.Merger {
+	merger := services.NewStrategyMerger(
+		k8sClient,
+		k8sClient,
+		logr.Discard(),
+		recorder,
+		nil,
+		routes.NewSortItemsFunc(routes.OrderingPriority),
+		adoption.ModeAdopt,
+		strategies.NewServiceRouterStrategy(),
+	)
+
+	return merger
+}
+
+// expectGolden compares the YAML of obj with the golden file with the given name.
+// The golden file is rewritten instead when the tests are run with -update-golden.
+func expectGolden(name string, obj interface{}) {
+	actual, err := yaml.Marshal(obj)
+	Expect(err).NotTo(HaveOccurred())
+
+	path := filepath.Join("testdata", "merger", name)
+	if *updateGolden {
+		Expect(ioutil.WriteFile(path, actual, 0644)).To(Succeed())
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(string(actual)).To(Equal(string(expected)), "the merged destination differs from %s", path)
+}
+
+func drainEvents(recorder *record.FakeRecorder) []string {
+	events := []string{}
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+var _ = Describe("Merger", func() {
+	table.DescribeTable("Merge",
+		func(previousItems, items []client.Object, expectedEvents []string, golden string) {
+			// The property based merger and the typed strategy must produce the same destination.
+			for _, newMerger := range []func(client.Client, record.EventRecorder) services.Merger{newServiceRouterMerger, newServiceRouterStrategyMerger} {
+				ctx := context.Background()
+				k8sClient := newFakeClient()
+				recorder := record.NewFakeRecorder(100)
+				merger := newMerger(k8sClient, recorder)
+
+				if previousItems != nil {
+					res, err := merger.Merge(ctx, serviceRouterName, "default", previousItems)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res).To(BeNil())
+				}
+
+				previous := &consulk8s.ServiceRouter{}
+				previousErr := k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: serviceRouterName}, previous)
+				drainEvents(recorder)
+
+				res, err := merger.Merge(ctx, serviceRouterName, "default", items)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(res).To(BeNil())
+				Expect(drainEvents(recorder)).To(Equal(expectedEvents))
+
+				actual := &consulk8s.ServiceRouter{}
+				err = k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: serviceRouterName}, actual)
+				if len(golden) == 0 {
+					Expect(errors.IsNotFound(err)).To(BeTrue())
+
+					continue
+				}
+
+				Expect(err).NotTo(HaveOccurred())
+				if len(expectedEvents) == 0 {
+					Expect(previousErr).NotTo(HaveOccurred())
+					Expect(actual.ResourceVersion).To(Equal(previous.ResourceVersion))
+				}
+
+				actual.TypeMeta.Kind = "ServiceRouter"
+				actual.TypeMeta.APIVersion = consulk8s.GroupVersion.String()
+				actual.ResourceVersion = ""
+				expectGolden(golden, actual)
+			}
+		},
+		table.Entry("creates the destination from the items",
+			nil,
+			[]client.Object{newRoute("service-a-v1", "/v1", 0), newRoute("service-a-v2", "/v2", 10)},
+			[]string{"Normal Created created from ConsulServiceRoute service-a-v1, service-a-v2"},
+			"create.yaml",
+		),
+		table.Entry("updates the destination when the items are changed",
+			[]client.Object{newRoute("service-a-v1", "/v1", 0), newRoute("service-a-v2", "/v2", 10)},
+			[]client.Object{newRoute("service-a-v1", "/v1", 0), newRoute("service-a-v2", "/v2", 10), newRoute("service-a-v3", "/v3", 5)},
+			[]string{"Normal Updated updated from ConsulServiceRoute service-a-v1, service-a-v2, service-a-v3"},
+			"update.yaml",
+		),
+		table.Entry("doesn't change an up to date destination",
+			[]client.Object{newRoute("service-a-v1", "/v1", 0), newRoute("service-a-v2", "/v2", 10)},
+			[]client.Object{newRoute("service-a-v2", "/v2", 10), newRoute("service-a-v1", "/v1", 0)},
+			[]string{},
+			"create.yaml",
+		),
+		table.Entry("deletes the destination when no items are left",
+			[]client.Object{newRoute("service-a-v1", "/v1", 0)},
+			[]client.Object{},
+			[]string{"Normal Deleted deleted because no resources are merged into it"},
+			"",
+		),
+		table.Entry("doesn't create a destination without items",
+			nil,
+			[]client.Object{},
+			[]string{},
+			"",
+		),
+	)
+})
@@ -0,0 +1,172 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	e "github.com/NativeChat/consul-merge-controller/pkg/errors"
+	"github.com/go-logr/logr"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// propertyMergeStrategy is a MergeStrategy which looks up the merged properties by name.
+type propertyMergeStrategy struct {
+	mergeItem             MergeItemFunc
+	mergeIntoPropertyName string
+	mergeItemPropertyName string
+	mergeDestinationType  reflect.Type
+}
+
+func (s *propertyMergeStrategy) NewDestination() client.Object {
+	return reflect.New(s.mergeDestinationType).Interface().(client.Object)
+}
+
+// NewMergeItemFunc returns the custom MergeItemFunc if it is set. Otherwise the returned func merges
+// the item property into the destination property. Slices are appended to and maps are merged by key.
+func (s *propertyMergeStrategy) NewMergeItemFunc() MergeItemFunc {
+	if s.mergeItem != nil {
+		return s.mergeItem
+	}
+
+	mapKeyOwners := map[interface{}]string{}
+
+	mergeItem := func(expected client.Object, item client.Object) error {
+		mergeDestinationProp := s.getMergeDestinationProp(expected)
+		mergeItemProp := s.getSpec(item).FieldByName(s.mergeItemPropertyName)
+
+		if mergeDestinationProp.Kind() == reflect.Map {
+			return s.mergeIntoMap(mergeDestinationProp, mergeItemProp, item.GetName(), mapKeyOwners)
+		}
+
+		mergeDestinationProp.Set(reflect.Append(mergeDestinationProp, mergeItemProp))
+
+		return nil
+	}
+
+	return mergeItem
+}
+
+func (s *propertyMergeStrategy) GetSpec(destination client.Object) interface{} {
+	return s.getSpec(destination).Interface()
+}
+
+func (s *propertyMergeStrategy) SetSpec(destination client.Object, source client.Object) {
+	s.getSpec(destination).Set(s.getSpec(source))
+}
+
+func (s *propertyMergeStrategy) GetEntriesName() string {
+	return s.mergeIntoPropertyName
+}
+
+func (s *propertyMergeStrategy) GetEntries(destination client.Object) interface{} {
+	return s.getMergeDestinationProp(destination).Interface()
+}
+
+func (s *propertyMergeStrategy) CountEntries(destination client.Object) int {
+	return s.getMergeDestinationProp(destination).Len()
+}
+
+func (s *propertyMergeStrategy) AddUnmanagedEntries(destination client.Object, entries []byte) error {
+	mergeDestinationProp := s.getMergeDestinationProp(destination)
+	if mergeDestinationProp.Kind() != reflect.Slice {
+		return nil
+	}
+
+	unmanagedEntries := reflect.New(mergeDestinationProp.Type())
+	err := json.Unmarshal(entries, unmanagedEntries.Interface())
+	if err != nil {
+		return err
+	}
+
+	mergeDestinationProp.Set(reflect.AppendSlice(unmanagedEntries.Elem(), mergeDestinationProp))
+
+	return nil
+}
+
+// mergeIntoMap copies the entries of the item map into the destination map.
+// The same key can be set by more than one item only if all of them set the same value.
+func (s *propertyMergeStrategy) mergeIntoMap(destination reflect.Value, itemMap reflect.Value, itemName string, keyOwners map[interface{}]string) error {
+	if destination.IsNil() {
+		destination.Set(reflect.MakeMap(destination.Type()))
+	}
+
+	iter := itemMap.MapRange()
+	for iter.Next() {
+		key := iter.Key()
+		value := iter.Value()
+
+		existing := destination.MapIndex(key)
+		if existing.IsValid() && !reflect.DeepEqual(existing.Interface(), value.Interface()) {
+			owner := keyOwners[key.Interface()]
+			err := fmt.Errorf("%s %v is defined differently by %s and %s", s.mergeIntoPropertyName, key.Interface(), owner, itemName)
+
+			return e.NewConflictError(err, owner, itemName)
+		}
+
+		if !existing.IsValid() {
+			keyOwners[key.Interface()] = itemName
+		}
+
+		destination.SetMapIndex(key, value)
+	}
+
+	return nil
+}
+
+func (s *propertyMergeStrategy) getSpec(obj client.Object) reflect.Value {
+	spec := reflect.ValueOf(obj).Elem().FieldByName("Spec")
+
+	return spec
+}
+
+func (s *propertyMergeStrategy) getMergeDestinationProp(obj client.Object) reflect.Value {
+	destination := s.getSpec(obj).FieldByName(s.mergeIntoPropertyName)
+
+	return destination
+}
+
+// NewMerger creates new merger instance which looks up the merged properties by name.
+// Prefer NewStrategyMerger with a typed MergeStrategy, which doesn't fail at runtime when a property is renamed.
+// When mergeItem is nil the merge item property of each item is merged into the merge into property.
+// The items are merged in the order of their names unless sortItems is set.
+// When adoptionMode is empty the destinations which weren't created by the controller are overwritten.
+func NewMerger(
+	reader client.Reader,
+	writer client.Writer,
+	log logr.Logger,
+	recorder record.EventRecorder,
+	patchExpectedDefinition func(obj client.Object, items []client.Object) (client.Object, error),
+	mergeItem MergeItemFunc,
+	sortItems SortItemsFunc,
+	adoptionMode string,
+	mergeIntoPropertyName string,
+	mergeItemPropertyName string,
+	mergeDestinationType reflect.Type,
+) Merger {
+	strategy := &propertyMergeStrategy{
+		mergeItem:             mergeItem,
+		mergeIntoPropertyName: mergeIntoPropertyName,
+		mergeItemPropertyName: mergeItemPropertyName,
+		mergeDestinationType:  mergeDestinationType,
+	}
+
+	return NewStrategyMerger(reader, writer, log, recorder, patchExpectedDefinition, sortItems, adoptionMode, strategy)
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 
 	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -33,6 +34,8 @@ type ServiceRouterService interface {
 type CRDService interface {
 	GetResourceFromRequest(ctx context.Context, req ctrl.Request) (client.Object, *ctrl.Result, error)
 	GetAllResourcesForService(ctx context.Context, label, serviceName, namespace string) ([]client.Object, error)
+	// ListResources returns all resources for the service including the resources which are marked for deletion.
+	ListResources(ctx context.Context, label, serviceName, namespace string) ([]client.Object, error)
 	UpdateFinalizer(ctx context.Context, obj client.Object) error
 	IsDeleted(obj client.Object) bool
 	IsNew(obj client.Object) bool
@@ -40,9 +43,71 @@ type CRDService interface {
 	GetContentSHA(obj client.Object) string
 	SetUpdatedAt(obj client.Object, updatedAt string)
 	SetContentSHA(obj client.Object, contentSHA string)
+
+	// GetConditions returns the status conditions of the resource or nil if the resource has no conditions.
+	GetConditions(obj client.Object) *[]metav1.Condition
+
+	// SetObservedGeneration and SetDestination do nothing if the status of the resource has no such field.
+	SetObservedGeneration(obj client.Object, observedGeneration int64)
+	SetDestination(obj client.Object, destination *servicev1alpha1.DestinationReference)
+
+	// GetLastDestination returns the destination into which the resource was last merged or nil if it is unknown.
+	GetLastDestination(obj client.Object) *servicev1alpha1.DestinationReference
 }
 
 // Merger provides methods for merging items into a destination property of a k8s object.
 type Merger interface {
 	Merge(ctx context.Context, destinationResourceName, namespace string, items []client.Object) (*ctrl.Result, error)
+
+	// GetDestination returns the merge destination or nil if it doesn't exist.
+	GetDestination(ctx context.Context, destinationResourceName, namespace string) (client.Object, error)
+	GetDestinationReference(destinationResourceName, namespace string) *servicev1alpha1.DestinationReference
+}
+
+// MergeStrategy describes how the items are merged into a kind of destination.
+// Each destination kind implements it with typed access to its spec, so that the merger
+// doesn't look up the fields of the destination by name.
+type MergeStrategy interface {
+	// NewDestination returns an empty destination.
+	NewDestination() client.Object
+
+	// NewMergeItemFunc returns the MergeItemFunc for a single merge, so the func can keep state between the items.
+	NewMergeItemFunc() MergeItemFunc
+
+	// GetSpec returns the spec of the destination. The specs of the expected and the actual destinations are compared
+	// to check if the destination is up to date.
+	GetSpec(destination client.Object) interface{}
+
+	// SetSpec sets the spec of the destination to the spec of the source destination.
+	SetSpec(destination client.Object, source client.Object)
+
+	// GetEntriesName returns the name of the merged entries, e.g. Routes.
+	GetEntriesName() string
+
+	// GetEntries returns the entries which are merged into the destination.
+	GetEntries(destination client.Object) interface{}
+
+	// CountEntries returns the number of entries in the destination. A destination without entries is deleted.
+	CountEntries(destination client.Object) int
+
+	// AddUnmanagedEntries places the entries serialized as JSON before the merged entries of the destination.
+	// The destinations whose entries aren't a list ignore them.
+	AddUnmanagedEntries(destination client.Object, entries []byte) error
 }
+
+// MergeItemFunc merges a single item into the expected definition of the merge destination.
+type MergeItemFunc func(expected client.Object, item client.Object) error
+
+// SortItemsFunc sorts in place the items before they are merged into the merge destination.
+type SortItemsFunc func(items []client.Object)
+
+// Conflict describes an item which has no effect in its merge destination because of another item.
+type Conflict struct {
+	ConflictsWith string
+	Reason        string
+	Message       string
+}
+
+// FindConflictsFunc finds the items which have no effect in their merge destination because of another item.
+// The result is keyed by the name of the conflicting item. The items must not be reordered in place.
+type FindConflictsFunc func(items []client.Object) map[string]Conflict
@@ -0,0 +1,145 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package splits
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// WeightPolicyAccepted is the outcome for a split whose weight is written as is.
+	WeightPolicyAccepted = "Accepted"
+
+	// WeightPolicyRemainderAssigned is the outcome for the default split which receives the remainder of the weights.
+	WeightPolicyRemainderAssigned = "RemainderAssigned"
+
+	// WeightPolicyRejected is the outcome for all splits of a service splitter whose weights can't add up to 100.
+	WeightPolicyRejected = "Rejected"
+)
+
+// Consul keeps the weights with a precision of 0.01%,
+// so the policy works with integer weights in hundredths of a percent.
+const totalWeight = 100 * 100
+
+// Result is the outcome of the weight policy for a single ConsulServiceSplit.
+type Result struct {
+	WeightPolicy string
+	Weight       float32
+	Message      string
+}
+
+// EffectiveWeight returns the weight which is written in the service splitter
+// or an empty string if the split was rejected.
+func (r Result) EffectiveWeight() string {
+	if r.WeightPolicy == WeightPolicyRejected {
+		return ""
+	}
+
+	return strconv.FormatFloat(float64(r.Weight), 'f', -1, 32)
+}
+
+// ApplyWeightPolicy applies the weight policy to all splits of a service splitter.
+// The weights of the splits must add up to 100. When one of the splits is marked as default
+// it receives the remainder of the weights of the other splits.
+// When the weights can't add up to 100 all splits are rejected and an error is returned.
+// The results are keyed by the name of the ConsulServiceSplit.
+func ApplyWeightPolicy(items []client.Object) (map[string]Result, error) {
+	results := map[string]Result{}
+	if len(items) == 0 {
+		return results, nil
+	}
+
+	defaultSplits := []string{}
+	sum := 0
+	var err error
+	for _, item := range items {
+		split := item.(*servicev1alpha1.ConsulServiceSplit)
+		if split.Spec.Default {
+			defaultSplits = append(defaultSplits, split.Name)
+			continue
+		}
+
+		weight := toScaledWeight(split.Spec.Split.Weight)
+		if weight < 0 || weight > totalWeight {
+			err = fmt.Errorf("the weight of %s must be between 0 and 100", split.Name)
+			break
+		}
+
+		sum += weight
+	}
+
+	if err == nil {
+		switch {
+		case len(defaultSplits) > 1:
+			err = fmt.Errorf("only one default split is allowed, found %s", strings.Join(defaultSplits, ", "))
+		case len(defaultSplits) == 1 && sum > totalWeight:
+			err = fmt.Errorf("the weights add up to %s and leave no remainder for the default split %s", formatScaledWeight(sum), defaultSplits[0])
+		case len(defaultSplits) == 0 && sum != totalWeight:
+			err = fmt.Errorf("the weights add up to %s instead of 100 and there is no default split", formatScaledWeight(sum))
+		}
+	}
+
+	for _, item := range items {
+		split := item.(*servicev1alpha1.ConsulServiceSplit)
+
+		var result Result
+		switch {
+		case err != nil:
+			result = Result{WeightPolicy: WeightPolicyRejected, Message: err.Error()}
+		case split.Spec.Default:
+			result = Result{
+				WeightPolicy: WeightPolicyRemainderAssigned,
+				Weight:       fromScaledWeight(totalWeight - sum),
+				Message:      "the split receives the remainder of the weights",
+			}
+		default:
+			result = Result{
+				WeightPolicy: WeightPolicyAccepted,
+				Weight:       fromScaledWeight(toScaledWeight(split.Spec.Split.Weight)),
+				Message:      "the weights add up to 100",
+			}
+		}
+
+		results[split.Name] = result
+	}
+
+	return results, err
+}
+
+func toScaledWeight(weight float32) int {
+	scaled := int(math.Round(float64(weight) * 100))
+
+	return scaled
+}
+
+func fromScaledWeight(weight int) float32 {
+	result := float32(weight) / 100
+
+	return result
+}
+
+func formatScaledWeight(weight int) string {
+	result := strconv.FormatFloat(float64(fromScaledWeight(weight)), 'f', -1, 32)
+
+	return result
+}
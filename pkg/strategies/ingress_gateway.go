@@ -0,0 +1,238 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strategies
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	e "github.com/NativeChat/consul-merge-controller/pkg/errors"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const ingressListenerProtocolTCP = "tcp"
+
+// ConflictReasonDuplicateService is the reason for a ConsulIngressGatewayService whose service
+// is added to the same listener by an item before it.
+const ConflictReasonDuplicateService = "DuplicateService"
+
+// ingressServiceKey identifies a service in the listeners of an ingress gateway.
+type ingressServiceKey struct {
+	port      int
+	name      string
+	namespace string
+}
+
+func newIngressServiceKey(item client.Object) ingressServiceKey {
+	spec := item.(*v1alpha1.ConsulIngressGatewayService).Spec
+
+	return ingressServiceKey{port: spec.Port, name: spec.Service.Name, namespace: spec.Service.Namespace}
+}
+
+// ingressGatewayStrategy merges the service of each ConsulIngressGatewayService into the listeners of an IngressGateway.
+type ingressGatewayStrategy struct{}
+
+func (s *ingressGatewayStrategy) NewDestination() client.Object {
+	return &consulk8s.IngressGateway{}
+}
+
+// NewMergeItemFunc returns a MergeItemFunc which groups the ingress gateway services
+// into listeners by port and appends the services within each listener.
+// The items whose service is already added to the listener are skipped, they are reported by findIngressGatewayConflicts.
+func (s *ingressGatewayStrategy) NewMergeItemFunc() services.MergeItemFunc {
+	listenerOwners := map[int]string{}
+	serviceOwners := map[ingressServiceKey]string{}
+
+	mergeItem := func(expected client.Object, item client.Object) error {
+		ingressGateway := expected.(*consulk8s.IngressGateway)
+		spec := item.(*v1alpha1.ConsulIngressGatewayService).Spec
+
+		key := newIngressServiceKey(item)
+		if _, ok := serviceOwners[key]; ok {
+			return nil
+		}
+
+		protocol := getIngressListenerProtocol(spec.Protocol)
+
+		for i := range ingressGateway.Spec.Listeners {
+			listener := &ingressGateway.Spec.Listeners[i]
+			if listener.Port != spec.Port {
+				continue
+			}
+
+			owner := listenerOwners[listener.Port]
+			if listener.Protocol != protocol {
+				err := fmt.Errorf("listener %d has protocol %s in %s and %s in %s", listener.Port, listener.Protocol, owner, protocol, item.GetName())
+
+				return e.NewConflictError(err, owner, item.GetName())
+			}
+
+			if protocol == ingressListenerProtocolTCP {
+				err := fmt.Errorf("tcp listener %d supports a single service and is used by %s and %s", listener.Port, owner, item.GetName())
+
+				return e.NewConflictError(err, owner, item.GetName())
+			}
+
+			listener.Services = append(listener.Services, spec.Service)
+			serviceOwners[key] = item.GetName()
+
+			return nil
+		}
+
+		listenerOwners[spec.Port] = item.GetName()
+		serviceOwners[key] = item.GetName()
+		ingressGateway.Spec.Listeners = append(ingressGateway.Spec.Listeners, consulk8s.IngressListener{
+			Port:     spec.Port,
+			Protocol: protocol,
+			Services: []consulk8s.IngressService{spec.Service},
+		})
+
+		return nil
+	}
+
+	return mergeItem
+}
+
+func (s *ingressGatewayStrategy) GetSpec(destination client.Object) interface{} {
+	return destination.(*consulk8s.IngressGateway).Spec
+}
+
+func (s *ingressGatewayStrategy) SetSpec(destination client.Object, source client.Object) {
+	destination.(*consulk8s.IngressGateway).Spec = source.(*consulk8s.IngressGateway).Spec
+}
+
+func (s *ingressGatewayStrategy) GetEntriesName() string {
+	return "Listeners"
+}
+
+func (s *ingressGatewayStrategy) GetEntries(destination client.Object) interface{} {
+	return destination.(*consulk8s.IngressGateway).Spec.Listeners
+}
+
+func (s *ingressGatewayStrategy) CountEntries(destination client.Object) int {
+	return len(destination.(*consulk8s.IngressGateway).Spec.Listeners)
+}
+
+func (s *ingressGatewayStrategy) AddUnmanagedEntries(destination client.Object, entries []byte) error {
+	var unmanaged []consulk8s.IngressListener
+	err := json.Unmarshal(entries, &unmanaged)
+	if err != nil {
+		return err
+	}
+
+	// The listeners are merged by port because Consul rejects an ingress gateway with duplicate ports.
+	ingressGateway := destination.(*consulk8s.IngressGateway)
+	listeners := unmanaged
+	for _, listener := range ingressGateway.Spec.Listeners {
+		unmanagedListener := findIngressListener(listeners, listener.Port)
+		if unmanagedListener == nil {
+			listeners = append(listeners, listener)
+
+			continue
+		}
+
+		protocol := getIngressListenerProtocol(listener.Protocol)
+		if getIngressListenerProtocol(unmanagedListener.Protocol) != protocol {
+			return fmt.Errorf("listener %d has protocol %s in the unmanaged listeners and %s in the merged listeners",
+				listener.Port, getIngressListenerProtocol(unmanagedListener.Protocol), protocol)
+		}
+
+		if protocol == ingressListenerProtocolTCP {
+			return fmt.Errorf("tcp listener %d supports a single service and is used by the unmanaged and the merged listeners", listener.Port)
+		}
+
+		for _, service := range listener.Services {
+			if findIngressService(unmanagedListener.Services, service) != nil {
+				return fmt.Errorf("service %s is in the unmanaged and the merged listener %d", service.Name, listener.Port)
+			}
+
+			unmanagedListener.Services = append(unmanagedListener.Services, service)
+		}
+	}
+
+	ingressGateway.Spec.Listeners = listeners
+
+	return nil
+}
+
+// getIngressListenerProtocol returns the protocol of a listener, Consul uses tcp when it isn't set.
+func getIngressListenerProtocol(protocol string) string {
+	if len(protocol) == 0 {
+		return ingressListenerProtocolTCP
+	}
+
+	return protocol
+}
+
+func findIngressListener(listeners []consulk8s.IngressListener, port int) *consulk8s.IngressListener {
+	for i := range listeners {
+		if listeners[i].Port == port {
+			return &listeners[i]
+		}
+	}
+
+	return nil
+}
+
+func findIngressService(services []consulk8s.IngressService, service consulk8s.IngressService) *consulk8s.IngressService {
+	for i := range services {
+		if services[i].Name == service.Name && services[i].Namespace == service.Namespace {
+			return &services[i]
+		}
+	}
+
+	return nil
+}
+
+// findIngressGatewayConflicts finds the ConsulIngressGatewayService items whose service is added
+// to the listener with the same port by an item before them. The items are merged in the order of their names.
+// The result is keyed by the name of the conflicting item.
+func findIngressGatewayConflicts(items []client.Object) map[string]services.Conflict {
+	items = append([]client.Object{}, items...)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].GetName() < items[j].GetName()
+	})
+
+	conflicts := map[string]services.Conflict{}
+	serviceOwners := map[ingressServiceKey]string{}
+	for _, item := range items {
+		key := newIngressServiceKey(item)
+		owner, ok := serviceOwners[key]
+		if !ok {
+			serviceOwners[key] = item.GetName()
+
+			continue
+		}
+
+		conflicts[item.GetName()] = services.Conflict{
+			ConflictsWith: owner,
+			Reason:        ConflictReasonDuplicateService,
+			Message:       fmt.Sprintf("the service is skipped because %s already adds service %s to listener %d", owner, key.name, key.port),
+		}
+	}
+
+	return conflicts
+}
+
+// NewIngressGatewayStrategy returns a MergeStrategy which merges ConsulIngressGatewayServices into an IngressGateway.
+func NewIngressGatewayStrategy() services.MergeStrategy {
+	return &ingressGatewayStrategy{}
+}
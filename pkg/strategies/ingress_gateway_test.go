@@ -0,0 +1,133 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strategies_test
+
+import (
+	"encoding/json"
+
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/strategies"
+)
+
+func newIngressListener(port int, protocol string, services ...string) consulk8s.IngressListener {
+	listener := consulk8s.IngressListener{Port: port, Protocol: protocol}
+	for _, service := range services {
+		listener.Services = append(listener.Services, consulk8s.IngressService{Name: service})
+	}
+
+	return listener
+}
+
+func newIngressGatewayService(name string, port int, protocol, service string) *v1alpha1.ConsulIngressGatewayService {
+	ingressGatewayService := &v1alpha1.ConsulIngressGatewayService{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1alpha1.ConsulIngressGatewayServiceSpec{
+			Port:     port,
+			Protocol: protocol,
+			Service:  consulk8s.IngressService{Name: service},
+		},
+	}
+
+	return ingressGatewayService
+}
+
+var _ = Describe("Ingress gateway strategy", func() {
+	Context("Conflicts", func() {
+		items := []client.Object{
+			newIngressGatewayService("c-service-a", 8080, "http", "service-a"),
+			newIngressGatewayService("a-service-a", 8080, "http", "service-a"),
+			newIngressGatewayService("b-service-a", 9090, "http", "service-a"),
+			newIngressGatewayService("d-service-b", 8080, "http", "service-b"),
+		}
+
+		It("skips the later items which add the same service to the same listener", func() {
+			ingressGateway := &consulk8s.IngressGateway{}
+			mergeItem := strategies.NewIngressGatewayStrategy().NewMergeItemFunc()
+			for _, name := range []string{"a-service-a", "b-service-a", "c-service-a", "d-service-b"} {
+				for _, item := range items {
+					if item.GetName() == name {
+						Expect(mergeItem(ingressGateway, item)).To(Succeed())
+					}
+				}
+			}
+
+			Expect(ingressGateway.Spec.Listeners).To(Equal([]consulk8s.IngressListener{
+				newIngressListener(8080, "http", "service-a", "service-b"),
+				newIngressListener(9090, "http", "service-a"),
+			}))
+		})
+
+		It("reports the later items which add the same service to the same listener", func() {
+			findConflicts := strategies.GetMergeKind(controllerlabels.IngressGateway).NewFindConflictsFunc(strategies.MergeOptions{})
+			Expect(findConflicts).NotTo(BeNil())
+
+			conflicts := findConflicts(items)
+			Expect(conflicts).To(HaveLen(1))
+			Expect(conflicts).To(HaveKey("c-service-a"))
+			Expect(conflicts["c-service-a"].ConflictsWith).To(Equal("a-service-a"))
+			Expect(conflicts["c-service-a"].Reason).To(Equal(strategies.ConflictReasonDuplicateService))
+		})
+	})
+
+	Context("AddUnmanagedEntries", func() {
+		addUnmanagedEntries := func(merged []consulk8s.IngressListener, unmanaged []consulk8s.IngressListener) (*consulk8s.IngressGateway, error) {
+			entries, err := json.Marshal(unmanaged)
+			Expect(err).NotTo(HaveOccurred())
+
+			ingressGateway := &consulk8s.IngressGateway{Spec: consulk8s.IngressGatewaySpec{Listeners: merged}}
+			err = strategies.NewIngressGatewayStrategy().AddUnmanagedEntries(ingressGateway, entries)
+
+			return ingressGateway, err
+		}
+
+		It("merges the listeners with the same port", func() {
+			ingressGateway, err := addUnmanagedEntries(
+				[]consulk8s.IngressListener{
+					newIngressListener(8080, "http", "service-b"),
+					newIngressListener(9090, "http", "service-c"),
+				},
+				[]consulk8s.IngressListener{
+					newIngressListener(7070, "tcp", "service-d"),
+					newIngressListener(8080, "http", "service-a"),
+				})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ingressGateway.Spec.Listeners).To(Equal([]consulk8s.IngressListener{
+				newIngressListener(7070, "tcp", "service-d"),
+				newIngressListener(8080, "http", "service-a", "service-b"),
+				newIngressListener(9090, "http", "service-c"),
+			}))
+		})
+
+		DescribeTable("fails for listeners which can't be merged",
+			func(merged consulk8s.IngressListener, unmanaged consulk8s.IngressListener) {
+				_, err := addUnmanagedEntries([]consulk8s.IngressListener{merged}, []consulk8s.IngressListener{unmanaged})
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("different protocols", newIngressListener(8080, "http", "service-b"), newIngressListener(8080, "http2", "service-a")),
+			Entry("tcp listeners", newIngressListener(8080, "tcp", "service-b"), newIngressListener(8080, "", "service-a")),
+			Entry("the same service", newIngressListener(8080, "http", "service-a"), newIngressListener(8080, "http", "service-a")),
+		)
+	})
+})
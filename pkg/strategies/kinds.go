@@ -0,0 +1,220 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strategies
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/adoption"
+	e "github.com/NativeChat/consul-merge-controller/pkg/errors"
+	"github.com/NativeChat/consul-merge-controller/pkg/finalizers"
+	"github.com/NativeChat/consul-merge-controller/pkg/intentions"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/routes"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	"github.com/NativeChat/consul-merge-controller/pkg/splits"
+)
+
+// MergeOptions are the options of the controller which change how the resources are merged.
+type MergeOptions struct {
+	// AdoptionMode is used for the kinds which support adoption. The destinations of the other kinds are overwritten.
+	AdoptionMode string
+
+	// RouteOrdering is the default ordering of the routes. Defaults to routes.OrderingPriority.
+	RouteOrdering string
+
+	ExcludeConflictingRoutes bool
+}
+
+// MergeKind describes how the resources of one kind are merged into their destinations.
+// The controllers and the render command are created from the same kinds, so they merge the resources the same way.
+type MergeKind struct {
+	// QueryLabel is the label of the resources with the name of their destination.
+	QueryLabel string
+
+	ResourceType     reflect.Type
+	ResourceListType reflect.Type
+
+	// SupportsAdoption is true when the destinations which weren't created by the controller are handled
+	// with the adoption mode of the options.
+	SupportsAdoption bool
+
+	newStrategy          func() services.MergeStrategy
+	newPatchFunc         func(options MergeOptions) func(obj client.Object, items []client.Object) (client.Object, error)
+	newSortItemsFunc     func(options MergeOptions) services.SortItemsFunc
+	newFindConflictsFunc func(options MergeOptions) services.FindConflictsFunc
+}
+
+// NewResource returns an empty resource of the kind.
+func (k MergeKind) NewResource() client.Object {
+	return reflect.New(k.ResourceType).Interface().(client.Object)
+}
+
+// NewStrategy returns the strategy which merges the resources into their destination.
+func (k MergeKind) NewStrategy() services.MergeStrategy {
+	return k.newStrategy()
+}
+
+// NewMerger creates a merger which merges the resources of the kind.
+func (k MergeKind) NewMerger(reader client.Reader, writer client.Writer, log logr.Logger, recorder record.EventRecorder, options MergeOptions) services.Merger {
+	var patch func(obj client.Object, items []client.Object) (client.Object, error)
+	if k.newPatchFunc != nil {
+		patch = k.newPatchFunc(options)
+	}
+
+	var sortItems services.SortItemsFunc
+	if k.newSortItemsFunc != nil {
+		sortItems = k.newSortItemsFunc(options)
+	}
+
+	adoptionMode := adoption.ModeOverwrite
+	if k.SupportsAdoption {
+		adoptionMode = options.AdoptionMode
+	}
+
+	return services.NewStrategyMerger(reader, writer, log, recorder, patch, sortItems, adoptionMode, k.newStrategy())
+}
+
+// NewCRDService creates a CRDService for the resources of the kind.
+func (k MergeKind) NewCRDService(reader client.Reader, writer client.Writer, log logr.Logger) services.CRDService {
+	return services.NewCRDService(reader, writer, log, finalizers.FinalizerName, k.ResourceType, k.ResourceListType)
+}
+
+// NewFindConflictsFunc returns the FindConflictsFunc of the kind or nil if the resources of the kind don't conflict.
+func (k MergeKind) NewFindConflictsFunc(options MergeOptions) services.FindConflictsFunc {
+	if k.newFindConflictsFunc == nil {
+		return nil
+	}
+
+	return k.newFindConflictsFunc(options)
+}
+
+// newMergeKinds returns the merge kinds sorted by the kind of their destination.
+// The kinds are created on each call because the query labels change when the label prefix is set.
+func newMergeKinds() []MergeKind {
+	return []MergeKind{
+		{
+			QueryLabel:       controllerlabels.IngressGateway,
+			ResourceType:     reflect.TypeOf(v1alpha1.ConsulIngressGatewayService{}),
+			ResourceListType: reflect.TypeOf(v1alpha1.ConsulIngressGatewayServiceList{}),
+			newStrategy:      NewIngressGatewayStrategy,
+			newFindConflictsFunc: func(options MergeOptions) services.FindConflictsFunc {
+				return findIngressGatewayConflicts
+			},
+		},
+		{
+			QueryLabel:       controllerlabels.ServiceIntentions,
+			ResourceType:     reflect.TypeOf(v1alpha1.ConsulServiceIntentionsSource{}),
+			ResourceListType: reflect.TypeOf(v1alpha1.ConsulServiceIntentionsSourceList{}),
+			SupportsAdoption: true,
+			newStrategy:      NewServiceIntentionsStrategy,
+			newPatchFunc: func(options MergeOptions) func(obj client.Object, items []client.Object) (client.Object, error) {
+				return intentions.SetDestinationName
+			},
+		},
+		{
+			QueryLabel:       controllerlabels.ServiceResolver,
+			ResourceType:     reflect.TypeOf(v1alpha1.ConsulServiceResolverSubset{}),
+			ResourceListType: reflect.TypeOf(v1alpha1.ConsulServiceResolverSubsetList{}),
+			newStrategy:      NewServiceResolverStrategy,
+		},
+		{
+			QueryLabel:       controllerlabels.ServiceRouter,
+			ResourceType:     reflect.TypeOf(v1alpha1.ConsulServiceRoute{}),
+			ResourceListType: reflect.TypeOf(v1alpha1.ConsulServiceRouteList{}),
+			SupportsAdoption: true,
+			newStrategy:      NewServiceRouterStrategy,
+			newPatchFunc: func(options MergeOptions) func(obj client.Object, items []client.Object) (client.Object, error) {
+				if !options.ExcludeConflictingRoutes {
+					return nil
+				}
+
+				return routes.ExcludeConflicts
+			},
+			newSortItemsFunc: func(options MergeOptions) services.SortItemsFunc {
+				return routes.NewSortItemsFunc(getRouteOrdering(options))
+			},
+			newFindConflictsFunc: func(options MergeOptions) services.FindConflictsFunc {
+				return routes.NewFindConflictsFunc(getRouteOrdering(options))
+			},
+		},
+		{
+			QueryLabel:       controllerlabels.ServiceSplitter,
+			ResourceType:     reflect.TypeOf(v1alpha1.ConsulServiceSplit{}),
+			ResourceListType: reflect.TypeOf(v1alpha1.ConsulServiceSplitList{}),
+			newStrategy:      NewServiceSplitterStrategy,
+			newPatchFunc: func(options MergeOptions) func(obj client.Object, items []client.Object) (client.Object, error) {
+				return applyWeightPolicy
+			},
+		},
+		{
+			QueryLabel:       controllerlabels.TerminatingGateway,
+			ResourceType:     reflect.TypeOf(v1alpha1.ConsulTerminatingGatewayService{}),
+			ResourceListType: reflect.TypeOf(v1alpha1.ConsulTerminatingGatewayServiceList{}),
+			newStrategy:      NewTerminatingGatewayStrategy,
+		},
+	}
+}
+
+// GetMergeKinds returns the kinds merged by the controllers sorted by the kind of their destination.
+func GetMergeKinds() []MergeKind {
+	return newMergeKinds()
+}
+
+// GetMergeKind returns the kind whose resources select their destination with the query label.
+// It panics for an unknown label.
+func GetMergeKind(queryLabel string) MergeKind {
+	for _, kind := range newMergeKinds() {
+		if kind.QueryLabel == queryLabel {
+			return kind
+		}
+	}
+
+	panic(fmt.Sprintf("no merge kind for the label %s", queryLabel))
+}
+
+func getRouteOrdering(options MergeOptions) string {
+	if len(options.RouteOrdering) == 0 {
+		return routes.OrderingPriority
+	}
+
+	return options.RouteOrdering
+}
+
+// applyWeightPolicy sets the weights of the splits of the service splitter after the weight policy of the items is applied.
+func applyWeightPolicy(obj client.Object, items []client.Object) (client.Object, error) {
+	serviceSplitter := obj.(*consulk8s.ServiceSplitter)
+
+	results, err := splits.ApplyWeightPolicy(items)
+	if err != nil {
+		return nil, e.NewReconcileError(err, false)
+	}
+
+	// The splits are appended in the order of the items.
+	for i, item := range items {
+		serviceSplitter.Spec.Splits[i].Weight = results[item.GetName()].Weight
+	}
+
+	return serviceSplitter, nil
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strategies_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/strategies"
+)
+
+var _ = Describe("Merge kinds", func() {
+	AfterEach(func() {
+		controllerlabels.SetPrefix(servicev1alpha1.GroupVersion.Group)
+	})
+
+	It("returns a kind for each query label", func() {
+		for _, kind := range strategies.GetMergeKinds() {
+			Expect(strategies.GetMergeKind(kind.QueryLabel).ResourceType).To(Equal(kind.ResourceType))
+		}
+	})
+
+	It("uses the query labels with the prefix which is set", func() {
+		controllerlabels.SetPrefix("example.com")
+
+		kind := strategies.GetMergeKind(controllerlabels.ServiceRouter)
+		Expect(kind.QueryLabel).To(Equal("example.com/service-router"))
+		Expect(kind.NewResource()).To(BeAssignableToTypeOf(&servicev1alpha1.ConsulServiceRoute{}))
+	})
+})
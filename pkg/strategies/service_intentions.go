@@ -0,0 +1,109 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strategies
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/NativeChat/consul-merge-controller/pkg/intentions"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// serviceIntentionsStrategy merges the source of each ConsulServiceIntentionsSource into the sources of a ServiceIntentions.
+type serviceIntentionsStrategy struct{}
+
+func (s *serviceIntentionsStrategy) NewDestination() client.Object {
+	return &consulk8s.ServiceIntentions{}
+}
+
+// NewMergeItemFunc returns a MergeItemFunc which groups the sources by name and namespace.
+func (s *serviceIntentionsStrategy) NewMergeItemFunc() services.MergeItemFunc {
+	return intentions.NewMergeItemFunc()
+}
+
+func (s *serviceIntentionsStrategy) GetSpec(destination client.Object) interface{} {
+	return destination.(*consulk8s.ServiceIntentions).Spec
+}
+
+func (s *serviceIntentionsStrategy) SetSpec(destination client.Object, source client.Object) {
+	destination.(*consulk8s.ServiceIntentions).Spec = source.(*consulk8s.ServiceIntentions).Spec
+}
+
+func (s *serviceIntentionsStrategy) GetEntriesName() string {
+	return "Sources"
+}
+
+func (s *serviceIntentionsStrategy) GetEntries(destination client.Object) interface{} {
+	return destination.(*consulk8s.ServiceIntentions).Spec.Sources
+}
+
+func (s *serviceIntentionsStrategy) CountEntries(destination client.Object) int {
+	return len(destination.(*consulk8s.ServiceIntentions).Spec.Sources)
+}
+
+func (s *serviceIntentionsStrategy) AddUnmanagedEntries(destination client.Object, entries []byte) error {
+	var unmanaged consulk8s.SourceIntentions
+	err := json.Unmarshal(entries, &unmanaged)
+	if err != nil {
+		return err
+	}
+
+	// The sources are grouped by name and namespace because Consul rejects service intentions with duplicate sources.
+	serviceIntentions := destination.(*consulk8s.ServiceIntentions)
+	sources := unmanaged
+	for _, source := range serviceIntentions.Spec.Sources {
+		unmanagedSource := findSourceIntention(sources, source)
+		if unmanagedSource == nil {
+			sources = append(sources, source)
+
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s", source.Namespace, source.Name)
+		if len(unmanagedSource.Action) > 0 && len(source.Permissions) > 0 || len(unmanagedSource.Permissions) > 0 && len(source.Action) > 0 {
+			return fmt.Errorf("source %s has action in one of the unmanaged and the merged sources and permissions in the other", key)
+		}
+
+		if unmanagedSource.Action != source.Action {
+			return fmt.Errorf("source %s has action %s in the unmanaged sources and %s in the merged sources", key, unmanagedSource.Action, source.Action)
+		}
+
+		unmanagedSource.Permissions = append(unmanagedSource.Permissions, source.Permissions...)
+	}
+
+	serviceIntentions.Spec.Sources = sources
+
+	return nil
+}
+
+func findSourceIntention(sources consulk8s.SourceIntentions, source *consulk8s.SourceIntention) *consulk8s.SourceIntention {
+	for _, existing := range sources {
+		if existing.Name == source.Name && existing.Namespace == source.Namespace {
+			return existing
+		}
+	}
+
+	return nil
+}
+
+// NewServiceIntentionsStrategy returns a MergeStrategy which merges ConsulServiceIntentionsSources into a ServiceIntentions.
+func NewServiceIntentionsStrategy() services.MergeStrategy {
+	return &serviceIntentionsStrategy{}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strategies_test
+
+import (
+	"encoding/json"
+
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/NativeChat/consul-merge-controller/pkg/strategies"
+)
+
+func newSourceIntention(name, action string, pathPrefixes ...string) *consulk8s.SourceIntention {
+	source := &consulk8s.SourceIntention{Name: name, Namespace: "default", Action: consulk8s.IntentionAction(action)}
+	for _, pathPrefix := range pathPrefixes {
+		permission := &consulk8s.IntentionPermission{Action: "allow", HTTP: &consulk8s.IntentionHTTPPermission{PathPrefix: pathPrefix}}
+		source.Permissions = append(source.Permissions, permission)
+	}
+
+	return source
+}
+
+var _ = Describe("Service intentions strategy", func() {
+	Context("AddUnmanagedEntries", func() {
+		addUnmanagedEntries := func(merged consulk8s.SourceIntentions, unmanaged consulk8s.SourceIntentions) (*consulk8s.ServiceIntentions, error) {
+			entries, err := json.Marshal(unmanaged)
+			Expect(err).NotTo(HaveOccurred())
+
+			serviceIntentions := &consulk8s.ServiceIntentions{Spec: consulk8s.ServiceIntentionsSpec{Sources: merged}}
+			err = strategies.NewServiceIntentionsStrategy().AddUnmanagedEntries(serviceIntentions, entries)
+
+			return serviceIntentions, err
+		}
+
+		It("merges the sources with the same name and namespace", func() {
+			serviceIntentions, err := addUnmanagedEntries(
+				consulk8s.SourceIntentions{
+					newSourceIntention("service-b", "", "/v2"),
+					newSourceIntention("service-c", "allow"),
+					newSourceIntention("service-d", "deny"),
+				},
+				consulk8s.SourceIntentions{
+					newSourceIntention("service-a", "allow"),
+					newSourceIntention("service-b", "", "/v1"),
+					newSourceIntention("service-d", "deny"),
+				})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceIntentions.Spec.Sources).To(Equal(consulk8s.SourceIntentions{
+				newSourceIntention("service-a", "allow"),
+				newSourceIntention("service-b", "", "/v1", "/v2"),
+				newSourceIntention("service-d", "deny"),
+				newSourceIntention("service-c", "allow"),
+			}))
+		})
+
+		It("keeps the sources with the same name in different namespaces", func() {
+			unmanaged := newSourceIntention("service-a", "allow")
+			unmanaged.Namespace = "other"
+
+			serviceIntentions, err := addUnmanagedEntries(
+				consulk8s.SourceIntentions{newSourceIntention("service-a", "deny")},
+				consulk8s.SourceIntentions{unmanaged})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(serviceIntentions.Spec.Sources).To(HaveLen(2))
+		})
+
+		DescribeTable("fails for sources which can't be merged",
+			func(merged *consulk8s.SourceIntention, unmanaged *consulk8s.SourceIntention) {
+				_, err := addUnmanagedEntries(consulk8s.SourceIntentions{merged}, consulk8s.SourceIntentions{unmanaged})
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("different actions", newSourceIntention("service-a", "allow"), newSourceIntention("service-a", "deny")),
+			Entry("an action and permissions", newSourceIntention("service-a", "allow"), newSourceIntention("service-a", "", "/v1")),
+			Entry("permissions and an action", newSourceIntention("service-a", "", "/v1"), newSourceIntention("service-a", "deny")),
+		)
+	})
+})
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strategies
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	e "github.com/NativeChat/consul-merge-controller/pkg/errors"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// serviceResolverStrategy merges the subsets of each ConsulServiceResolverSubset into the subsets of a ServiceResolver.
+type serviceResolverStrategy struct{}
+
+func (s *serviceResolverStrategy) NewDestination() client.Object {
+	return &consulk8s.ServiceResolver{}
+}
+
+// NewMergeItemFunc returns a MergeItemFunc which merges the subsets by name.
+// The same subset can be set by more than one item only if all of them set the same value.
+func (s *serviceResolverStrategy) NewMergeItemFunc() services.MergeItemFunc {
+	subsetOwners := map[string]string{}
+
+	mergeItem := func(expected client.Object, item client.Object) error {
+		serviceResolver := expected.(*consulk8s.ServiceResolver)
+		subsets := item.(*v1alpha1.ConsulServiceResolverSubset).Spec.Subsets
+		if serviceResolver.Spec.Subsets == nil {
+			serviceResolver.Spec.Subsets = consulk8s.ServiceResolverSubsetMap{}
+		}
+
+		// The subsets are merged by name, so the same conflict is reported for any order of the subsets.
+		names := []string{}
+		for name := range subsets {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			existing, ok := serviceResolver.Spec.Subsets[name]
+			if ok && !reflect.DeepEqual(existing, subsets[name]) {
+				owner := subsetOwners[name]
+				err := fmt.Errorf("%s %s is defined differently by %s and %s", s.GetEntriesName(), name, owner, item.GetName())
+
+				return e.NewConflictError(err, owner, item.GetName())
+			}
+
+			if !ok {
+				subsetOwners[name] = item.GetName()
+			}
+
+			serviceResolver.Spec.Subsets[name] = subsets[name]
+		}
+
+		return nil
+	}
+
+	return mergeItem
+}
+
+func (s *serviceResolverStrategy) GetSpec(destination client.Object) interface{} {
+	return destination.(*consulk8s.ServiceResolver).Spec
+}
+
+func (s *serviceResolverStrategy) SetSpec(destination client.Object, source client.Object) {
+	destination.(*consulk8s.ServiceResolver).Spec = source.(*consulk8s.ServiceResolver).Spec
+}
+
+func (s *serviceResolverStrategy) GetEntriesName() string {
+	return "Subsets"
+}
+
+func (s *serviceResolverStrategy) GetEntries(destination client.Object) interface{} {
+	return destination.(*consulk8s.ServiceResolver).Spec.Subsets
+}
+
+func (s *serviceResolverStrategy) CountEntries(destination client.Object) int {
+	return len(destination.(*consulk8s.ServiceResolver).Spec.Subsets)
+}
+
+// AddUnmanagedEntries ignores the entries, because the subsets of a service resolver aren't a list.
+func (s *serviceResolverStrategy) AddUnmanagedEntries(destination client.Object, entries []byte) error {
+	return nil
+}
+
+// NewServiceResolverStrategy returns a MergeStrategy which merges ConsulServiceResolverSubsets into a ServiceResolver.
+func NewServiceResolverStrategy() services.MergeStrategy {
+	return &serviceResolverStrategy{}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strategies
+
+import (
+	"encoding/json"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// serviceRouterStrategy merges the route of each ConsulServiceRoute into the routes of a ServiceRouter.
+type serviceRouterStrategy struct{}
+
+func (s *serviceRouterStrategy) NewDestination() client.Object {
+	return &consulk8s.ServiceRouter{}
+}
+
+func (s *serviceRouterStrategy) NewMergeItemFunc() services.MergeItemFunc {
+	mergeItem := func(expected client.Object, item client.Object) error {
+		serviceRouter := expected.(*consulk8s.ServiceRouter)
+		serviceRouter.Spec.Routes = append(serviceRouter.Spec.Routes, item.(*v1alpha1.ConsulServiceRoute).Spec.Route)
+
+		return nil
+	}
+
+	return mergeItem
+}
+
+func (s *serviceRouterStrategy) GetSpec(destination client.Object) interface{} {
+	return destination.(*consulk8s.ServiceRouter).Spec
+}
+
+func (s *serviceRouterStrategy) SetSpec(destination client.Object, source client.Object) {
+	destination.(*consulk8s.ServiceRouter).Spec = source.(*consulk8s.ServiceRouter).Spec
+}
+
+func (s *serviceRouterStrategy) GetEntriesName() string {
+	return "Routes"
+}
+
+func (s *serviceRouterStrategy) GetEntries(destination client.Object) interface{} {
+	return destination.(*consulk8s.ServiceRouter).Spec.Routes
+}
+
+func (s *serviceRouterStrategy) CountEntries(destination client.Object) int {
+	return len(destination.(*consulk8s.ServiceRouter).Spec.Routes)
+}
+
+func (s *serviceRouterStrategy) AddUnmanagedEntries(destination client.Object, entries []byte) error {
+	var unmanaged []consulk8s.ServiceRoute
+	err := json.Unmarshal(entries, &unmanaged)
+	if err != nil {
+		return err
+	}
+
+	serviceRouter := destination.(*consulk8s.ServiceRouter)
+	serviceRouter.Spec.Routes = append(unmanaged, serviceRouter.Spec.Routes...)
+
+	return nil
+}
+
+// NewServiceRouterStrategy returns a MergeStrategy which merges ConsulServiceRoutes into a ServiceRouter.
+func NewServiceRouterStrategy() services.MergeStrategy {
+	return &serviceRouterStrategy{}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strategies
+
+import (
+	"encoding/json"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// serviceSplitterStrategy merges the split of each ConsulServiceSplit into the splits of a ServiceSplitter.
+type serviceSplitterStrategy struct{}
+
+func (s *serviceSplitterStrategy) NewDestination() client.Object {
+	return &consulk8s.ServiceSplitter{}
+}
+
+func (s *serviceSplitterStrategy) NewMergeItemFunc() services.MergeItemFunc {
+	mergeItem := func(expected client.Object, item client.Object) error {
+		serviceSplitter := expected.(*consulk8s.ServiceSplitter)
+		serviceSplitter.Spec.Splits = append(serviceSplitter.Spec.Splits, item.(*v1alpha1.ConsulServiceSplit).Spec.Split)
+
+		return nil
+	}
+
+	return mergeItem
+}
+
+func (s *serviceSplitterStrategy) GetSpec(destination client.Object) interface{} {
+	return destination.(*consulk8s.ServiceSplitter).Spec
+}
+
+func (s *serviceSplitterStrategy) SetSpec(destination client.Object, source client.Object) {
+	destination.(*consulk8s.ServiceSplitter).Spec = source.(*consulk8s.ServiceSplitter).Spec
+}
+
+func (s *serviceSplitterStrategy) GetEntriesName() string {
+	return "Splits"
+}
+
+func (s *serviceSplitterStrategy) GetEntries(destination client.Object) interface{} {
+	return destination.(*consulk8s.ServiceSplitter).Spec.Splits
+}
+
+func (s *serviceSplitterStrategy) CountEntries(destination client.Object) int {
+	return len(destination.(*consulk8s.ServiceSplitter).Spec.Splits)
+}
+
+func (s *serviceSplitterStrategy) AddUnmanagedEntries(destination client.Object, entries []byte) error {
+	var unmanaged consulk8s.ServiceSplits
+	err := json.Unmarshal(entries, &unmanaged)
+	if err != nil {
+		return err
+	}
+
+	serviceSplitter := destination.(*consulk8s.ServiceSplitter)
+	serviceSplitter.Spec.Splits = append(unmanaged, serviceSplitter.Spec.Splits...)
+
+	return nil
+}
+
+// NewServiceSplitterStrategy returns a MergeStrategy which merges ConsulServiceSplits into a ServiceSplitter.
+func NewServiceSplitterStrategy() services.MergeStrategy {
+	return &serviceSplitterStrategy{}
+}
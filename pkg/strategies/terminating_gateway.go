@@ -0,0 +1,83 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package strategies
+
+import (
+	"encoding/json"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"github.com/NativeChat/consul-merge-controller/pkg/services"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// terminatingGatewayStrategy merges the service of each ConsulTerminatingGatewayService into the services of a TerminatingGateway.
+type terminatingGatewayStrategy struct{}
+
+func (s *terminatingGatewayStrategy) NewDestination() client.Object {
+	return &consulk8s.TerminatingGateway{}
+}
+
+func (s *terminatingGatewayStrategy) NewMergeItemFunc() services.MergeItemFunc {
+	mergeItem := func(expected client.Object, item client.Object) error {
+		terminatingGateway := expected.(*consulk8s.TerminatingGateway)
+		service := item.(*v1alpha1.ConsulTerminatingGatewayService).Spec.Service
+		terminatingGateway.Spec.Services = append(terminatingGateway.Spec.Services, service)
+
+		return nil
+	}
+
+	return mergeItem
+}
+
+func (s *terminatingGatewayStrategy) GetSpec(destination client.Object) interface{} {
+	return destination.(*consulk8s.TerminatingGateway).Spec
+}
+
+func (s *terminatingGatewayStrategy) SetSpec(destination client.Object, source client.Object) {
+	destination.(*consulk8s.TerminatingGateway).Spec = source.(*consulk8s.TerminatingGateway).Spec
+}
+
+func (s *terminatingGatewayStrategy) GetEntriesName() string {
+	return "Services"
+}
+
+func (s *terminatingGatewayStrategy) GetEntries(destination client.Object) interface{} {
+	return destination.(*consulk8s.TerminatingGateway).Spec.Services
+}
+
+func (s *terminatingGatewayStrategy) CountEntries(destination client.Object) int {
+	return len(destination.(*consulk8s.TerminatingGateway).Spec.Services)
+}
+
+func (s *terminatingGatewayStrategy) AddUnmanagedEntries(destination client.Object, entries []byte) error {
+	var unmanaged []consulk8s.LinkedService
+	err := json.Unmarshal(entries, &unmanaged)
+	if err != nil {
+		return err
+	}
+
+	terminatingGateway := destination.(*consulk8s.TerminatingGateway)
+	terminatingGateway.Spec.Services = append(unmanaged, terminatingGateway.Spec.Services...)
+
+	return nil
+}
+
+// NewTerminatingGatewayStrategy returns a MergeStrategy which merges ConsulTerminatingGatewayServices into a TerminatingGateway.
+func NewTerminatingGatewayStrategy() services.MergeStrategy {
+	return &terminatingGatewayStrategy{}
+}
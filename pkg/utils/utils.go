@@ -18,6 +18,9 @@ package utils
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -46,3 +49,15 @@ func ExtractCRDFromReq(ctx context.Context, req ctrl.Request, reader client.Read
 
 	return nil, nil
 }
+
+// GetSHA returns the SHA-256 of the JSON representation of the value.
+func GetSHA(value interface{}) string {
+	serialized, _ := json.Marshal(value)
+
+	h := sha256.New()
+
+	h.Write(serialized)
+	result := fmt.Sprintf("%x", h.Sum(nil))
+
+	return result
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ConsulServiceIntentionsSourceValidatePath is the path of the validating webhook for ConsulServiceIntentionsSource.
+const ConsulServiceIntentionsSourceValidatePath = "/validate-service-consul-k8s-nativechat-com-v1alpha1-consulserviceintentionssource"
+
+// +kubebuilder:webhook:path=/validate-service-consul-k8s-nativechat-com-v1alpha1-consulserviceintentionssource,mutating=false,failurePolicy=fail,sideEffects=None,groups=service.consul.k8s.nativechat.com,resources=consulserviceintentionssources,verbs=create;update,versions=v1alpha1,name=vconsulserviceintentionssource.service.consul.k8s.nativechat.com,admissionReviewVersions=v1
+
+type consulServiceIntentionsSourceValidator struct {
+	log     logr.Logger
+	decoder *admission.Decoder
+}
+
+func (v *consulServiceIntentionsSourceValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	intentionsSource := new(servicev1alpha1.ConsulServiceIntentionsSource)
+	err := v.decoder.Decode(req, intentionsSource)
+	if err != nil {
+		v.log.Error(err, "failed to decode the ConsulServiceIntentionsSource")
+
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if req.Operation == admissionv1.Update {
+		old := new(servicev1alpha1.ConsulServiceIntentionsSource)
+		err = v.decoder.DecodeRaw(req.OldObject, old)
+		if err != nil {
+			v.log.Error(err, "failed to decode the old ConsulServiceIntentionsSource")
+
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		if !isValidationRequired(intentionsSource, old) {
+			return admission.Allowed("")
+		}
+	}
+
+	log := v.log.WithValues("consulserviceintentionssource", fmt.Sprintf("%s/%s", req.Namespace, intentionsSource.Name))
+
+	errs := field.ErrorList{}
+
+	if len(intentionsSource.Labels[controllerlabels.ServiceIntentions]) == 0 {
+		errs = append(errs, field.Required(field.NewPath("metadata", "labels").Key(controllerlabels.ServiceIntentions), "the service intentions of the source are required"))
+	}
+
+	source := intentionsSource.Spec.Source
+	switch {
+	case source == nil:
+		errs = append(errs, field.Required(field.NewPath("spec", "source"), "the source is required"))
+	case len(source.Action) > 0 && len(source.Permissions) > 0:
+		errs = append(errs, field.Invalid(field.NewPath("spec", "source", "action"), source.Action, "action can't be combined with permissions"))
+	}
+
+	if len(errs) > 0 {
+		err = apierrors.NewInvalid(servicev1alpha1.GroupVersion.WithKind("ConsulServiceIntentionsSource").GroupKind(), intentionsSource.Name, errs)
+		log.Info("denied", "reason", err.Error())
+
+		return admission.Denied(err.Error())
+	}
+
+	return admission.Allowed("")
+}
+
+// InjectDecoder injects the decoder of the webhook server.
+func (v *consulServiceIntentionsSourceValidator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+
+	return nil
+}
+
+// NewConsulServiceIntentionsSourceValidator creates new validating webhook handler for ConsulServiceIntentionsSource.
+func NewConsulServiceIntentionsSourceValidator(log logr.Logger) admission.Handler {
+	v := new(consulServiceIntentionsSourceValidator)
+	v.log = log
+
+	return v
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks_test
+
+import (
+	"net/http"
+
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/webhooks"
+)
+
+func newIntentionsSource(name string, labels map[string]string, source *consulk8s.SourceIntention) *v1alpha1.ConsulServiceIntentionsSource {
+	intentionsSource := &v1alpha1.ConsulServiceIntentionsSource{
+		TypeMeta: metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: "ConsulServiceIntentionsSource"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    labels,
+		},
+		Spec: v1alpha1.ConsulServiceIntentionsSourceSpec{
+			Source: source,
+		},
+	}
+
+	return intentionsSource
+}
+
+var _ = Describe("ConsulServiceIntentionsSource validator", func() {
+	serviceIntentionsLabels := map[string]string{controllerlabels.ServiceIntentions: "service-a"}
+	allow := &consulk8s.SourceIntention{Name: "service-b", Action: "allow"}
+	permissions := consulk8s.IntentionPermissions{{Action: "allow", HTTP: &consulk8s.IntentionHTTPPermission{PathPrefix: "/v1"}}}
+	actionAndPermissions := &consulk8s.SourceIntention{Name: "service-b", Action: "deny", Permissions: permissions}
+
+	table.DescribeTable("Handle",
+		func(intentionsSource, old *v1alpha1.ConsulServiceIntentionsSource, deniedField string) {
+			validator := webhooks.NewConsulServiceIntentionsSourceValidator(logr.Discard())
+
+			var oldObj client.Object
+			if old != nil {
+				oldObj = old
+			}
+
+			res := handle(validator, intentionsSource, oldObj)
+
+			if len(deniedField) == 0 {
+				Expect(res.Allowed).To(BeTrue(), "expected the intentions source to be allowed, got %v", res.Result)
+
+				return
+			}
+
+			Expect(res.Allowed).To(BeFalse())
+			Expect(string(res.Result.Reason)).To(ContainSubstring(deniedField))
+		},
+		table.Entry("allows a source with an action",
+			newIntentionsSource("service-b", serviceIntentionsLabels, allow), nil, ""),
+		table.Entry("allows a source with permissions",
+			newIntentionsSource("service-b", serviceIntentionsLabels, &consulk8s.SourceIntention{Name: "service-b", Permissions: permissions}), nil, ""),
+		table.Entry("denies a source without service intentions",
+			newIntentionsSource("service-b", nil, allow), nil, "metadata.labels["+controllerlabels.ServiceIntentions+"]"),
+		table.Entry("denies a resource without a source",
+			newIntentionsSource("service-b", serviceIntentionsLabels, nil), nil, "spec.source"),
+		table.Entry("denies a source with both an action and permissions",
+			newIntentionsSource("service-b", serviceIntentionsLabels, actionAndPermissions), nil, "spec.source.action"),
+		table.Entry("denies an update of the labels to an invalid source",
+			newIntentionsSource("service-b", nil, allow), newIntentionsSource("service-b", serviceIntentionsLabels, allow), "metadata.labels"),
+		table.Entry("doesn't validate an update which changes neither the labels nor the source",
+			newIntentionsSource("service-b", serviceIntentionsLabels, actionAndPermissions), newIntentionsSource("service-b", serviceIntentionsLabels, actionAndPermissions), ""),
+	)
+
+	It("returns an error for a request which can't be decoded", func() {
+		validator := webhooks.NewConsulServiceIntentionsSourceValidator(logr.Discard())
+		res := handleInvalidJSON(validator)
+
+		Expect(res.Allowed).To(BeFalse())
+		Expect(res.Result.Code).To(BeEquivalentTo(http.StatusBadRequest))
+	})
+})
@@ -0,0 +1,137 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/routes"
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ConsulServiceRouteValidatePath is the path of the validating webhook for ConsulServiceRoute.
+const ConsulServiceRouteValidatePath = "/validate-service-consul-k8s-nativechat-com-v1alpha1-consulserviceroute"
+
+// +kubebuilder:webhook:path=/validate-service-consul-k8s-nativechat-com-v1alpha1-consulserviceroute,mutating=false,failurePolicy=fail,sideEffects=None,groups=service.consul.k8s.nativechat.com,resources=consulserviceroutes,verbs=create;update,versions=v1alpha1,name=vconsulserviceroute.service.consul.k8s.nativechat.com,admissionReviewVersions=v1
+
+type consulServiceRouteValidator struct {
+	reader  client.Reader
+	log     logr.Logger
+	decoder *admission.Decoder
+}
+
+func (v *consulServiceRouteValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	route := new(servicev1alpha1.ConsulServiceRoute)
+	err := v.decoder.Decode(req, route)
+	if err != nil {
+		v.log.Error(err, "failed to decode the ConsulServiceRoute")
+
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if req.Operation == admissionv1.Update {
+		old := new(servicev1alpha1.ConsulServiceRoute)
+		err = v.decoder.DecodeRaw(req.OldObject, old)
+		if err != nil {
+			v.log.Error(err, "failed to decode the old ConsulServiceRoute")
+
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		if !isValidationRequired(route, old) {
+			return admission.Allowed("")
+		}
+	}
+
+	log := v.log.WithValues("consulserviceroute", fmt.Sprintf("%s/%s", req.Namespace, route.Name))
+
+	errs := field.ErrorList{}
+
+	serviceRouterName := route.Labels[controllerlabels.ServiceRouter]
+	if len(serviceRouterName) == 0 {
+		errs = append(errs, field.Required(field.NewPath("metadata", "labels").Key(controllerlabels.ServiceRouter), "the service router of the route is required"))
+	}
+
+	destination := route.Spec.Route.Destination
+	if destination == nil || reflect.DeepEqual(*destination, consulk8s.ServiceRouteDestination{}) {
+		errs = append(errs, field.Required(field.NewPath("spec", "route", "destination"), "the destination of the route is required"))
+	}
+
+	if match := route.Spec.Route.Match; match != nil && match.HTTP != nil && len(match.HTTP.PathRegex) > 0 {
+		_, err = regexp.Compile(match.HTTP.PathRegex)
+		if err != nil {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "route", "match", "http", "pathRegex"), match.HTTP.PathRegex, err.Error()))
+		}
+	}
+
+	if len(serviceRouterName) > 0 {
+		consulServiceRoutes := new(servicev1alpha1.ConsulServiceRouteList)
+		err = v.reader.List(ctx, consulServiceRoutes, client.InNamespace(req.Namespace), client.MatchingLabels{controllerlabels.ServiceRouter: serviceRouterName})
+		if err != nil {
+			log.Error(err, "failed to list the routes of the service router")
+
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+
+		items := []client.Object{}
+		for i := range consulServiceRoutes.Items {
+			items = append(items, &consulServiceRoutes.Items[i])
+		}
+
+		if duplicate, ok := routes.FindDuplicate(route, items); ok {
+			message := fmt.Sprintf("the match duplicates the match of %s in service router %s", duplicate, serviceRouterName)
+			errs = append(errs, field.Invalid(field.NewPath("spec", "route", "match"), route.Spec.Route.Match, message))
+		}
+	}
+
+	if len(errs) > 0 {
+		err = apierrors.NewInvalid(servicev1alpha1.GroupVersion.WithKind("ConsulServiceRoute").GroupKind(), route.Name, errs)
+		log.Info("denied", "reason", err.Error())
+
+		return admission.Denied(err.Error())
+	}
+
+	return admission.Allowed("")
+}
+
+// InjectDecoder injects the decoder of the webhook server.
+func (v *consulServiceRouteValidator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+
+	return nil
+}
+
+// NewConsulServiceRouteValidator creates new validating webhook handler for ConsulServiceRoute.
+func NewConsulServiceRouteValidator(reader client.Reader, log logr.Logger) admission.Handler {
+	v := new(consulServiceRouteValidator)
+	v.reader = reader
+	v.log = log
+
+	return v
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks_test
+
+import (
+	"net/http"
+
+	"github.com/go-logr/logr"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	controllerlabels "github.com/NativeChat/consul-merge-controller/pkg/labels"
+	"github.com/NativeChat/consul-merge-controller/pkg/webhooks"
+)
+
+const serviceRouterName = "service-a"
+
+func newRoute(name, pathPrefix string, destination *consulk8s.ServiceRouteDestination) *v1alpha1.ConsulServiceRoute {
+	route := &v1alpha1.ConsulServiceRoute{
+		TypeMeta: metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: "ConsulServiceRoute"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{controllerlabels.ServiceRouter: serviceRouterName},
+		},
+		Spec: v1alpha1.ConsulServiceRouteSpec{
+			Route: consulk8s.ServiceRoute{
+				Match:       &consulk8s.ServiceRouteMatch{HTTP: &consulk8s.ServiceRouteHTTPMatch{PathPrefix: pathPrefix}},
+				Destination: destination,
+			},
+		},
+	}
+
+	return route
+}
+
+func newValidRoute(name, pathPrefix string) *v1alpha1.ConsulServiceRoute {
+	return newRoute(name, pathPrefix, &consulk8s.ServiceRouteDestination{Service: name})
+}
+
+func withoutLabels(route *v1alpha1.ConsulServiceRoute) *v1alpha1.ConsulServiceRoute {
+	route.Labels = nil
+
+	return route
+}
+
+func withPathRegex(route *v1alpha1.ConsulServiceRoute, pathRegex string) *v1alpha1.ConsulServiceRoute {
+	route.Spec.Route.Match.HTTP = &consulk8s.ServiceRouteHTTPMatch{PathRegex: pathRegex}
+
+	return route
+}
+
+func markedForDeletion(route *v1alpha1.ConsulServiceRoute) *v1alpha1.ConsulServiceRoute {
+	now := metav1.Now()
+	route.DeletionTimestamp = &now
+	route.Finalizers = []string{"finalizer"}
+
+	return route
+}
+
+func withFinalizers(route *v1alpha1.ConsulServiceRoute) *v1alpha1.ConsulServiceRoute {
+	route.Finalizers = []string{"finalizer"}
+
+	return route
+}
+
+var _ = Describe("ConsulServiceRoute validator", func() {
+	table.DescribeTable("Handle",
+		func(route, old *v1alpha1.ConsulServiceRoute, existing []client.Object, deniedField string) {
+			validator := webhooks.NewConsulServiceRouteValidator(newFakeClient(existing...), logr.Discard())
+
+			var oldObj client.Object
+			if old != nil {
+				oldObj = old
+			}
+
+			res := handle(validator, route, oldObj)
+
+			if len(deniedField) == 0 {
+				Expect(res.Allowed).To(BeTrue(), "expected the route to be allowed, got %v", res.Result)
+
+				return
+			}
+
+			Expect(res.Allowed).To(BeFalse())
+			Expect(string(res.Result.Reason)).To(ContainSubstring(deniedField))
+		},
+		table.Entry("allows a valid route",
+			newValidRoute("service-a-v1", "/v1"), nil, []client.Object{}, ""),
+		table.Entry("allows a route with a different match than the other routes of the service router",
+			newValidRoute("service-a-v2", "/v2"), nil, []client.Object{newValidRoute("service-a-v1", "/v1")}, ""),
+		table.Entry("allows a route with the same match as a route of another service router",
+			newValidRoute("service-a-v2", "/v1"), nil, []client.Object{withoutLabels(newValidRoute("service-a-v1", "/v1"))}, ""),
+		table.Entry("allows an update of a route which keeps its match",
+			newValidRoute("service-a-v1", "/v1"), newValidRoute("service-a-v1", "/old"), []client.Object{newValidRoute("service-a-v1", "/old")}, ""),
+		table.Entry("denies a route without a service router",
+			withoutLabels(newValidRoute("service-a-v1", "/v1")), nil, []client.Object{}, "metadata.labels["+controllerlabels.ServiceRouter+"]"),
+		table.Entry("denies a route without a destination",
+			newRoute("service-a-v1", "/v1", nil), nil, []client.Object{}, "spec.route.destination"),
+		table.Entry("denies a route with an empty destination",
+			newRoute("service-a-v1", "/v1", &consulk8s.ServiceRouteDestination{}), nil, []client.Object{}, "spec.route.destination"),
+		table.Entry("denies a route with an invalid path regex",
+			withPathRegex(newValidRoute("service-a-v1", ""), "/v1/("), nil, []client.Object{}, "spec.route.match.http.pathRegex"),
+		table.Entry("denies a route with the same match as another route of the service router",
+			newValidRoute("service-a-v2", "/v1"), nil, []client.Object{newValidRoute("service-a-v1", "/v1")}, "spec.route.match"),
+		table.Entry("denies an update of the spec to an invalid route",
+			newRoute("service-a-v1", "/v1", nil), newValidRoute("service-a-v1", "/v1"), []client.Object{}, "spec.route.destination"),
+		table.Entry("doesn't validate an update of the finalizers of an invalid route",
+			withFinalizers(newRoute("service-a-v1", "/v1", nil)), newRoute("service-a-v1", "/v1", nil), []client.Object{}, ""),
+		table.Entry("doesn't validate an update of an invalid route which is marked for deletion",
+			markedForDeletion(newRoute("service-a-v1", "/v2", nil)), newRoute("service-a-v1", "/v1", nil), []client.Object{}, ""),
+	)
+
+	It("returns an error for a request which can't be decoded", func() {
+		validator := webhooks.NewConsulServiceRouteValidator(newFakeClient(), logr.Discard())
+		res := handleInvalidJSON(validator)
+
+		Expect(res.Allowed).To(BeFalse())
+		Expect(res.Result.Code).To(BeEquivalentTo(http.StatusBadRequest))
+	})
+})
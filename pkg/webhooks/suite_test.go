@@ -0,0 +1,102 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+)
+
+func TestWebhooks(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecsWithDefaultAndCustomReporters(t,
+		"Webhooks Suite",
+		[]Reporter{printer.NewlineReporter{}})
+}
+
+func newScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(consulk8s.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+
+	return scheme
+}
+
+// newFakeClient returns a fake client with the controller and the consul-k8s types which contains objs.
+func newFakeClient(objs ...client.Object) client.Client {
+	return fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(objs...).Build()
+}
+
+// injectDecoder injects the decoder of the webhook server into the handler.
+func injectDecoder(handler admission.Handler) {
+	decoder, err := admission.NewDecoder(newScheme())
+	Expect(err).NotTo(HaveOccurred())
+	Expect(handler.(admission.DecoderInjector).InjectDecoder(decoder)).To(Succeed())
+}
+
+// handle sends the admission request for obj to the handler. The request is an update of old when old isn't nil.
+func handle(handler admission.Handler, obj, old client.Object) admission.Response {
+	injectDecoder(handler)
+
+	req := admission.Request{}
+	req.Operation = admissionv1.Create
+	req.Namespace = obj.GetNamespace()
+	req.Name = obj.GetName()
+	req.Object.Raw = toJSON(obj)
+	if old != nil {
+		req.Operation = admissionv1.Update
+		req.OldObject.Raw = toJSON(old)
+	}
+
+	return handler.Handle(context.Background(), req)
+}
+
+// handleInvalidJSON sends an admission request whose object can't be decoded to the handler.
+func handleInvalidJSON(handler admission.Handler) admission.Response {
+	injectDecoder(handler)
+
+	req := admission.Request{}
+	req.Operation = admissionv1.Create
+	req.Namespace = "default"
+	req.Object.Raw = []byte("{")
+
+	return handler.Handle(context.Background(), req)
+}
+
+func toJSON(obj client.Object) []byte {
+	raw, err := json.Marshal(obj)
+	Expect(err).NotTo(HaveOccurred())
+
+	return raw
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"reflect"
+
+	servicev1alpha1 "github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// isValidationRequired checks if an update of a resource has to be validated. Updates of resources which
+// are marked for deletion and updates which change neither the labels nor the spec, e.g. of the finalizers,
+// are not validated so that resources created before the webhooks can still be finalized.
+func isValidationRequired(obj client.Object, old client.Object) bool {
+	if obj.GetDeletionTimestamp() != nil {
+		return false
+	}
+
+	if !reflect.DeepEqual(obj.GetLabels(), old.GetLabels()) {
+		return true
+	}
+
+	content := obj.(servicev1alpha1.MergeSource).GetContent()
+	oldContent := old.(servicev1alpha1.MergeSource).GetContent()
+
+	return !reflect.DeepEqual(content, oldContent)
+}
@@ -36,6 +36,30 @@ var (
 	// ServiceIntentions is the name of the label which stores the service intentions name.
 	ServiceIntentions = fmt.Sprintf("%s/service-intentions", ServiceGroup)
 
+	// ServiceSplitterLabel is the name of the label which stores the service splitter name.
+	ServiceSplitterLabel = fmt.Sprintf("%s/service-splitter", ServiceGroup)
+
+	// ServiceResolverLabel is the name of the label which stores the service resolver name.
+	ServiceResolverLabel = fmt.Sprintf("%s/service-resolver", ServiceGroup)
+
+	// IngressGatewayLabel is the name of the label which stores the ingress gateway name.
+	IngressGatewayLabel = fmt.Sprintf("%s/ingress-gateway", ServiceGroup)
+
+	// TerminatingGatewayLabel is the name of the label which stores the terminating gateway name.
+	TerminatingGatewayLabel = fmt.Sprintf("%s/terminating-gateway", ServiceGroup)
+
+	// RouteOrderingAnnotation is the name of the annotation which selects the ordering of the routes in a service router.
+	RouteOrderingAnnotation = fmt.Sprintf("%s/route-ordering", ServiceGroup)
+
+	// RevertedDriftsAnnotation is the name of the annotation which stores the number of reverted drifts of a merge destination.
+	RevertedDriftsAnnotation = fmt.Sprintf("%s/reverted-drifts", ServiceGroup)
+
+	// UnmanagedEntriesAnnotation is the name of the annotation which stores the unmanaged entries of a merge destination.
+	UnmanagedEntriesAnnotation = fmt.Sprintf("%s/unmanaged-entries", ServiceGroup)
+
+	// ManagedByLabel is the name of the label which marks the merge destinations created by the controller.
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+
 	// ServiceFinalizer is the name of the service finalizer.
 	ServiceFinalizer = fmt.Sprintf("finalizer.%s", ServiceGroup)
 )
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutils
+
+import (
+	"context"
+	"fmt"
+
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+)
+
+// CreateConsulConfigFragment creates a fragment which is merged into the path of the service defaults.
+func CreateConsulConfigFragment(ctx context.Context, k8sClient client.Client, name, serviceDefaults, path, mergeStrategy, mergeKey, fragment string) error {
+	ccf := &v1alpha1.ConsulConfigFragment{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: v1alpha1.GroupVersion.Version,
+			Kind:       "ConsulConfigFragment",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: DefaultK8sNamespace,
+		},
+		Spec: v1alpha1.ConsulConfigFragmentSpec{
+			Target: v1alpha1.ConfigFragmentTarget{
+				APIVersion: consulk8s.GroupVersion.String(),
+				Kind:       "ServiceDefaults",
+				Name:       serviceDefaults,
+			},
+			Path:          path,
+			Fragment:      apiextensionsv1.JSON{Raw: []byte(fragment)},
+			MergeStrategy: mergeStrategy,
+			MergeKey:      mergeKey,
+		},
+	}
+
+	err := k8sClient.Create(ctx, ccf)
+	if err != nil {
+		return err
+	}
+
+	err = waitForConsulConfigFragmentToBeUpToDate(ctx, k8sClient, ccf)
+
+	return err
+}
+
+// GetConsulConfigFragment ...
+func GetConsulConfigFragment(ctx context.Context, k8sClient client.Client, name string) (*v1alpha1.ConsulConfigFragment, error) {
+	ccf := new(v1alpha1.ConsulConfigFragment)
+	exists, err := getK8sObject(ctx, k8sClient, name, ccf)
+	if !exists {
+		ccf = nil
+	}
+
+	return ccf, err
+}
+
+// DeleteConsulConfigFragment ...
+func DeleteConsulConfigFragment(ctx context.Context, k8sClient client.Client, name string) error {
+	ccf := new(v1alpha1.ConsulConfigFragment)
+	err := deleteK8sObject(ctx, k8sClient, name, ccf)
+
+	return err
+}
+
+func waitForConsulConfigFragmentToBeUpToDate(ctx context.Context, k8sClient client.Client, expected *v1alpha1.ConsulConfigFragment) error {
+	expectedSHA := getResourceContentSHA(expected)
+	hasTimedOut := retryWithSleep(func() bool {
+		existing, _ := GetConsulConfigFragment(ctx, k8sClient, expected.Name)
+		result := existing != nil && existing.Status.ContentSHA == expectedSHA
+
+		return result
+	})
+
+	if hasTimedOut {
+		return fmt.Errorf("ConsulConfigFragment sync timeout exceeded")
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onsi/gomega"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CreateConsulIngressGatewayService ...
+func CreateConsulIngressGatewayService(ctx context.Context, k8sClient client.Client, ingressGateway string, port int, protocol string, service consulk8s.IngressService) (string, error) {
+	name := fmt.Sprintf("%s-%d", service.Name, port)
+
+	return CreateNamedConsulIngressGatewayService(ctx, k8sClient, name, ingressGateway, port, protocol, service)
+}
+
+// CreateNamedConsulIngressGatewayService ...
+func CreateNamedConsulIngressGatewayService(ctx context.Context, k8sClient client.Client, name, ingressGateway string, port int, protocol string, service consulk8s.IngressService) (string, error) {
+	cigs := &v1alpha1.ConsulIngressGatewayService{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: v1alpha1.GroupVersion.Version,
+			Kind:       "ConsulIngressGatewayService",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: DefaultK8sNamespace,
+
+			Labels: map[string]string{IngressGatewayLabel: ingressGateway},
+		},
+		Spec: v1alpha1.ConsulIngressGatewayServiceSpec{
+			Port:     port,
+			Protocol: protocol,
+			Service:  service,
+		},
+	}
+
+	err := k8sClient.Create(ctx, cigs)
+	if err != nil {
+		return "", err
+	}
+
+	err = waitForConsulIngressGatewayServiceToBeUpToDate(ctx, k8sClient, cigs)
+
+	return name, err
+}
+
+// GetConsulIngressGatewayService ...
+func GetConsulIngressGatewayService(ctx context.Context, k8sClient client.Client, name string) (*v1alpha1.ConsulIngressGatewayService, error) {
+	cigs := new(v1alpha1.ConsulIngressGatewayService)
+	exists, err := getK8sObject(ctx, k8sClient, name, cigs)
+	if !exists {
+		cigs = nil
+	}
+
+	return cigs, err
+}
+
+// DeleteConsulIngressGatewayServices ...
+func DeleteConsulIngressGatewayServices(ctx context.Context, k8sClient client.Client, ingressGatewayName string) {
+	requirement, err := labels.NewRequirement(IngressGatewayLabel, selection.Equals, []string{ingressGatewayName})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	ingressGatewayServices := new(v1alpha1.ConsulIngressGatewayServiceList)
+	err = k8sClient.List(ctx, ingressGatewayServices, &client.ListOptions{
+		Namespace:     DefaultK8sNamespace,
+		LabelSelector: labels.Everything().Add(*requirement),
+	})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for _, ingressGatewayService := range ingressGatewayServices.Items {
+		err := DeleteConsulIngressGatewayService(ctx, k8sClient, ingressGatewayService.Name)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	ingressGateway, err := GetIngressGateway(ctx, k8sClient, ingressGatewayName)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	if ingressGateway == nil {
+		return
+	}
+
+	err = deleteK8sObject(ctx, k8sClient, ingressGatewayName, ingressGateway)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+// DeleteConsulIngressGatewayService ...
+func DeleteConsulIngressGatewayService(ctx context.Context, k8sClient client.Client, name string) error {
+	cigs := new(v1alpha1.ConsulIngressGatewayService)
+	err := deleteK8sObject(ctx, k8sClient, name, cigs)
+
+	return err
+}
+
+// WaitForConsulIngressGatewayServiceCondition ...
+func WaitForConsulIngressGatewayServiceCondition(ctx context.Context, k8sClient client.Client, name, conditionType string, isTrue bool) error {
+	hasTimedOut := retryWithSleep(func() bool {
+		existing, _ := GetConsulIngressGatewayService(ctx, k8sClient, name)
+		if existing == nil {
+			return false
+		}
+
+		result := meta.IsStatusConditionTrue(existing.Status.Conditions, conditionType) == isTrue
+
+		return result
+	})
+
+	if hasTimedOut {
+		return fmt.Errorf("ConsulIngressGatewayService %s condition timeout exceeded", conditionType)
+	}
+
+	return nil
+}
+
+func waitForConsulIngressGatewayServiceToBeUpToDate(ctx context.Context, k8sClient client.Client, expected *v1alpha1.ConsulIngressGatewayService) error {
+	expectedSHA := getResourceContentSHA(expected)
+	hasTimedOut := retryWithSleep(func() bool {
+		existing, _ := GetConsulIngressGatewayService(ctx, k8sClient, expected.Name)
+		result := existing.Status.ContentSHA == expectedSHA
+
+		return result
+	})
+
+	if hasTimedOut {
+		return fmt.Errorf("ConsulIngressGatewayService sync timeout exceeded")
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	capi "github.com/hashicorp/consul/api"
+)
+
+const consulConfigPath = "/v1/config"
+
+// ConsulServer is a stand-in for the config entries endpoints of the Consul HTTP API
+// which can be used instead of a local Consul agent.
+type ConsulServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	token   string
+	index   uint64
+	entries map[string]map[string]interface{}
+}
+
+// NewConsulServer starts new ConsulServer without config entries. It has to be closed when it isn't used anymore.
+func NewConsulServer() *ConsulServer {
+	s := &ConsulServer{
+		entries: map[string]map[string]interface{}{},
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// SetToken sets the ACL token required by the requests. An empty token allows all requests.
+func (s *ConsulServer) SetToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = token
+}
+
+// GetConfigEntry returns the config entry or nil if it doesn't exist.
+func (s *ConsulServer) GetConfigEntry(kind, name string) (capi.ConfigEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, ok := s.entries[getConfigEntryKey(kind, name)]
+	if !ok {
+		return nil, nil
+	}
+
+	return capi.DecodeConfigEntry(raw)
+}
+
+// SetConfigEntry writes the config entry without checking its modify index, e.g. to change it outside of the controller.
+func (s *ConsulServer) SetConfigEntry(entry capi.ConfigEntry) error {
+	serialized, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	raw := map[string]interface{}{}
+	err = json.Unmarshal(serialized, &raw)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setConfigEntry(entry.GetKind(), entry.GetName(), raw)
+
+	return nil
+}
+
+func (s *ConsulServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.token) > 0 && r.Header.Get("X-Consul-Token") != s.token {
+		http.Error(w, "Permission denied", http.StatusForbidden)
+
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, consulConfigPath)
+	switch {
+	case r.Method == http.MethodPut && len(path) == 0:
+		s.handlePut(w, r)
+	case r.Method == http.MethodGet && strings.Count(path, "/") == 2:
+		s.handleGet(w, path)
+	case r.Method == http.MethodDelete && strings.Count(path, "/") == 2:
+		s.handleDelete(w, r, path)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *ConsulServer) handleGet(w http.ResponseWriter, path string) {
+	raw, ok := s.entries[strings.TrimPrefix(path, "/")]
+	if !ok {
+		http.Error(w, "Config entry not found", http.StatusNotFound)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(raw)
+}
+
+func (s *ConsulServer) handleDelete(w http.ResponseWriter, r *http.Request, path string) {
+	key := strings.TrimPrefix(path, "/")
+	if cas := r.URL.Query().Get("cas"); len(cas) > 0 {
+		index, err := strconv.ParseUint(cas, 10, 64)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		// The delete is rejected when the config entry doesn't exist or its modify index differs from the index.
+		existing, ok := s.entries[key]
+		if !ok || uint64(existing["ModifyIndex"].(float64)) != index {
+			fmt.Fprint(w, "false")
+
+			return
+		}
+	}
+
+	delete(s.entries, key)
+	fmt.Fprint(w, "true")
+}
+
+func (s *ConsulServer) handlePut(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	raw := map[string]interface{}{}
+	err = json.Unmarshal(body, &raw)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	kind, _ := raw["Kind"].(string)
+	name, _ := raw["Name"].(string)
+	if len(kind) == 0 || len(name) == 0 {
+		http.Error(w, "Kind and Name are required", http.StatusBadRequest)
+
+		return
+	}
+
+	if cas := r.URL.Query().Get("cas"); len(cas) > 0 {
+		index, err := strconv.ParseUint(cas, 10, 64)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		// The write is rejected when the modify index of the config entry differs from the index
+		// or when the config entry exists and the index is 0.
+		existing, ok := s.entries[getConfigEntryKey(kind, name)]
+		if !ok && index != 0 || ok && uint64(existing["ModifyIndex"].(float64)) != index {
+			fmt.Fprint(w, "false")
+
+			return
+		}
+	}
+
+	s.setConfigEntry(kind, name, raw)
+	fmt.Fprint(w, "true")
+}
+
+func (s *ConsulServer) setConfigEntry(kind, name string, raw map[string]interface{}) {
+	key := getConfigEntryKey(kind, name)
+
+	s.index++
+	raw["CreateIndex"] = float64(s.index)
+	if existing, ok := s.entries[key]; ok {
+		raw["CreateIndex"] = existing["CreateIndex"]
+	}
+
+	raw["ModifyIndex"] = float64(s.index)
+	s.entries[key] = raw
+}
+
+func getConfigEntryKey(kind, name string) string {
+	return fmt.Sprintf("%s/%s", kind, name)
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
 	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
@@ -59,6 +60,11 @@ func ExpectConsulServiceIntentionsSource(ctx context.Context, k8sClient client.C
 func CreateConsulServiceIntentionsSource(ctx context.Context, k8sClient client.Client, serviceName string, source *consulk8s.SourceIntention) (string, error) {
 	name := fmt.Sprintf("%s-%s-to-%s", source.Action, source.Name, serviceName)
 
+	return CreateNamedConsulServiceIntentionsSource(ctx, k8sClient, name, serviceName, source)
+}
+
+// CreateNamedConsulServiceIntentionsSource ...
+func CreateNamedConsulServiceIntentionsSource(ctx context.Context, k8sClient client.Client, name string, serviceName string, source *consulk8s.SourceIntention) (string, error) {
 	csis := &v1alpha1.ConsulServiceIntentionsSource{
 		TypeMeta: v1.TypeMeta{
 			APIVersion: v1alpha1.GroupVersion.Version,
@@ -152,6 +158,26 @@ func DeleteConsulServiceIntentionsSource(ctx context.Context, k8sClient client.C
 	return err
 }
 
+// WaitForConsulServiceIntentionsSourceCondition ...
+func WaitForConsulServiceIntentionsSourceCondition(ctx context.Context, k8sClient client.Client, name, conditionType string, isTrue bool) error {
+	hasTimedOut := retryWithSleep(func() bool {
+		existing, _ := GetConsulServiceIntentionsSource(ctx, k8sClient, name)
+		if existing == nil {
+			return false
+		}
+
+		result := meta.IsStatusConditionTrue(existing.Status.Conditions, conditionType) == isTrue
+
+		return result
+	})
+
+	if hasTimedOut {
+		return fmt.Errorf("ConsulServiceIntentionsSource %s condition timeout exceeded", conditionType)
+	}
+
+	return nil
+}
+
 func waitForConsulServiceIntentionsSourceToBeUpToDate(ctx context.Context, k8sClient client.Client, expected *v1alpha1.ConsulServiceIntentionsSource) error {
 	expectedSHA := getResourceContentSHA(expected)
 
@@ -0,0 +1,122 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onsi/gomega"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CreateConsulServiceResolverSubset ...
+func CreateConsulServiceResolverSubset(ctx context.Context, k8sClient client.Client, name, serviceResolver string, subsets consulk8s.ServiceResolverSubsetMap) error {
+	csrs := &v1alpha1.ConsulServiceResolverSubset{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: v1alpha1.GroupVersion.Version,
+			Kind:       "ConsulServiceResolverSubset",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: DefaultK8sNamespace,
+
+			Labels: map[string]string{ServiceResolverLabel: serviceResolver},
+		},
+		Spec: v1alpha1.ConsulServiceResolverSubsetSpec{
+			Subsets: subsets,
+		},
+	}
+
+	err := k8sClient.Create(ctx, csrs)
+	if err != nil {
+		return err
+	}
+
+	err = waitForConsulServiceResolverSubsetToBeUpToDate(ctx, k8sClient, csrs)
+
+	return err
+}
+
+// GetConsulServiceResolverSubset ...
+func GetConsulServiceResolverSubset(ctx context.Context, k8sClient client.Client, name string) (*v1alpha1.ConsulServiceResolverSubset, error) {
+	csrs := new(v1alpha1.ConsulServiceResolverSubset)
+	exists, err := getK8sObject(ctx, k8sClient, name, csrs)
+	if !exists {
+		csrs = nil
+	}
+
+	return csrs, err
+}
+
+// DeleteConsulServiceResolverSubsets ...
+func DeleteConsulServiceResolverSubsets(ctx context.Context, k8sClient client.Client, serviceResolverName string) {
+	requirement, err := labels.NewRequirement(ServiceResolverLabel, selection.Equals, []string{serviceResolverName})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	subsets := new(v1alpha1.ConsulServiceResolverSubsetList)
+	err = k8sClient.List(ctx, subsets, &client.ListOptions{
+		Namespace:     DefaultK8sNamespace,
+		LabelSelector: labels.Everything().Add(*requirement),
+	})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for _, subset := range subsets.Items {
+		err := DeleteConsulServiceResolverSubset(ctx, k8sClient, subset.Name)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	serviceResolver, err := GetServiceResolver(ctx, k8sClient, serviceResolverName)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	if serviceResolver == nil {
+		return
+	}
+
+	err = deleteK8sObject(ctx, k8sClient, serviceResolverName, serviceResolver)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+// DeleteConsulServiceResolverSubset ...
+func DeleteConsulServiceResolverSubset(ctx context.Context, k8sClient client.Client, name string) error {
+	csrs := new(v1alpha1.ConsulServiceResolverSubset)
+	err := deleteK8sObject(ctx, k8sClient, name, csrs)
+
+	return err
+}
+
+func waitForConsulServiceResolverSubsetToBeUpToDate(ctx context.Context, k8sClient client.Client, expected *v1alpha1.ConsulServiceResolverSubset) error {
+	expectedSHA := getResourceContentSHA(expected)
+	hasTimedOut := retryWithSleep(func() bool {
+		existing, _ := GetConsulServiceResolverSubset(ctx, k8sClient, expected.Name)
+		result := existing.Status.ContentSHA == expectedSHA
+
+		return result
+	})
+
+	if hasTimedOut {
+		return fmt.Errorf("ConsulServiceResolverSubset sync timeout exceeded")
+	}
+
+	return nil
+}
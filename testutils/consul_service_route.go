@@ -24,12 +24,27 @@ import (
 
 	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
 	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // CreateConsulServiceRoute ...
 func CreateConsulServiceRoute(ctx context.Context, k8sClient client.Client, serviceRouter string, route consulk8s.ServiceRoute) error {
+	return createConsulServiceRoute(ctx, k8sClient, serviceRouter, route, 0, nil)
+}
+
+// CreateConsulServiceRouteWithPriority ...
+func CreateConsulServiceRouteWithPriority(ctx context.Context, k8sClient client.Client, serviceRouter string, route consulk8s.ServiceRoute, priority int32) error {
+	return createConsulServiceRoute(ctx, k8sClient, serviceRouter, route, priority, nil)
+}
+
+// CreateConsulServiceRouteWithAnnotations ...
+func CreateConsulServiceRouteWithAnnotations(ctx context.Context, k8sClient client.Client, serviceRouter string, route consulk8s.ServiceRoute, annotations map[string]string) error {
+	return createConsulServiceRoute(ctx, k8sClient, serviceRouter, route, 0, annotations)
+}
+
+func createConsulServiceRoute(ctx context.Context, k8sClient client.Client, serviceRouter string, route consulk8s.ServiceRoute, priority int32, annotations map[string]string) error {
 	name := route.Destination.Service
 
 	csr := &v1alpha1.ConsulServiceRoute{
@@ -41,10 +56,12 @@ func CreateConsulServiceRoute(ctx context.Context, k8sClient client.Client, serv
 			Name:      name,
 			Namespace: DefaultK8sNamespace,
 
-			Labels: map[string]string{ServiceRouterLabel: serviceRouter},
+			Labels:      map[string]string{ServiceRouterLabel: serviceRouter},
+			Annotations: annotations,
 		},
 		Spec: v1alpha1.ConsulServiceRouteSpec{
-			Route: route,
+			Route:    route,
+			Priority: priority,
 		},
 	}
 
@@ -114,6 +131,26 @@ func CreateConsulServiceRoutes(ctx context.Context, k8sClient client.Client, ser
 	gomega.Expect(err).NotTo(gomega.HaveOccurred())
 }
 
+// WaitForConsulServiceRouteCondition ...
+func WaitForConsulServiceRouteCondition(ctx context.Context, k8sClient client.Client, name, conditionType string, isTrue bool) error {
+	hasTimedOut := retryWithSleep(func() bool {
+		existing, _ := GetConsulServiceRoute(ctx, k8sClient, name)
+		if existing == nil {
+			return false
+		}
+
+		result := meta.IsStatusConditionTrue(existing.Status.Conditions, conditionType) == isTrue
+
+		return result
+	})
+
+	if hasTimedOut {
+		return fmt.Errorf("ConsulServiceRoute %s condition timeout exceeded", conditionType)
+	}
+
+	return nil
+}
+
 // DeleteConsulServiceRoutes ...
 func DeleteConsulServiceRoutes(ctx context.Context, k8sClient client.Client, serviceRouterName string, routes []consulk8s.ServiceRoute) {
 	for _, serviceRoute := range routes {
@@ -0,0 +1,155 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onsi/gomega"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CreateConsulServiceSplit ...
+func CreateConsulServiceSplit(ctx context.Context, k8sClient client.Client, serviceSplitter string, split consulk8s.ServiceSplit, isDefault bool) error {
+	name := split.Service
+
+	css := &v1alpha1.ConsulServiceSplit{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: v1alpha1.GroupVersion.Version,
+			Kind:       "ConsulServiceSplit",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: DefaultK8sNamespace,
+
+			Labels: map[string]string{ServiceSplitterLabel: serviceSplitter},
+		},
+		Spec: v1alpha1.ConsulServiceSplitSpec{
+			Split:   split,
+			Default: isDefault,
+		},
+	}
+
+	err := k8sClient.Create(ctx, css)
+	if err != nil {
+		return err
+	}
+
+	err = waitForConsulServiceSplitWeightPolicy(ctx, k8sClient, css)
+
+	return err
+}
+
+// GetConsulServiceSplit ...
+func GetConsulServiceSplit(ctx context.Context, k8sClient client.Client, name string) (*v1alpha1.ConsulServiceSplit, error) {
+	css := new(v1alpha1.ConsulServiceSplit)
+	exists, err := getK8sObject(ctx, k8sClient, name, css)
+	if !exists {
+		css = nil
+	}
+
+	return css, err
+}
+
+// UpdateConsulServiceSplit ...
+func UpdateConsulServiceSplit(ctx context.Context, k8sClient client.Client, updated *v1alpha1.ConsulServiceSplit) error {
+	err := k8sClient.Update(ctx, updated)
+	if err != nil {
+		return err
+	}
+
+	err = waitForConsulServiceSplitWeightPolicy(ctx, k8sClient, updated)
+
+	return err
+}
+
+// ExpectConsulServiceSplitWeightPolicy ...
+func ExpectConsulServiceSplitWeightPolicy(ctx context.Context, k8sClient client.Client, name, weightPolicy, effectiveWeight string) {
+	// The weight policy of a split changes when the other splits of the service splitter change.
+	retryWithSleep(func() bool {
+		existing, _ := GetConsulServiceSplit(ctx, k8sClient, name)
+		result := existing != nil && existing.Status.WeightPolicy == weightPolicy && existing.Status.EffectiveWeight == effectiveWeight
+
+		return result
+	})
+
+	css, err := GetConsulServiceSplit(ctx, k8sClient, name)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(css).NotTo(gomega.BeNil())
+
+	gomega.Expect(css.Status.WeightPolicy).To(gomega.Equal(weightPolicy))
+	gomega.Expect(css.Status.EffectiveWeight).To(gomega.Equal(effectiveWeight))
+	gomega.Expect(css.Status.Message).NotTo(gomega.BeEmpty())
+}
+
+// DeleteConsulServiceSplits ...
+func DeleteConsulServiceSplits(ctx context.Context, k8sClient client.Client, serviceSplitterName string) {
+	requirement, err := labels.NewRequirement(ServiceSplitterLabel, selection.Equals, []string{serviceSplitterName})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	splits := new(v1alpha1.ConsulServiceSplitList)
+	err = k8sClient.List(ctx, splits, &client.ListOptions{
+		Namespace:     DefaultK8sNamespace,
+		LabelSelector: labels.Everything().Add(*requirement),
+	})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for _, split := range splits.Items {
+		err := DeleteConsulServiceSplit(ctx, k8sClient, split.Name)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	serviceSplitter, err := GetServiceSplitter(ctx, k8sClient, serviceSplitterName)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	if serviceSplitter == nil {
+		return
+	}
+
+	err = deleteK8sObject(ctx, k8sClient, serviceSplitterName, serviceSplitter)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+// DeleteConsulServiceSplit ...
+func DeleteConsulServiceSplit(ctx context.Context, k8sClient client.Client, name string) error {
+	css := new(v1alpha1.ConsulServiceSplit)
+	err := deleteK8sObject(ctx, k8sClient, name, css)
+
+	return err
+}
+
+func waitForConsulServiceSplitWeightPolicy(ctx context.Context, k8sClient client.Client, expected *v1alpha1.ConsulServiceSplit) error {
+	hasTimedOut := retryWithSleep(func() bool {
+		existing, _ := GetConsulServiceSplit(ctx, k8sClient, expected.Name)
+		result := existing != nil && existing.Generation == expected.Generation && len(existing.Status.WeightPolicy) > 0
+
+		return result
+	})
+
+	if hasTimedOut {
+		return fmt.Errorf("ConsulServiceSplit weight policy timeout exceeded")
+	}
+
+	return nil
+}
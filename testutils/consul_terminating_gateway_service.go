@@ -0,0 +1,124 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onsi/gomega"
+
+	"github.com/NativeChat/consul-merge-controller/apis/service/v1alpha1"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CreateConsulTerminatingGatewayService ...
+func CreateConsulTerminatingGatewayService(ctx context.Context, k8sClient client.Client, terminatingGateway string, service consulk8s.LinkedService) (string, error) {
+	name := service.Name
+
+	ctgs := &v1alpha1.ConsulTerminatingGatewayService{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: v1alpha1.GroupVersion.Version,
+			Kind:       "ConsulTerminatingGatewayService",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: DefaultK8sNamespace,
+
+			Labels: map[string]string{TerminatingGatewayLabel: terminatingGateway},
+		},
+		Spec: v1alpha1.ConsulTerminatingGatewayServiceSpec{
+			Service: service,
+		},
+	}
+
+	err := k8sClient.Create(ctx, ctgs)
+	if err != nil {
+		return "", err
+	}
+
+	err = waitForConsulTerminatingGatewayServiceToBeUpToDate(ctx, k8sClient, ctgs)
+
+	return name, err
+}
+
+// GetConsulTerminatingGatewayService ...
+func GetConsulTerminatingGatewayService(ctx context.Context, k8sClient client.Client, name string) (*v1alpha1.ConsulTerminatingGatewayService, error) {
+	ctgs := new(v1alpha1.ConsulTerminatingGatewayService)
+	exists, err := getK8sObject(ctx, k8sClient, name, ctgs)
+	if !exists {
+		ctgs = nil
+	}
+
+	return ctgs, err
+}
+
+// DeleteConsulTerminatingGatewayServices ...
+func DeleteConsulTerminatingGatewayServices(ctx context.Context, k8sClient client.Client, terminatingGatewayName string) {
+	requirement, err := labels.NewRequirement(TerminatingGatewayLabel, selection.Equals, []string{terminatingGatewayName})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	terminatingGatewayServices := new(v1alpha1.ConsulTerminatingGatewayServiceList)
+	err = k8sClient.List(ctx, terminatingGatewayServices, &client.ListOptions{
+		Namespace:     DefaultK8sNamespace,
+		LabelSelector: labels.Everything().Add(*requirement),
+	})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	for _, terminatingGatewayService := range terminatingGatewayServices.Items {
+		err := DeleteConsulTerminatingGatewayService(ctx, k8sClient, terminatingGatewayService.Name)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	terminatingGateway, err := GetTerminatingGateway(ctx, k8sClient, terminatingGatewayName)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	if terminatingGateway == nil {
+		return
+	}
+
+	err = deleteK8sObject(ctx, k8sClient, terminatingGatewayName, terminatingGateway)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+}
+
+// DeleteConsulTerminatingGatewayService ...
+func DeleteConsulTerminatingGatewayService(ctx context.Context, k8sClient client.Client, name string) error {
+	ctgs := new(v1alpha1.ConsulTerminatingGatewayService)
+	err := deleteK8sObject(ctx, k8sClient, name, ctgs)
+
+	return err
+}
+
+func waitForConsulTerminatingGatewayServiceToBeUpToDate(ctx context.Context, k8sClient client.Client, expected *v1alpha1.ConsulTerminatingGatewayService) error {
+	expectedSHA := getResourceContentSHA(expected)
+	hasTimedOut := retryWithSleep(func() bool {
+		existing, _ := GetConsulTerminatingGatewayService(ctx, k8sClient, expected.Name)
+		result := existing.Status.ContentSHA == expectedSHA
+
+		return result
+	})
+
+	if hasTimedOut {
+		return fmt.Errorf("ConsulTerminatingGatewayService sync timeout exceeded")
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutils
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WaitForEvent waits for an event with the given reason on the object.
+func WaitForEvent(ctx context.Context, k8sClient client.Client, obj client.Object, reason string) error {
+	hasTimedOut := retryWithSleep(func() bool {
+		events := new(corev1.EventList)
+		err := k8sClient.List(ctx, events, client.InNamespace(obj.GetNamespace()), client.MatchingFields{"involvedObject.uid": string(obj.GetUID())})
+		if err != nil {
+			return false
+		}
+
+		for _, event := range events.Items {
+			if event.Reason == reason {
+				return true
+			}
+		}
+
+		return false
+	})
+
+	if hasTimedOut {
+		return fmt.Errorf("timeout exceeded while waiting for event %s on %s", reason, obj.GetName())
+	}
+
+	return nil
+}
@@ -0,0 +1,274 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/consul-k8s/api/common"
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// consulAgentError is the reason of the Synced condition set by consul-k8s when Consul rejects a config entry.
+const consulAgentError = "ConsulAgentError"
+
+var stopFakeConsulK8s context.CancelFunc
+
+// consulK8sResourceTypes are the consul-k8s resources whose Synced condition is set by the fake consul-k8s.
+var consulK8sResourceTypes = []common.ConfigEntryResource{
+	&consulk8s.ServiceDefaults{},
+	&consulk8s.ProxyDefaults{},
+	&consulk8s.ServiceRouter{},
+	&consulk8s.ServiceSplitter{},
+	&consulk8s.ServiceResolver{},
+	&consulk8s.ServiceIntentions{},
+	&consulk8s.IngressGateway{},
+	&consulk8s.TerminatingGateway{},
+	&consulk8s.Mesh{},
+}
+
+// fakeConsulK8sReconciler sets the Synced condition of a consul-k8s resource the way consul-k8s does
+// after the resource is written to Consul.
+type fakeConsulK8sReconciler struct {
+	client       client.Client
+	log          logr.Logger
+	resourceType reflect.Type
+	validate     bool
+}
+
+func (r *fakeConsulK8sReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	resource := reflect.New(r.resourceType).Interface().(common.ConfigEntryResource)
+	err := r.client.Get(ctx, req.NamespacedName, resource)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	}
+
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !resource.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	status, reason, message := corev1.ConditionTrue, "", ""
+	if r.validate {
+		err = r.validateResource(ctx, resource)
+		if err != nil {
+			status, reason, message = corev1.ConditionFalse, consulAgentError, err.Error()
+		}
+	}
+
+	currentStatus, currentReason, currentMessage := resource.SyncedCondition()
+	if currentStatus == status && currentReason == reason && currentMessage == message {
+		return ctrl.Result{}, nil
+	}
+
+	r.log.Info("setting the synced condition", "resource", req.NamespacedName, "status", status, "message", message)
+
+	resource.SetSyncedCondition(status, reason, message)
+	if status == corev1.ConditionTrue {
+		now := metav1.Now()
+		resource.SetLastSyncedTime(&now)
+	}
+
+	err = r.client.Status().Update(ctx, resource)
+
+	return ctrl.Result{}, err
+}
+
+// validateResource validates the resource the way the consul-k8s webhook and Consul do.
+// Only the protocol checks of Consul for the service routers and splitters are implemented.
+func (r *fakeConsulK8sReconciler) validateResource(ctx context.Context, resource common.ConfigEntryResource) error {
+	err := resource.Validate(false)
+	if err != nil {
+		return err
+	}
+
+	services := []string{}
+	switch resource := resource.(type) {
+	case *consulk8s.ServiceRouter:
+		services = append(services, resource.Name)
+		for _, route := range resource.Spec.Routes {
+			if route.Destination != nil && len(route.Destination.Service) > 0 {
+				services = append(services, route.Destination.Service)
+			}
+		}
+	case *consulk8s.ServiceSplitter:
+		services = append(services, resource.Name)
+		for _, split := range resource.Spec.Splits {
+			if len(split.Service) > 0 {
+				services = append(services, split.Service)
+			}
+		}
+	}
+
+	for _, service := range services {
+		protocol, err := r.getProtocol(ctx, resource.GetNamespace(), service)
+		if err != nil {
+			return err
+		}
+
+		if protocol != "http" && protocol != "http2" && protocol != "grpc" {
+			return fmt.Errorf("discovery chain %q uses a protocol %q that does not permit advanced routing or splitting behavior", service, protocol)
+		}
+	}
+
+	return nil
+}
+
+// getProtocol returns the protocol of the service from its service defaults or from the global proxy defaults.
+func (r *fakeConsulK8sReconciler) getProtocol(ctx context.Context, namespace, service string) (string, error) {
+	serviceDefaults := &consulk8s.ServiceDefaults{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: service}, serviceDefaults)
+	if err != nil && !errors.IsNotFound(err) {
+		return "", err
+	}
+
+	if err == nil && len(serviceDefaults.Spec.Protocol) > 0 {
+		return serviceDefaults.Spec.Protocol, nil
+	}
+
+	proxyDefaults := &consulk8s.ProxyDefaults{}
+	err = r.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: common.Global}, proxyDefaults)
+	if err != nil && !errors.IsNotFound(err) {
+		return "", err
+	}
+
+	config := struct {
+		Protocol string `json:"protocol"`
+	}{}
+	if err == nil && len(proxyDefaults.Spec.Config) > 0 {
+		_ = json.Unmarshal(proxyDefaults.Spec.Config, &config)
+	}
+
+	if len(config.Protocol) > 0 {
+		return config.Protocol, nil
+	}
+
+	return "tcp", nil
+}
+
+// newProtocolMapFunc returns a MapFunc which maps a change of the protocol of the services
+// to requests for all resources of the reconciled type in the same namespace.
+func (r *fakeConsulK8sReconciler) newProtocolMapFunc() handler.MapFunc {
+	listType := reflect.TypeOf(consulk8s.ServiceRouterList{})
+	if r.resourceType == reflect.TypeOf(consulk8s.ServiceSplitter{}) {
+		listType = reflect.TypeOf(consulk8s.ServiceSplitterList{})
+	}
+
+	mapFunc := func(obj client.Object) []ctrl.Request {
+		list := reflect.New(listType).Interface().(client.ObjectList)
+		err := r.client.List(context.Background(), list, client.InNamespace(obj.GetNamespace()))
+		if err != nil {
+			r.log.Error(err, "failed to list the resources affected by the protocol change")
+
+			return nil
+		}
+
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			r.log.Error(err, "failed to list the resources affected by the protocol change")
+
+			return nil
+		}
+
+		requests := []ctrl.Request{}
+		for _, item := range items {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(item.(client.Object))})
+		}
+
+		return requests
+	}
+
+	return mapFunc
+}
+
+// StartFakeConsulK8s starts an in-process stand-in for consul-k8s, which sets the Synced condition
+// of the consul-k8s resources without a Consul agent. When validate is true the resources are validated
+// the way Consul would, e.g. the service routers for services without an http protocol aren't synced.
+// The stand-in runs until StopFakeConsulK8s is called.
+func StartFakeConsulK8s(k8sClient client.Client, validate bool) error {
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), manager.Options{
+		Scheme:             k8sClient.Scheme(),
+		MetricsBindAddress: "0",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, resourceType := range consulK8sResourceTypes {
+		r := &fakeConsulK8sReconciler{
+			client:       mgr.GetClient(),
+			log:          ctrl.Log.WithName("fake-consul-k8s").WithName(resourceType.KubeKind()),
+			resourceType: reflect.TypeOf(resourceType).Elem(),
+			validate:     validate,
+		}
+
+		builder := ctrl.NewControllerManagedBy(mgr).
+			Named(fmt.Sprintf("fake-consul-k8s-%s", resourceType.KubeKind())).
+			For(resourceType)
+
+		switch resourceType.(type) {
+		case *consulk8s.ServiceRouter, *consulk8s.ServiceSplitter:
+			protocolChanges := handler.EnqueueRequestsFromMapFunc(r.newProtocolMapFunc())
+			builder = builder.
+				Watches(&source.Kind{Type: &consulk8s.ServiceDefaults{}}, protocolChanges).
+				Watches(&source.Kind{Type: &consulk8s.ProxyDefaults{}}, protocolChanges)
+		}
+
+		err = builder.Complete(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopFakeConsulK8s = cancel
+
+	go func() {
+		defer ginkgo.GinkgoRecover()
+
+		err := mgr.Start(ctx)
+		gomega.Expect(err).ToNot(gomega.HaveOccurred())
+	}()
+
+	return nil
+}
+
+// StopFakeConsulK8s stops the stand-in for consul-k8s started by StartFakeConsulK8s.
+func StopFakeConsulK8s() {
+	if stopFakeConsulK8s != nil {
+		stopFakeConsulK8s()
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutils
+
+import (
+	"context"
+	"fmt"
+
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetIngressGateway ...
+func GetIngressGateway(ctx context.Context, k8sClient client.Client, name string) (*consulk8s.IngressGateway, error) {
+	ig := new(consulk8s.IngressGateway)
+	exists, err := getK8sObject(ctx, k8sClient, name, ig)
+	if !exists {
+		ig = nil
+	}
+
+	return ig, err
+}
+
+// WaitForIngressGatewayToBeCreated ...
+func WaitForIngressGatewayToBeCreated(ctx context.Context, k8sClient client.Client, name string) error {
+	ingressGateway := new(consulk8s.IngressGateway)
+	hasTimedOut := retryWithSleep(func() bool {
+		exists, _ := getK8sObject(ctx, k8sClient, name, ingressGateway)
+
+		return exists
+	})
+
+	if hasTimedOut {
+		return fmt.Errorf("IngressGateway creation timeout exceeded")
+	}
+
+	return nil
+}
+
+// CreateIngressService ...
+func CreateIngressService(service string, hosts ...string) consulk8s.IngressService {
+	result := consulk8s.IngressService{
+		Name:  service,
+		Hosts: hosts,
+	}
+
+	return result
+}
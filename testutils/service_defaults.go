@@ -65,3 +65,14 @@ func DeleteServiceDefaults(ctx context.Context, k8sClient client.Client, service
 
 	return err
 }
+
+// GetServiceDefaults ...
+func GetServiceDefaults(ctx context.Context, k8sClient client.Client, service string) (*consulk8s.ServiceDefaults, error) {
+	sd := new(consulk8s.ServiceDefaults)
+	exists, err := getK8sObject(ctx, k8sClient, service, sd)
+	if !exists {
+		sd = nil
+	}
+
+	return sd, err
+}
@@ -21,9 +21,35 @@ import (
 	"fmt"
 
 	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// CreateServiceIntentions creates a ServiceIntentions outside of the controller.
+func CreateServiceIntentions(ctx context.Context, k8sClient client.Client, name string, sources []*consulk8s.SourceIntention) error {
+	serviceIntentions := &consulk8s.ServiceIntentions{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: DefaultK8sNamespace,
+		},
+		Spec: consulk8s.ServiceIntentionsSpec{
+			Destination: consulk8s.Destination{Name: name},
+			Sources:     sources,
+		},
+	}
+
+	err := k8sClient.Create(ctx, serviceIntentions)
+
+	return err
+}
+
+// DeleteServiceIntentions ...
+func DeleteServiceIntentions(ctx context.Context, k8sClient client.Client, name string) error {
+	err := deleteK8sObject(ctx, k8sClient, name, new(consulk8s.ServiceIntentions))
+
+	return err
+}
+
 // GetServiceIntentions ...
 func GetServiceIntentions(ctx context.Context, k8sClient client.Client, name string) (*consulk8s.ServiceIntentions, error) {
 	serviceIntentions := new(consulk8s.ServiceIntentions)
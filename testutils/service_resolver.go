@@ -0,0 +1,61 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutils
+
+import (
+	"context"
+	"fmt"
+
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetServiceResolver ...
+func GetServiceResolver(ctx context.Context, k8sClient client.Client, name string) (*consulk8s.ServiceResolver, error) {
+	sr := new(consulk8s.ServiceResolver)
+	exists, err := getK8sObject(ctx, k8sClient, name, sr)
+	if !exists {
+		sr = nil
+	}
+
+	return sr, err
+}
+
+// WaitForServiceResolverToBeCreated ...
+func WaitForServiceResolverToBeCreated(ctx context.Context, k8sClient client.Client, name string) error {
+	serviceResolver := new(consulk8s.ServiceResolver)
+	hasTimedOut := retryWithSleep(func() bool {
+		exists, _ := getK8sObject(ctx, k8sClient, name, serviceResolver)
+
+		return exists
+	})
+
+	if hasTimedOut {
+		return fmt.Errorf("ServiceResolver creation timeout exceeded")
+	}
+
+	return nil
+}
+
+// CreateVersionSubset ...
+func CreateVersionSubset(version string) consulk8s.ServiceResolverSubset {
+	result := consulk8s.ServiceResolverSubset{
+		Filter: fmt.Sprintf("Service.Meta.version == %q", version),
+	}
+
+	return result
+}
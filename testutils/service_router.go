@@ -21,9 +21,34 @@ import (
 	"fmt"
 
 	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// CreateServiceRouter creates a ServiceRouter outside of the controller.
+func CreateServiceRouter(ctx context.Context, k8sClient client.Client, name string, routes []consulk8s.ServiceRoute) error {
+	serviceRouter := &consulk8s.ServiceRouter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: DefaultK8sNamespace,
+		},
+		Spec: consulk8s.ServiceRouterSpec{
+			Routes: routes,
+		},
+	}
+
+	err := k8sClient.Create(ctx, serviceRouter)
+
+	return err
+}
+
+// DeleteServiceRouter ...
+func DeleteServiceRouter(ctx context.Context, k8sClient client.Client, name string) error {
+	err := deleteK8sObject(ctx, k8sClient, name, new(consulk8s.ServiceRouter))
+
+	return err
+}
+
 // GetServiceRouter ...
 func GetServiceRouter(ctx context.Context, k8sClient client.Client, name string) (*consulk8s.ServiceRouter, error) {
 	sr := new(consulk8s.ServiceRouter)
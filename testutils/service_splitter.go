@@ -0,0 +1,62 @@
+/*
+Copyright 2021 Progress Software Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testutils
+
+import (
+	"context"
+	"fmt"
+
+	consulk8s "github.com/hashicorp/consul-k8s/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetServiceSplitter ...
+func GetServiceSplitter(ctx context.Context, k8sClient client.Client, name string) (*consulk8s.ServiceSplitter, error) {
+	ss := new(consulk8s.ServiceSplitter)
+	exists, err := getK8sObject(ctx, k8sClient, name, ss)
+	if !exists {
+		ss = nil
+	}
+
+	return ss, err
+}
+
+// WaitForServiceSplitterToBeCreated ...
+func WaitForServiceSplitterToBeCreated(ctx context.Context, k8sClient client.Client, name string) error {
+	serviceSplitter := new(consulk8s.ServiceSplitter)
+	hasTimedOut := retryWithSleep(func() bool {
+		exists, _ := getK8sObject(ctx, k8sClient, name, serviceSplitter)
+
+		return exists
+	})
+
+	if hasTimedOut {
+		return fmt.Errorf("ServiceSplitter creation timeout exceeded")
+	}
+
+	return nil
+}
+
+// CreateServiceSplit ...
+func CreateServiceSplit(service string, weight float32) consulk8s.ServiceSplit {
+	result := consulk8s.ServiceSplit{
+		Service: service,
+		Weight:  weight,
+	}
+
+	return result
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package testutils
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"os"
@@ -44,8 +45,9 @@ var assetsDir = os.Getenv("ENVTEST_ASSETS_DIR")
 var testBinDir = path.Join(assetsDir, "bin")
 var kubeconfigPath = path.Join(assetsDir, "kubeconfig.json")
 
-// StartControllers ...
-func StartControllers(k8sClient client.Client) {
+// StartControllers starts the controllers with the adoption mode and returns a function which stops them.
+// The controllers which don't support adoption always overwrite their destinations.
+func StartControllers(k8sClient client.Client, adoptionMode string) func() {
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), manager.Options{
 		Scheme:             k8sClient.Scheme(),
 		MetricsBindAddress: "0",
@@ -53,29 +55,101 @@ func StartControllers(k8sClient client.Client) {
 	gomega.Expect(err).NotTo(gomega.HaveOccurred())
 
 	consulServiceRouterController := &service.ConsulServiceRouteReconciler{
-		Client: k8sClient,
-		Log:    ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulServiceRoute"),
-		Scheme: mgr.GetScheme(),
+		Client:       k8sClient,
+		Log:          ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulServiceRoute"),
+		Scheme:       mgr.GetScheme(),
+		AdoptionMode: adoptionMode,
+		Recorder:     mgr.GetEventRecorderFor("consulserviceroute-controller"),
 	}
 
 	err = consulServiceRouterController.SetupWithManager(mgr)
 	gomega.Expect(err).NotTo(gomega.HaveOccurred())
 
 	consulServiceIntentionsSource := &service.ConsulServiceIntentionsSourceReconciler{
-		Client: k8sClient,
-		Log:    ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulServiceIntentionsSource"),
-		Scheme: mgr.GetScheme(),
+		Client:       k8sClient,
+		Log:          ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulServiceIntentionsSource"),
+		Scheme:       mgr.GetScheme(),
+		AdoptionMode: adoptionMode,
+		Recorder:     mgr.GetEventRecorderFor("consulserviceintentionssource-controller"),
 	}
 
 	err = consulServiceIntentionsSource.SetupWithManager(mgr)
 	gomega.Expect(err).NotTo(gomega.HaveOccurred())
 
+	consulServiceSplit := &service.ConsulServiceSplitReconciler{
+		Client:   k8sClient,
+		Log:      ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulServiceSplit"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("consulservicesplit-controller"),
+	}
+
+	err = consulServiceSplit.SetupWithManager(mgr)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	consulServiceResolverSubset := &service.ConsulServiceResolverSubsetReconciler{
+		Client:   k8sClient,
+		Log:      ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulServiceResolverSubset"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("consulserviceresolversubset-controller"),
+	}
+
+	err = consulServiceResolverSubset.SetupWithManager(mgr)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	consulIngressGatewayService := &service.ConsulIngressGatewayServiceReconciler{
+		Client:   k8sClient,
+		Log:      ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulIngressGatewayService"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("consulingressgatewayservice-controller"),
+	}
+
+	err = consulIngressGatewayService.SetupWithManager(mgr)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	consulTerminatingGatewayService := &service.ConsulTerminatingGatewayServiceReconciler{
+		Client:   k8sClient,
+		Log:      ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulTerminatingGatewayService"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("consulterminatinggatewayservice-controller"),
+	}
+
+	err = consulTerminatingGatewayService.SetupWithManager(mgr)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	consulConfigFragment := &service.ConsulConfigFragmentReconciler{
+		Client:   k8sClient,
+		Log:      ctrl.Log.WithName("controllers").WithName("service").WithName("ConsulConfigFragment"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("consulconfigfragment-controller"),
+	}
+
+	err = consulConfigFragment.SetupWithManager(mgr)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+
 	go func() {
 		defer ginkgo.GinkgoRecover()
+		defer close(stopped)
 
-		err = mgr.Start(ctrl.SetupSignalHandler())
+		err := mgr.Start(ctx)
 		gomega.Expect(err).ToNot(gomega.HaveOccurred())
 	}()
+
+	stop := func() {
+		cancel()
+		<-stopped
+	}
+
+	return stop
+}
+
+// RestartControllers stops the running controllers and starts them with the adoption mode.
+func RestartControllers(k8sClient client.Client, stop func(), adoptionMode string) func() {
+	stop()
+
+	return StartControllers(k8sClient, adoptionMode)
 }
 
 // CreateAndSetTestKubeconfig ...